@@ -10,6 +10,8 @@ import (
 	"unsafe"
 
 	"bit-labs.cn/owl/provider/appconf"
+	"bit-labs.cn/owl/provider/socketio"
+	"bit-labs.cn/owl/provider/translator"
 	"bit-labs.cn/owl/provider/validator"
 
 	"bit-labs.cn/owl/contract/foundation"
@@ -35,6 +37,16 @@ type SubApp interface {
 	Bootstrap()
 }
 
+// ValidationRuleProvider 子应用可选实现此接口，注册自定义校验规则，容器中的验证器和请求绑定同时生效
+type ValidationRuleProvider interface {
+	ValidationRules() []validator.Rule
+}
+
+// SocketIONamespaceProvider 子应用可选实现此接口，注册 Socket.IO 命名空间，需同时注册 SocketIOServiceProvider
+type SocketIONamespaceProvider interface {
+	SocketIONamespaces() []socketio.Namespace
+}
+
 const (
 	version = "1.0.0"
 	/**
@@ -142,6 +154,8 @@ type Application struct {
 	rootCmd         *cobra.Command
 	binds           []any
 	menus           []*router.Menu
+	baseProviders   []foundation.ServiceProvider
+	locale          string
 
 	l logContract.Logger
 }
@@ -225,9 +239,12 @@ func (i *Application) Booted(callback func(application foundation.Application))
 	i.bootedCallbacks = append(i.bootedCallbacks, callback)
 }
 
+// GetLocale 应用的默认语言，未设置时为 zh
 func (i *Application) GetLocale() string {
-	//TODO implement me
-	panic("implement me")
+	if i.locale == "" {
+		return "zh"
+	}
+	return i.locale
 }
 
 func (i *Application) GetProviders(provider interface{}) []interface{} {
@@ -240,9 +257,9 @@ func (i *Application) HasBeenBootstrapped() bool {
 	panic("implement me")
 }
 
+// SetLocale 设置应用的默认语言
 func (i *Application) SetLocale(locale string) {
-	//TODO implement me
-	panic("implement me")
+	i.locale = locale
 }
 
 func (i *Application) Terminating(callback interface{}) foundation.Application {
@@ -314,6 +331,11 @@ func (i *Application) Run() {
 	PanicIf(err)
 }
 
+// Commands 返回框架与子应用注册的所有命令，可以添加到安装程序的根命令中
+func (i *Application) Commands() []*cobra.Command {
+	return i.rootCmd.Commands()
+}
+
 func (i *Application) ShowProviders() {
 	i.l.Info("============================已注册服务提供者==========================================")
 	for _, provider := range i.serviceProvider {
@@ -325,17 +347,23 @@ func (i *Application) ShowProviders() {
 
 }
 
-// 注册基础服务提供者
-func (i *Application) registerBaseServiceProviders() {
-	var baseProviders = []foundation.ServiceProvider{
+// baseServiceProviders 框架内置的基础服务提供者
+func baseServiceProviders() []foundation.ServiceProvider {
+	return []foundation.ServiceProvider{
 		&conf.ConfServiceProvider{},
 		&log.LogServiceProvider{},
 		&event.EventServiceProvider{},
 		&appconf.AppConfigServiceProvider{},
 		&validator.ValidatorServiceProvider{},
+		&translator.TranslatorServiceProvider{},
 	}
+}
+
+// 注册基础服务提供者
+func (i *Application) registerBaseServiceProviders() {
+	i.baseProviders = baseServiceProviders()
 
-	i.bootServiceProviders(baseProviders...)
+	i.bootServiceProviders(i.baseProviders...)
 
 	err := i.Invoke(func(l logContract.Logger) {
 		i.l = l
@@ -346,6 +374,7 @@ func (i *Application) registerBaseServiceProviders() {
 
 func (i *Application) bootServiceProviders(provider ...foundation.ServiceProvider) {
 
+	var generated []string
 	for _, serviceProvider := range provider {
 		i.injectAppInstance(serviceProvider)
 		serviceProvider.Register()
@@ -356,11 +385,23 @@ func (i *Application) bootServiceProviders(provider ...foundation.ServiceProvide
 				confFile := i.GetConfigPath() + "/" + fileName
 				_, err := os.Stat(confFile)
 				if os.IsNotExist(err) {
-					_ = os.WriteFile(confFile, []byte(content), 0644)
+					if err = os.WriteFile(confFile, []byte(content), 0644); err == nil {
+						generated = append(generated, fileName)
+					}
 				}
 			}
 		}
 	}
+
+	// 日志已初始化说明配置管理器已经加载过配置目录，新生成的配置文件需要补充加载
+	if i.l != nil && len(generated) > 0 {
+		err := i.Invoke(func(c *conf.Configure) {
+			for _, fileName := range generated {
+				c.LoadFile(fileName)
+			}
+		})
+		PanicIf(err)
+	}
 }
 
 func (i *Application) newSubApp(apps ...SubApp) {
@@ -372,6 +413,12 @@ func (i *Application) newSubApp(apps ...SubApp) {
 	for _, app := range i.subApps {
 		i.binds = append(i.binds, app.Binds()...)
 		i.serviceProvider = append(i.serviceProvider, app.ServiceProviders()...)
+		if p, ok := app.(ValidationRuleProvider); ok {
+			validator.RegisterRules(p.ValidationRules()...)
+		}
+		if p, ok := app.(SocketIONamespaceProvider); ok {
+			socketio.RegisterNamespaces(p.SocketIONamespaces()...)
+		}
 	}
 
 	i.bootServiceProviders(i.serviceProvider...)
@@ -381,6 +428,18 @@ func (i *Application) newSubApp(apps ...SubApp) {
 		PanicIf(err)
 	}
 
+	// 在子应用使用配置之前统一校验，避免错误配置在各服务内部以 panic 的形式暴露
+	i.validateConfig()
+	i.rootCmd.AddCommand(NewConfigCheckCommand(apps...))
+	i.rootCmd.AddCommand(i.configCommands()...)
+	i.rootCmd.AddCommand(i.newSeedCommand())
+	i.rootCmd.AddCommand(i.newMakeCrudCommand())
+	for _, provider := range append(append([]foundation.ServiceProvider{}, i.baseProviders...), i.serviceProvider...) {
+		if p, ok := provider.(foundation.CommandProvider); ok {
+			i.rootCmd.AddCommand(p.Commands()...)
+		}
+	}
+
 	for _, app := range i.subApps {
 		app.RegisterRouters()
 		i.menus = append(i.menus, app.Menu()...)
@@ -391,7 +450,7 @@ func (i *Application) newSubApp(apps ...SubApp) {
 	// 将所有子应用的菜单添加到菜单管理器
 	i.menuManager.AddMenu(i.menus...)
 
-	for _, serviceProvider := range i.serviceProvider {
+	for _, serviceProvider := range append(append([]foundation.ServiceProvider{}, i.baseProviders...), i.serviceProvider...) {
 		serviceProvider.Boot()
 	}
 
@@ -0,0 +1,51 @@
+package owl_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"bit-labs.cn/owl"
+	"bit-labs.cn/owl/contract/foundation"
+	"bit-labs.cn/owl/provider/db"
+	"bit-labs.cn/owl/provider/router"
+	"bit-labs.cn/owl/provider/tenant"
+	"github.com/spf13/cobra"
+)
+
+type emptyApp struct {
+	app       foundation.Application
+	providers []foundation.ServiceProvider
+}
+
+func (a *emptyApp) Name() string                                   { return "empty" }
+func (a *emptyApp) RegisterRouters()                               {}
+func (a *emptyApp) ServiceProviders() []foundation.ServiceProvider { return a.providers }
+func (a *emptyApp) Binds() []any                                   { return nil }
+func (a *emptyApp) Menu() []*router.Menu                           { return nil }
+func (a *emptyApp) Commands() []*cobra.Command                     { return nil }
+func (a *emptyApp) Bootstrap()                                     {}
+
+// TestNewAppWithEmptyConfigDir 首次运行时配置文件由服务提供者生成，启动过程不能依赖已有的配置
+func TestNewAppWithEmptyConfigDir(t *testing.T) {
+	t.Chdir(t.TempDir())
+
+	app := owl.NewApp(&emptyApp{providers: []foundation.ServiceProvider{
+		&db.DBServiceProvider{},
+		&tenant.TenantServiceProvider{},
+	}})
+
+	for _, name := range []string{"database.yaml", "tenant.yaml"} {
+		if _, err := os.Stat(filepath.Join(app.GetConfigPath(), name)); err != nil {
+			t.Fatalf("%s 未生成: %v", name, err)
+		}
+	}
+	err := app.Invoke(func(m *db.Manager) {
+		if names := m.Names(); len(names) != 1 || names[0] != db.DefaultConnection {
+			t.Fatalf("connections = %v", names)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,104 @@
+package owl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"bit-labs.cn/owl/contract/foundation"
+	"bit-labs.cn/owl/provider/conf"
+	"bit-labs.cn/owl/utils"
+	"github.com/asaskevich/EventBus"
+	"github.com/spf13/cobra"
+	"go.uber.org/dig"
+)
+
+// collectConfigSchemas 收集服务提供者声明的配置结构体
+func collectConfigSchemas(providers ...foundation.ServiceProvider) map[string]any {
+	schemas := make(map[string]any)
+	for _, provider := range providers {
+		p, ok := provider.(foundation.ConfigSchemaProvider)
+		if !ok {
+			continue
+		}
+		for fileName, schema := range p.ConfSchema() {
+			schemas[fileName] = schema
+		}
+	}
+	return schemas
+}
+
+// validateConfig 校验所有服务提供者声明的配置，存在错误时一次性输出全部错误并终止启动
+// 执行 config:* 命令时跳过，以便在配置错误时使用 config:check 排查、config:set 修改
+func (i *Application) validateConfig() {
+	if isConfigCommand(os.Args[1:]) {
+		return
+	}
+	providers := append(append([]foundation.ServiceProvider{}, i.baseProviders...), i.serviceProvider...)
+	err := i.Invoke(func(c *conf.Configure) error {
+		return c.Validate(collectConfigSchemas(providers...))
+	})
+	if err != nil {
+		utils.PrintLnRed(err.Error())
+		panic(err)
+	}
+}
+
+// isConfigCommand 命令行中的第一个命令是否为 config:*
+func isConfigCommand(args []string) bool {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+		return strings.HasPrefix(arg, "config:")
+	}
+	return false
+}
+
+// NewConfigCheckCommand 创建 config:check 命令
+// 该命令只读取配置目录并按照服务提供者声明的结构体校验，不会注册服务，也不会连接数据库、Redis 等外部服务，
+// 因此配置错误导致应用无法启动时，也可以单独使用此命令排查
+func NewConfigCheckCommand(apps ...SubApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "config:check",
+		Short: "校验配置文件",
+		Long:  "按照各服务提供者声明的配置结构体校验配置目录下的配置文件，并一次性输出所有错误",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return checkConfig(apps...)
+		},
+	}
+}
+
+func checkConfig(apps ...SubApp) error {
+	i := &Application{Container: dig.New()}
+	i.setPath()
+
+	confDir := i.GetConfigPath()
+	if _, err := os.Stat(confDir); err != nil {
+		return fmt.Errorf("配置目录不存在: %s", confDir)
+	}
+
+	providers := baseServiceProviders()
+	for _, app := range apps {
+		providers = append(providers, app.ServiceProviders()...)
+	}
+
+	c := conf.NewConfigure(i, EventBus.New())
+	defer c.Close()
+	err := c.Validate(collectConfigSchemas(providers...))
+
+	var validationErr *conf.ValidationError
+	if errors.As(err, &validationErr) {
+		for _, fe := range validationErr.Errors {
+			utils.PrintLnRed(fe.String())
+		}
+		return fmt.Errorf("配置校验失败，共 %d 处错误", len(validationErr.Errors))
+	}
+	if err != nil {
+		return err
+	}
+
+	utils.PrintLnGreen("配置校验通过")
+	return nil
+}
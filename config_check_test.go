@@ -0,0 +1,21 @@
+package owl
+
+import "testing"
+
+func TestIsConfigCommand(t *testing.T) {
+	cases := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"config:check"}, true},
+		{[]string{"-v", "config:set", "app.name", "owl"}, true},
+		{[]string{"serve"}, false},
+		{[]string{"serve", "config:check"}, false},
+		{nil, false},
+	}
+	for _, c := range cases {
+		if got := isConfigCommand(c.args); got != c.want {
+			t.Errorf("isConfigCommand(%v) = %v, want %v", c.args, got, c.want)
+		}
+	}
+}
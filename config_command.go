@@ -0,0 +1,272 @@
+package owl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"bit-labs.cn/owl/contract/foundation"
+	"bit-labs.cn/owl/provider/conf"
+	"bit-labs.cn/owl/utils"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// configCommands 配置查看与维护相关的命令
+func (i *Application) configCommands() []*cobra.Command {
+	return []*cobra.Command{
+		i.newConfigShowCommand(),
+		i.newConfigSetCommand(),
+		i.newConfigPublishCommand(),
+		i.newEnvCommand(),
+	}
+}
+
+func (i *Application) configure() (*conf.Configure, error) {
+	var c *conf.Configure
+	err := i.Invoke(func(configure *conf.Configure) {
+		c = configure
+	})
+	return c, err
+}
+
+// newConfigShowCommand 查看合并后的最终配置，敏感配置项使用掩码代替
+func (i *Application) newConfigShowCommand() *cobra.Command {
+	var asJSON bool
+	cmd := &cobra.Command{
+		Use:   "config:show [key]",
+		Short: "查看配置",
+		Long:  "查看合并配置目录、配置源与环境变量之后的最终配置，key 为空时输出全部配置，如 router、router.server.port",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := i.configure()
+			if err != nil {
+				return err
+			}
+
+			var value any = c.All(true)
+			if len(args) == 1 {
+				if value, err = lookupConfig(c.All(true), args[0]); err != nil {
+					return err
+				}
+			}
+
+			var out []byte
+			if asJSON {
+				out, err = jsoniter.MarshalIndent(value, "", "  ")
+				out = append(out, '\n')
+			} else {
+				out, err = yaml.Marshal(value)
+			}
+			if err != nil {
+				return err
+			}
+
+			utils.PrintLnYellow("配置来源(优先级从低到高): ", strings.Join(c.Sources(), " < "))
+			fmt.Print(string(out))
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&asJSON, "json", false, "以 json 格式输出")
+	return cmd
+}
+
+// lookupConfig 按照 文件名.配置项 路径查找配置
+func lookupConfig(all map[string]map[string]any, key string) (any, error) {
+	segments := strings.Split(key, ".")
+	cfg, ok := all[segments[0]]
+	if !ok {
+		return nil, fmt.Errorf("配置 %s 不存在", segments[0])
+	}
+
+	var value any = cfg
+	for _, seg := range segments[1:] {
+		m, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("配置项 %s 不存在", key)
+		}
+		if value, ok = m[seg]; !ok {
+			return nil, fmt.Errorf("配置项 %s 不存在", key)
+		}
+	}
+	return value, nil
+}
+
+// newConfigSetCommand 修改配置目录中的配置文件
+func (i *Application) newConfigSetCommand() *cobra.Command {
+	var encrypt bool
+	cmd := &cobra.Command{
+		Use:     "config:set <file> <key> <value>",
+		Short:   "修改配置文件",
+		Long:    "修改配置目录中配置文件的配置项并写回文件，value 按 yaml 标量解析，如 8080、true",
+		Example: "owl config:set router server.port 8080\nowl config:set database password 123456 --encrypt",
+		Args:    cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := i.configure()
+			if err != nil {
+				return err
+			}
+
+			name := strings.TrimSuffix(args[0], filepath.Ext(args[0]))
+			key := args[1]
+			value := conf.ParseScalar(args[2])
+
+			if encrypt {
+				secret, err := conf.EncryptKeyFromEnv()
+				if err != nil {
+					return err
+				}
+				if secret == nil {
+					return errors.New("加密配置项需要设置环境变量 " + conf.EncryptKeyEnv)
+				}
+				if value, err = conf.EncryptValue(args[2], secret); err != nil {
+					return err
+				}
+			}
+
+			if err = c.SaveConfig(name, key, value); err != nil {
+				return err
+			}
+
+			absPath, _ := c.FilePath(name)
+			utils.PrintLnGreen(fmt.Sprintf("已保存 %s %s", absPath, key))
+
+			// 环境变量的优先级高于配置文件，提示修改不会生效
+			for _, env := range c.EnvVariables() {
+				if env.Set && env.Target == filepath.Base(absPath)+" "+key {
+					utils.PrintLnYellow(fmt.Sprintf("注意: 环境变量 %s 已设置，将覆盖此配置项", env.Name))
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&encrypt, "encrypt", false, "使用 "+conf.EncryptKeyEnv+" 加密后保存为 ENC(...)")
+	return cmd
+}
+
+// newConfigPublishCommand 根据服务提供者的配置模板生成配置文件
+func (i *Application) newConfigPublishCommand() *cobra.Command {
+	var force, dryRun bool
+	cmd := &cobra.Command{
+		Use:   "config:publish [file...]",
+		Short: "发布配置模板",
+		Long:  "根据服务提供者的配置模板生成配置目录中缺失的配置文件，已存在且内容不同的文件输出差异，使用 --force 覆盖",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			templates := i.configTemplates()
+			files := args
+			if len(files) == 0 {
+				for fileName := range templates {
+					files = append(files, fileName)
+				}
+				sort.Strings(files)
+			}
+
+			var published []string
+			for _, fileName := range files {
+				content, ok := templates[fileName]
+				if !ok {
+					return fmt.Errorf("没有服务提供者提供配置模板 %s", fileName)
+				}
+
+				confFile := filepath.Join(i.GetConfigPath(), fileName)
+				current, err := os.ReadFile(confFile)
+				exists := err == nil
+				if err != nil && !os.IsNotExist(err) {
+					return err
+				}
+
+				if exists {
+					diff := utils.UnifiedDiff(confFile, fileName+" (模板)", string(current), content)
+					if diff == "" {
+						utils.PrintLnGreen(fmt.Sprintf("%s 与模板一致", fileName))
+						continue
+					}
+					fmt.Print(diff)
+					if !force {
+						utils.PrintLnYellow(fmt.Sprintf("%s 与模板不一致，使用 --force 覆盖", fileName))
+						continue
+					}
+				}
+
+				if dryRun {
+					utils.PrintLnYellow(fmt.Sprintf("将写入 %s", confFile))
+					continue
+				}
+				if err = os.WriteFile(confFile, []byte(content), 0644); err != nil {
+					return err
+				}
+				utils.PrintLnGreen(fmt.Sprintf("已写入 %s", confFile))
+				if !exists {
+					published = append(published, fileName)
+				}
+			}
+
+			if len(published) > 0 {
+				c, err := i.configure()
+				if err != nil {
+					return err
+				}
+				for _, fileName := range published {
+					c.LoadFile(fileName)
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&force, "force", false, "覆盖与模板不一致的配置文件")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "只输出差异，不写入文件")
+	return cmd
+}
+
+// configTemplates 收集所有服务提供者的配置模板，配置文件名 => 模板内容
+func (i *Application) configTemplates() map[string]string {
+	providers := append(append([]foundation.ServiceProvider{}, i.baseProviders...), i.serviceProvider...)
+	templates := make(map[string]string)
+	for _, provider := range providers {
+		for fileName, content := range provider.Conf() {
+			templates[fileName] = content
+		}
+	}
+	return templates
+}
+
+// newEnvCommand 列出应用识别的全部环境变量
+func (i *Application) newEnvCommand() *cobra.Command {
+	var onlySet bool
+	cmd := &cobra.Command{
+		Use:   "env",
+		Short: "查看环境变量",
+		Long:  "列出应用识别的全部环境变量及当前值，包括框架使用的环境变量、配置文件自动识别的环境变量和 OWL_ 环境变量配置源",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := i.configure()
+			if err != nil {
+				return err
+			}
+
+			for _, envFile := range c.EnvFiles() {
+				utils.PrintLnYellow("已加载环境变量文件: ", envFile)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "环境变量\t配置项\t当前值")
+			for _, env := range c.EnvVariables() {
+				if onlySet && !env.Set {
+					continue
+				}
+				value := env.Value
+				if !env.Set {
+					value = "-"
+				}
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\n", env.Name, env.Target, value)
+			}
+			return w.Flush()
+		},
+	}
+	cmd.Flags().BoolVar(&onlySet, "set", false, "只列出已设置的环境变量")
+	return cmd
+}
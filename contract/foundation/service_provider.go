@@ -1,8 +1,21 @@
 package foundation
 
+import "github.com/spf13/cobra"
+
 type ServiceProvider interface {
 	Register()               // 注册服务
 	Boot()                   // 启动服务，视图，路由等等都可以在这个方法中初始化
 	Conf() map[string]string // 生成配置
 	Description() string     // 服务描述
 }
+
+// ConfigSchemaProvider 服务提供者可选实现此接口，声明配置文件对应的结构体，
+// 结构体中的 validate 标签描述校验规则，应用启动时统一校验所有配置
+type ConfigSchemaProvider interface {
+	ConfSchema() map[string]any // 配置文件名 => 配置结构体指针，文件名与 Conf() 中的一致
+}
+
+// CommandProvider 服务提供者可选实现此接口，提供的命令会添加到应用的根命令中
+type CommandProvider interface {
+	Commands() []*cobra.Command
+}
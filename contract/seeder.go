@@ -0,0 +1,9 @@
+package contract
+
+import "gorm.io/gorm"
+
+// Seeder 数据填充，用于初始化管理员账号、演示数据、集成测试数据等
+type Seeder interface {
+	Name() string          // 名称，db:seed --class 按名称筛选
+	Run(db *gorm.DB) error // 在事务中执行
+}
@@ -0,0 +1,250 @@
+package owl
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"bit-labs.cn/owl/utils"
+	"github.com/spf13/cobra"
+)
+
+//go:embed stubs/crud/*.tmpl
+var crudStubs embed.FS
+
+// crudFieldTypes make:crud 支持的字段类型，值为模型中的 Go 类型
+var crudFieldTypes = map[string]string{
+	"string":  "string",
+	"text":    "string",
+	"int":     "int",
+	"int64":   "int64",
+	"uint":    "uint",
+	"float":   "float64",
+	"float64": "float64",
+	"bool":    "bool",
+	"time":    "*time.Time",
+}
+
+// crudFilterOps 过滤操作符，与 db.ApplyFilter 支持的操作符一致
+var crudFilterOps = []string{"eq", "ne", "gt", "gte", "lt", "lte", "like", "prefix", "suffix", "in", "notin", "between", "null"}
+
+// crudBaseColumns db.BaseModel 中的列
+var crudBaseColumns = []string{"id", "created_at", "updated_at", "deleted_at", "creator_id", "creator_name", "updater_id", "updater_name"}
+
+type crudField struct {
+	Name       string // 字段名，如 CreatedBy
+	Column     string // 列名，如 created_by
+	JSON       string // json 名称，如 createdBy
+	Kind       string // 命令中的类型，如 text
+	Type       string // Go 类型
+	Op         string // 过滤操作符，为空时不生成过滤字段
+	FilterType string
+	FormKey    string
+}
+
+// ModelTag 模型字段的标签
+func (i crudField) ModelTag() string {
+	if i.Kind == "text" {
+		return fmt.Sprintf(`gorm:"type:text" json:"%s"`, i.JSON)
+	}
+	return fmt.Sprintf(`json:"%s"`, i.JSON)
+}
+
+type crudData struct {
+	Package string // 包名，如 orderitem
+	Name    string // 模型名称，如 OrderItem
+	Var     string // 模块英文名称，如 orderItem
+	Path    string // 路由路径，如 order-item
+	Zh      string // 模块中文名称
+	Fields  []crudField
+}
+
+func (i *crudData) NeedTime() bool {
+	for _, f := range i.Fields {
+		if f.Kind == "time" {
+			return true
+		}
+	}
+	return false
+}
+
+// SortColumns 允许排序的列
+func (i *crudData) SortColumns() string {
+	columns := []string{"id", "created_at", "updated_at"}
+	for _, f := range i.Fields {
+		if f.Kind != "text" {
+			columns = append(columns, f.Column)
+		}
+	}
+	return strings.Join(columns, ",")
+}
+
+// newCrudData 解析模型名称和字段定义，fields 格式为 "name:string:like,age:int:between"，操作符可以省略
+func newCrudData(name, zh, fields string) (*crudData, error) {
+	name = utils.FirstUpper(name)
+	if !token.IsIdentifier(name) {
+		return nil, fmt.Errorf("模型名称 %s 不合法", name)
+	}
+	snake := utils.Cc2Udl(name)
+	data := &crudData{
+		Package: strings.ReplaceAll(snake, "_", ""),
+		Name:    name,
+		Var:     utils.FirstLower(name),
+		Path:    strings.ReplaceAll(snake, "_", "-"),
+		Zh:      zh,
+	}
+	if data.Zh == "" {
+		data.Zh = name
+	}
+	// 中文名称会写入注释，不能包含换行
+	if strings.ContainsAny(data.Zh, "\r\n") {
+		return nil, fmt.Errorf("模块中文名称 %q 不能包含换行", data.Zh)
+	}
+
+	seen := map[string]bool{}
+	for _, item := range strings.Split(fields, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		parts := strings.Split(item, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("字段 %s 格式错误，应为 名称:类型[:操作符]", item)
+		}
+		f := crudField{Name: utils.FirstUpper(utils.UnderscoreToCamel(parts[0])), Kind: parts[1]}
+		if !token.IsIdentifier(f.Name) {
+			return nil, fmt.Errorf("字段名称 %s 不合法", parts[0])
+		}
+		f.Column = utils.Cc2Udl(f.Name)
+		if seen[f.Column] || slices.Contains(crudBaseColumns, f.Column) {
+			return nil, fmt.Errorf("字段 %s 重复或与 BaseModel 中的字段冲突", parts[0])
+		}
+		seen[f.Column] = true
+
+		var ok bool
+		if f.Type, ok = crudFieldTypes[f.Kind]; !ok {
+			return nil, fmt.Errorf("字段 %s 的类型 %s 不支持", parts[0], f.Kind)
+		}
+		f.JSON = utils.FirstLower(f.Name)
+		f.FormKey = f.JSON
+
+		if len(parts) == 3 {
+			f.Op = parts[2]
+			if !slices.Contains(crudFilterOps, f.Op) {
+				return nil, fmt.Errorf("字段 %s 的操作符 %s 不支持", parts[0], f.Op)
+			}
+			f.FilterType = crudFilterType(f)
+			if strings.HasPrefix(f.FilterType, "[]") {
+				f.FormKey += "[]"
+			}
+		}
+		data.Fields = append(data.Fields, f)
+	}
+	return data, nil
+}
+
+// crudFilterType 过滤字段的类型，区间和列表为切片，非字符串的等值条件使用指针以便按零值过滤
+func crudFilterType(f crudField) string {
+	elem := f.Type
+	if f.Kind == "time" {
+		elem = "string"
+	}
+	switch f.Op {
+	case "between", "in", "notin":
+		return "[]" + elem
+	case "like", "prefix", "suffix":
+		return "string"
+	case "null":
+		return "*bool"
+	}
+	if elem == "string" {
+		return elem
+	}
+	return "*" + elem
+}
+
+// render 渲染模板，返回文件名和格式化后的代码
+func (i *crudData) render() (map[string][]byte, error) {
+	tpl, err := template.ParseFS(crudStubs, "stubs/crud/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{}
+	for _, stub := range []string{"model", "repository", "handle", "router"} {
+		var buf bytes.Buffer
+		if err = tpl.ExecuteTemplate(&buf, stub+".go.tmpl", i); err != nil {
+			return nil, err
+		}
+		code, err := format.Source(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("格式化 %s 失败: %w", stub, err)
+		}
+		files[stub+".go"] = code
+	}
+	return files, nil
+}
+
+// newMakeCrudCommand 创建 make:crud 命令，生成模型、查询条件、仓库、控制器和路由
+func (i *Application) newMakeCrudCommand() *cobra.Command {
+	var fields, zh, dir string
+	var force bool
+	cmd := &cobra.Command{
+		Use:     "make:crud <Name>",
+		Short:   "生成增删改查代码",
+		Long:    "生成嵌入 db.BaseModel 的模型、请求、查询条件、仓库、实现 router.CrudHandler 的控制器以及路由和菜单注册",
+		Example: `owl make:crud User --zh 用户 --fields "name:string:like,age:int:between,remark:text"`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := newCrudData(args[0], zh, fields)
+			if err != nil {
+				return err
+			}
+			files, err := data.render()
+			if err != nil {
+				return err
+			}
+
+			if dir == "" {
+				dir = filepath.Join("app", data.Package)
+			}
+			if !force {
+				for name := range files {
+					if _, err = os.Stat(filepath.Join(dir, name)); err == nil {
+						return fmt.Errorf("文件 %s 已存在，覆盖请添加 --force", filepath.Join(dir, name))
+					} else if !errors.Is(err, os.ErrNotExist) {
+						return err
+					}
+				}
+			}
+			if err = os.MkdirAll(dir, 0755); err != nil {
+				return err
+			}
+			for _, name := range []string{"model.go", "repository.go", "handle.go", "router.go"} {
+				if err = os.WriteFile(filepath.Join(dir, name), files[name], 0644); err != nil {
+					return err
+				}
+				utils.PrintLnGreen("已生成: ", filepath.Join(dir, name))
+			}
+
+			utils.PrintLnYellow("请在子应用中完成注册：")
+			fmt.Printf("  Binds:           %[1]s.New%[2]sRepository, %[1]s.New%[2]sHandle\n", data.Package, data.Name)
+			fmt.Printf("  RegisterRouters: menu := %s.Register%sRoutes(appName, group, handle)\n", data.Package, data.Name)
+			fmt.Println("  Menu:            返回 RegisterRouters 中得到的菜单")
+			fmt.Printf("  Migration:       conn.AutoMigrate(&%s.%s{})\n", data.Package, data.Name)
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&fields, "fields", "", "字段定义，格式为 名称:类型[:操作符]，多个字段使用逗号分隔，类型: string text int int64 uint float bool time")
+	cmd.Flags().StringVar(&zh, "zh", "", "模块中文名称，用于菜单和接口名称，默认使用模型名称")
+	cmd.Flags().StringVar(&dir, "dir", "", "输出目录，默认为 app/<包名>")
+	cmd.Flags().BoolVar(&force, "force", false, "覆盖已存在的文件")
+	return cmd
+}
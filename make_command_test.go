@@ -0,0 +1,72 @@
+package owl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewCrudData(t *testing.T) {
+	data, err := newCrudData("orderItem", "订单明细", "name:string:like, age:int:between,status:int:eq,paid_at:time:between,remark:text")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Package != "orderitem" || data.Name != "OrderItem" || data.Var != "orderItem" || data.Path != "order-item" {
+		t.Fatalf("data = %+v", data)
+	}
+	if len(data.Fields) != 5 || data.Fields[3].Name != "PaidAt" || data.Fields[3].Column != "paid_at" {
+		t.Fatalf("fields = %+v", data.Fields)
+	}
+
+	files, err := data.render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	model := string(files["model.go"])
+	for _, want := range []string{
+		"\"time\"",
+		"db.BaseModel",
+		"`gorm:\"type:text\" json:\"remark\"`",
+		"type OrderItemReq struct",
+		"PaidAt: i.PaidAt,",
+		"`form:\"name\" filter:\"name,like\"`",
+		"[]int    `form:\"age[]\" filter:\"age,between\"`",
+		"*int     `form:\"status\" filter:\"status,eq\"`",
+		"[]string `form:\"paidAt[]\" filter:\"paid_at,between\"`",
+		"sort:\"id,created_at,updated_at,name,age,status,paid_at\"",
+	} {
+		if !strings.Contains(model, want) {
+			t.Errorf("model.go missing %s\n%s", want, model)
+		}
+	}
+	// 请求只绑定命令中声明的字段，不包含 BaseModel
+	if handle := string(files["handle.go"]); strings.Contains(handle, "var req OrderItem\n") || !strings.Contains(handle, "var req OrderItemReq") {
+		t.Errorf("handle.go:\n%s", handle)
+	}
+	if !strings.Contains(string(files["router.go"]), `group.Group("/order-item")`) {
+		t.Errorf("router.go:\n%s", files["router.go"])
+	}
+
+	// 中文名称中的引号被转义，生成的代码可以编译
+	data, err = newCrudData("Tag", `标签"x`, "name:string")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if files, err = data.render(); err != nil {
+		t.Fatal(err)
+	}
+	if handle := string(files["handle.go"]); !strings.Contains(handle, `return "tag", "标签\"x"`) || !strings.Contains(handle, "if id == 0") {
+		t.Errorf("handle.go:\n%s", handle)
+	}
+	if !strings.Contains(string(files["router.go"]), `Name("标签\"x列表")`) {
+		t.Errorf("router.go:\n%s", files["router.go"])
+	}
+	if _, err = newCrudData("Tag", "标签\n// x", "name:string"); err == nil {
+		t.Error("zh with newline should be invalid")
+	}
+
+	for _, fields := range []string{"name", "name:char", "name:string:regex", "id:uint", "createdAt:time", "1a:int", "a:int,a:string"} {
+		if _, err = newCrudData("User", "", fields); err == nil {
+			t.Errorf("fields %q should be invalid", fields)
+		}
+	}
+}
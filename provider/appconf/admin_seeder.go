@@ -0,0 +1,43 @@
+package appconf
+
+import (
+	"errors"
+
+	"bit-labs.cn/owl/contract"
+	"gorm.io/gorm"
+)
+
+var _ contract.Seeder = (*AdminSeeder[struct{}])(nil)
+
+// AdminSeeder 根据 app.yaml 中的 admin 配置创建管理员账号，账号已存在时跳过
+// 框架不包含用户模型，由用户模块提供模型的构造方法
+type AdminSeeder[T any] struct {
+	opt    *Options
+	column string
+	build  func(admin AdminOptions) *T
+}
+
+// NewAdminSeeder column 为用户名所在的列，build 根据配置构造管理员模型
+func NewAdminSeeder[T any](opt *Options, column string, build func(admin AdminOptions) *T) *AdminSeeder[T] {
+	return &AdminSeeder[T]{opt: opt, column: column, build: build}
+}
+
+func (i *AdminSeeder[T]) Name() string {
+	return "admin"
+}
+
+func (i *AdminSeeder[T]) Run(db *gorm.DB) error {
+	admin := i.opt.Admin
+	if admin.Username == "" || admin.Password == "" {
+		return errors.New("app.yaml 中未配置 admin.username 或 admin.password")
+	}
+
+	var count int64
+	if err := db.Model(new(T)).Where(db.Statement.Quote(i.column)+" = ?", admin.Username).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+	return db.Create(i.build(admin)).Error
+}
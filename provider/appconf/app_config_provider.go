@@ -4,6 +4,7 @@ import (
 	_ "embed"
 
 	"bit-labs.cn/owl/contract/foundation"
+	"bit-labs.cn/owl/provider/conf"
 )
 
 type AppConfigServiceProvider struct {
@@ -17,7 +18,11 @@ func (s AppConfigServiceProvider) Description() string {
 var _ foundation.ServiceProvider = (*AppConfigServiceProvider)(nil)
 
 func (s AppConfigServiceProvider) Register() {
-
+	s.app.Register(func(c *conf.Configure) (*Options, error) {
+		var opt Options
+		err := c.GetConfig("app", &opt)
+		return &opt, err
+	})
 }
 
 func (s AppConfigServiceProvider) Boot() {
@@ -0,0 +1,16 @@
+package appconf
+
+// Options app.yaml 配置
+type Options struct {
+	AppName string       `json:"app-name"`
+	AppEnv  string       `json:"app-env"`
+	Locale  string       `json:"locale"` // 默认语言，请求没有指定语言或语言没有对应翻译时使用
+	Admin   AdminOptions `json:"admin"`
+}
+
+// AdminOptions 初始管理员账号
+type AdminOptions struct {
+	Username string `json:"username"`
+	Password string `json:"password"` // bcrypt 加密后的密码
+	Mark     string `json:"mark"`
+}
@@ -64,6 +64,12 @@ func (i *CaptchaServiceProvider) Conf() map[string]string {
 	}
 }
 
+func (i *CaptchaServiceProvider) ConfSchema() map[string]any {
+	return map[string]any{
+		"captcha.yaml": &Options{},
+	}
+}
+
 // newStore 创建验证码存储实现
 func newStore(opt Options, client redis.UniversalClient) (cache_captcha.CaptchaStore, error) {
 	store := strings.TrimSpace(strings.ToLower(opt.Store))
@@ -42,13 +42,13 @@ type RotateCaptchaResp struct {
 }
 
 type Options struct {
-	Enabled         bool   `json:"enabled"`          // 是否启用
-	TTL             int    `json:"ttl"`              // 过期时间(秒)
-	Type            string `json:"type"`             // 默认验证码类型
-	Mode            string `json:"mode"`             // 默认生成模式
-	Padding         int    `json:"padding"`          // 校验容差
-	Store           string `json:"store"`            // 存储驱动(memory|redis)
-	CleanupInterval int    `json:"cleanup-interval"` // 清理间隔(秒)
+	Enabled         bool   `json:"enabled"`                                            // 是否启用
+	TTL             int    `json:"ttl"`                                                // 过期时间(秒)
+	Type            string `json:"type" validate:"omitempty,oneof=click slide rotate"` // 默认验证码类型
+	Mode            string `json:"mode" validate:"omitempty,oneof=text image"`         // 默认生成模式
+	Padding         int    `json:"padding"`                                            // 校验容差
+	Store           string `json:"store" validate:"omitempty,oneof=memory redis"`      // 存储驱动(memory|redis)
+	CleanupInterval int    `json:"cleanup-interval"`                                   // 清理间隔(秒)
 }
 
 type Service struct {
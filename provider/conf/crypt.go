@@ -0,0 +1,146 @@
+package conf
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// EncryptKeyEnv 配置解密密钥所在的环境变量，值为 16/24/32 字节的密钥，或 base64: 开头的 base64 编码密钥
+const EncryptKeyEnv = "OWL_CONFIG_KEY"
+
+var encValueRegexp = regexp.MustCompile(`^ENC\((.+)\)$`)
+
+// IsEncrypted 判断配置值是否为 ENC(...) 形式的密文
+func IsEncrypted(value string) bool {
+	return encValueRegexp.MatchString(strings.TrimSpace(value))
+}
+
+// EncryptValue 使用 AES-GCM 加密配置值，返回 ENC(...) 形式的密文，可以直接写入配置文件
+func EncryptValue(plain string, key []byte) (string, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plain), nil)
+	return "ENC(" + base64.StdEncoding.EncodeToString(sealed) + ")", nil
+}
+
+// DecryptValue 解密 ENC(...) 形式的配置值
+func DecryptValue(value string, key []byte) (string, error) {
+	matches := encValueRegexp.FindStringSubmatch(strings.TrimSpace(value))
+	if len(matches) != 2 {
+		return "", errors.New("不是 ENC(...) 格式的密文")
+	}
+	sealed, err := base64.StdEncoding.DecodeString(matches[1])
+	if err != nil {
+		return "", fmt.Errorf("密文不是合法的 base64: %w", err)
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", errors.New("密文长度不正确")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("解密失败，请检查密钥是否正确")
+	}
+	return string(plain), nil
+}
+
+// EncryptKeyFromEnv 从环境变量读取配置解密密钥，未设置时返回 nil
+func EncryptKeyFromEnv() ([]byte, error) {
+	raw := strings.TrimSpace(os.Getenv(EncryptKeyEnv))
+	if raw == "" {
+		return nil, nil
+	}
+	return ParseEncryptKey(raw)
+}
+
+// ParseEncryptKey 解析密钥，base64: 开头的按 base64 解码，否则按原始字符串处理
+func ParseEncryptKey(raw string) ([]byte, error) {
+	key := []byte(raw)
+	if strings.HasPrefix(raw, "base64:") {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(raw, "base64:"))
+		if err != nil {
+			return nil, fmt.Errorf("%s 不是合法的 base64: %w", EncryptKeyEnv, err)
+		}
+		key = decoded
+	}
+	if !isValidKeyLen(len(key)) {
+		return nil, fmt.Errorf("%s 长度必须为 16、24 或 32 字节", EncryptKeyEnv)
+	}
+	return key, nil
+}
+
+func isValidKeyLen(n int) bool {
+	return n == 16 || n == 24 || n == 32
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// decryptMap 解密配置中所有 ENC(...) 形式的值，返回解密过的配置项路径
+func decryptMap(m map[string]any, prefix string, key []byte, result *ValidationError, file string) []string {
+	var decrypted []string
+	for _, k := range sortedKeys(m) {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		var paths []string
+		m[k], paths = decryptValue(m[k], path, key, result, file)
+		decrypted = append(decrypted, paths...)
+	}
+	return decrypted
+}
+
+// decryptValue 解密单个配置值，map 和切片递归解密，切片元素的路径为下标，如 redis.nodes.0.password
+func decryptValue(v any, path string, key []byte, result *ValidationError, file string) (any, []string) {
+	switch v := v.(type) {
+	case map[string]any:
+		return v, decryptMap(v, path, key, result, file)
+	case []any:
+		var decrypted []string
+		for idx := range v {
+			var paths []string
+			v[idx], paths = decryptValue(v[idx], fmt.Sprintf("%s.%d", path, idx), key, result, file)
+			decrypted = append(decrypted, paths...)
+		}
+		return v, decrypted
+	case string:
+		if !IsEncrypted(v) {
+			return v, nil
+		}
+		if key == nil {
+			result.add(file, path, "配置项已加密，但未设置环境变量 "+EncryptKeyEnv)
+			return v, nil
+		}
+		plain, err := DecryptValue(v, key)
+		if err != nil {
+			result.add(file, path, err.Error())
+			return v, nil
+		}
+		return plain, []string{path}
+	}
+	return v, nil
+}
@@ -0,0 +1,175 @@
+package conf
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// MaskedValue 敏感配置项展示时使用的掩码
+const MaskedValue = "******"
+
+// 敏感配置项名称，匹配配置项路径的最后一段
+var secretKeyRegexp = regexp.MustCompile(`(?i)(password|passwd|secret|token|credential|private-key|access-key|api-key|^key$|-key$)`)
+
+// IsSecretKey 判断配置项是否为敏感配置，如 password、secret-key、access-token
+func IsSecretKey(key string) bool {
+	segments := strings.Split(key, ".")
+	return secretKeyRegexp.MatchString(segments[len(segments)-1])
+}
+
+// Names 返回所有已加载的配置名称(不含扩展名)
+func (i *Configure) Names() []string {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	return sortedKeys(i.merged)
+}
+
+// All 返回合并后的全部配置副本，mask 为 true 时加密的配置项和敏感配置项使用掩码代替
+func (i *Configure) All(mask bool) map[string]map[string]any {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	result := make(map[string]map[string]any, len(i.merged))
+	for name, cfg := range i.merged {
+		cfg = copyMap(cfg)
+		if mask {
+			encrypted := make(map[string]struct{})
+			for _, key := range i.encrypted[name] {
+				encrypted[key] = struct{}{}
+			}
+			maskMap(cfg, "", encrypted)
+		}
+		result[name] = cfg
+	}
+	return result
+}
+
+// Sources 按优先级从低到高返回配置来源，第一个为配置目录
+func (i *Configure) Sources() []string {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	names := []string{"file://" + i.confDir}
+	for _, src := range i.sources {
+		names = append(names, src.Name())
+	}
+	return names
+}
+
+// FilePath 返回配置目录中配置文件的绝对路径，仅存在于配置源中的配置返回 false
+func (i *Configure) FilePath(name string) (string, bool) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	absPath, ok := i.cfgFileNameMap[name]["abs-path"].(string)
+	return absPath, ok
+}
+
+// EnvFiles 返回已加载的 .env 文件
+func (i *Configure) EnvFiles() []string {
+	return append([]string{}, i.envFiles...)
+}
+
+// EnvVariable 应用识别的环境变量
+type EnvVariable struct {
+	Name   string // 环境变量名称
+	Target string // 对应的配置项或用途说明
+	Value  string // 当前值，敏感配置项使用掩码代替
+	Set    bool   // 当前是否已设置
+}
+
+// frameworkEnvVariables 框架自身使用的环境变量
+var frameworkEnvVariables = []EnvVariable{
+	{Name: "APP_ENV", Target: "运行环境，决定加载的 .env.{环境} 文件"},
+	{Name: "ENVIRONMENT", Target: "运行环境，APP_ENV 未设置时使用"},
+	{Name: EncryptKeyEnv, Target: "ENC(...) 配置解密密钥"},
+	{Name: "OWL_CONFIG_KV_PROVIDER", Target: "KV 配置源类型"},
+	{Name: "OWL_CONFIG_KV_ADDR", Target: "KV 配置源地址"},
+	{Name: "OWL_CONFIG_KV_PREFIX", Target: "KV 配置源键前缀"},
+	{Name: "OWL_CONFIG_KV_TOKEN", Target: "KV 配置源访问令牌"},
+	{Name: "OWL_CONFIG_KV_INTERVAL", Target: "KV 配置源轮询间隔(秒)"},
+}
+
+// EnvVariables 返回应用识别的全部环境变量，包括框架使用的环境变量，
+// 配置文件按照 文件名_配置项 规则自动识别的环境变量，以及 OWL_ 环境变量配置源的环境变量
+func (i *Configure) EnvVariables() []EnvVariable {
+	var result []EnvVariable
+	for _, item := range frameworkEnvVariables {
+		result = append(result, newEnvVariable(item.Name, item.Target, IsSecretKey(strings.ReplaceAll(strings.ToLower(item.Name), "_", "-"))))
+	}
+
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	envSource := NewEnvSource(EnvSourcePrefix)
+	replacer := strings.NewReplacer(".", "_", "-", "_")
+	for _, name := range sortedKeys(i.merged) {
+		_, inConfDir := i.cfgFileNameMap[name]
+		for _, key := range leafKeys(i.merged[name], "") {
+			target := i.fileName(name) + " " + key
+			secret := IsSecretKey(key)
+			// 配置目录中的配置文件启用了 viper 的 AutomaticEnv
+			if inConfDir {
+				result = append(result, newEnvVariable(strings.ToUpper(name+"_"+replacer.Replace(key)), target, secret))
+			}
+			result = append(result, newEnvVariable(envSource.EnvKey(name, key), target, secret))
+		}
+	}
+	return result
+}
+
+func newEnvVariable(name, target string, secret bool) EnvVariable {
+	value, ok := os.LookupEnv(name)
+	if ok && secret && value != "" {
+		value = MaskedValue
+	}
+	return EnvVariable{Name: name, Target: target, Value: value, Set: ok}
+}
+
+// leafKeys 返回配置中所有叶子配置项的路径
+func leafKeys(m map[string]any, prefix string) []string {
+	var keys []string
+	for _, k := range sortedKeys(m) {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		if sub, ok := toStringMap(m[k]); ok && len(sub) > 0 {
+			keys = append(keys, leafKeys(sub, path)...)
+			continue
+		}
+		keys = append(keys, path)
+	}
+	return keys
+}
+
+// maskMap 将加密的配置项和敏感配置项替换为掩码，空值保持不变以便区分是否已配置
+func maskMap(m map[string]any, prefix string, encrypted map[string]struct{}) {
+	for k, v := range m {
+		path := k
+		if prefix != "" {
+			path = prefix + "." + k
+		}
+		m[k] = maskValue(v, path, encrypted)
+	}
+}
+
+// maskValue 返回单个配置值的掩码结果，敏感配置项整体替换，切片按下标递归处理
+func maskValue(v any, path string, encrypted map[string]struct{}) any {
+	if sub, ok := toStringMap(v); ok {
+		maskMap(sub, path, encrypted)
+		return sub
+	}
+	_, isEncrypted := encrypted[path]
+	if (isEncrypted || IsSecretKey(path)) && v != nil && v != "" {
+		return MaskedValue
+	}
+	if list, ok := v.([]any); ok {
+		for idx := range list {
+			list[idx] = maskValue(list[idx], fmt.Sprintf("%s.%d", path, idx), encrypted)
+		}
+	}
+	return v
+}
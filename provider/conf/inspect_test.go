@@ -0,0 +1,69 @@
+package conf
+
+import "testing"
+
+func TestConfigure_AllMasked(t *testing.T) {
+	c := &Configure{
+		merged: map[string]map[string]any{
+			"database": {
+				"host":     "127.0.0.1",
+				"password": "123456",
+				"username": "",
+				"replica":  map[string]any{"dsn": "root:123456@tcp(127.0.0.1)/owl"},
+				"replicas": []any{map[string]any{"host": "10.0.0.2", "password": "654321", "dsn": "root@tcp(10.0.0.2)/owl"}},
+			},
+			"router": {"jwt": map[string]any{"secret": ""}},
+		},
+		encrypted: map[string][]string{"database": {"replica.dsn", "replicas.0.dsn"}},
+	}
+
+	all := c.All(true)
+	db := all["database"]
+	if db["password"] != MaskedValue {
+		t.Errorf("password = %v", db["password"])
+	}
+	if db["host"] != "127.0.0.1" {
+		t.Errorf("host = %v", db["host"])
+	}
+	if dsn := db["replica"].(map[string]any)["dsn"]; dsn != MaskedValue {
+		t.Errorf("encrypted replica.dsn = %v", dsn)
+	}
+	if secret := all["router"]["jwt"].(map[string]any)["secret"]; secret != "" {
+		t.Errorf("empty secret should stay empty, got %v", secret)
+	}
+	replica := db["replicas"].([]any)[0].(map[string]any)
+	if replica["password"] != MaskedValue || replica["dsn"] != MaskedValue || replica["host"] != "10.0.0.2" {
+		t.Errorf("replicas = %v", replica)
+	}
+	if c.merged["database"]["replicas"].([]any)[0].(map[string]any)["password"] != "654321" {
+		t.Errorf("All should not modify loaded replicas")
+	}
+	if c.merged["database"]["password"] != "123456" {
+		t.Errorf("All should not modify loaded config")
+	}
+}
+
+func TestConfigure_EnvVariables(t *testing.T) {
+	t.Setenv("OWL_DATABASE__PASSWORD", "secret")
+	t.Setenv("DATABASE_MAX_CONNS", "20")
+
+	c := &Configure{
+		cfgFileNameMap: map[string]map[string]any{"database": {"abs-path": "/conf/database.yaml"}},
+		merged:         map[string]map[string]any{"database": {"password": "x", "max-conns": 10}},
+	}
+
+	vars := make(map[string]EnvVariable)
+	for _, env := range c.EnvVariables() {
+		vars[env.Name] = env
+	}
+
+	if env := vars["OWL_DATABASE__PASSWORD"]; !env.Set || env.Value != MaskedValue || env.Target != "database.yaml password" {
+		t.Errorf("OWL_DATABASE__PASSWORD = %+v", env)
+	}
+	if env := vars["DATABASE_MAX_CONNS"]; !env.Set || env.Value != "20" {
+		t.Errorf("DATABASE_MAX_CONNS = %+v", env)
+	}
+	if _, ok := vars[EncryptKeyEnv]; !ok {
+		t.Errorf("%s should be listed", EncryptKeyEnv)
+	}
+}
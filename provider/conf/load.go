@@ -2,11 +2,13 @@ package conf
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"bit-labs.cn/owl/contract/foundation"
 	"bit-labs.cn/owl/contract/log"
@@ -16,14 +18,22 @@ import (
 	"github.com/joho/godotenv"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
 )
 
 type Configure struct {
 	confDir        string
-	cfgFileNameMap map[string]map[string]any // fileName => map[string]any
+	cfgFileNameMap map[string]map[string]any   // fileName => map[string]any 配置目录中的配置
+	sources        []ConfigSource              // 配置源，按优先级从低到高排列
+	sourceData     []map[string]map[string]any // 各配置源加载的配置，与 sources 一一对应
+	merged         map[string]map[string]any   // 合并并解密后的配置
+	encrypted      map[string][]string         // fileName => 加密的配置项
+	envFiles       []string                    // 已加载的 .env 文件
 	app            foundation.Application
 	eventBus       EventBus.Bus
 	l              log.Logger
+	lock           sync.RWMutex
+	cancel         context.CancelFunc
 }
 
 func NewConfigure(app foundation.Application, bus EventBus.Bus) *Configure {
@@ -32,7 +42,7 @@ func NewConfigure(app foundation.Application, bus EventBus.Bus) *Configure {
 
 	utils.PrintLnYellow("配置文件目录: ", confDir)
 
-	manager := Configure{
+	manager := &Configure{
 		confDir:        confDir,
 		cfgFileNameMap: make(map[string]map[string]any),
 		eventBus:       bus,
@@ -42,23 +52,176 @@ func NewConfigure(app foundation.Application, bus EventBus.Bus) *Configure {
 	// 加载 .env 文件
 	manager.loadEnvFiles()
 
+	// 配置文件的监听回调会读取配置，加载完成之前不允许回调修改配置
+	manager.lock.Lock()
+
 	err := filepath.Walk(confDir, func(path string, info fs.FileInfo, err error) error {
 		if info.IsDir() {
 			return nil
 		}
-		cfgMap := make(map[string]any)
-		ext := strings.Replace(filepath.Ext(info.Name()), ".", "", -1)
-		name := strings.Replace(info.Name(), "."+ext, "", -1)
-		absPath, v := manager.load(name, ext, &cfgMap)
-		cfgMap["abs-path"] = absPath
-		cfgMap["vip"] = v
-		manager.cfgFileNameMap[name] = cfgMap
+		manager.loadFile(info.Name())
 		return nil
 	})
 	if err != nil {
 		panic(err)
 	}
-	return &manager
+
+	manager.loadSources(defaultSources())
+	if err = manager.rebuild(); err != nil {
+		panic(err)
+	}
+
+	manager.lock.Unlock()
+
+	manager.watchSources()
+	return manager
+}
+
+// LoadFile 加载配置目录下的单个配置文件，已加载过的文件不会重复加载。
+// 服务提供者在配置管理器创建之后才生成的配置文件，需要通过此方法补充加载
+func (i *Configure) LoadFile(file string) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if i.loadFile(file) {
+		if err := i.rebuild(); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (i *Configure) loadFile(file string) bool {
+	ext := strings.Replace(filepath.Ext(file), ".", "", -1)
+	name := strings.Replace(file, "."+ext, "", -1)
+	if _, ok := i.cfgFileNameMap[name]; ok {
+		return false
+	}
+
+	cfgMap := make(map[string]any)
+	absPath, v := i.load(name, ext, &cfgMap)
+	cfgMap["abs-path"] = absPath
+	cfgMap["vip"] = v
+	i.cfgFileNameMap[name] = cfgMap
+	return true
+}
+
+// loadSources 加载配置目录之外的配置源
+func (i *Configure) loadSources(sources []ConfigSource) {
+	for _, src := range sources {
+		data, err := src.Load()
+		if err != nil {
+			panic(fmt.Sprintf("加载配置源 %s 失败: %v", src.Name(), err))
+		}
+		if len(data) > 0 {
+			utils.PrintLnYellow("配置源: ", src.Name())
+		}
+		i.sources = append(i.sources, src)
+		i.sourceData = append(i.sourceData, data)
+	}
+}
+
+// watchSources 监听支持变更通知的配置源，配置变化时重新合并并发布配置变更事件
+func (i *Configure) watchSources() {
+	ctx, cancel := context.WithCancel(context.Background())
+	i.cancel = cancel
+
+	for idx, src := range i.sources {
+		watchable, ok := src.(WatchableSource)
+		if !ok {
+			continue
+		}
+		go func(idx int, src WatchableSource) {
+			_ = src.Watch(ctx, func(data map[string]map[string]any) {
+				i.lock.Lock()
+				i.sourceData[idx] = data
+				err := i.rebuild()
+				i.lock.Unlock()
+
+				if err != nil {
+					utils.PrintLnRed("配置源 ", src.Name(), " 变更后合并失败，继续使用原配置: ", err.Error())
+					return
+				}
+				i.eventBus.Publish(ConfigChangeEvent, fsnotify.Event{Name: src.Name(), Op: fsnotify.Write})
+			})
+		}(idx, watchable)
+	}
+}
+
+// Close 停止监听配置源
+func (i *Configure) Close() {
+	if i.cancel != nil {
+		i.cancel()
+	}
+}
+
+// rebuild 按优先级合并配置目录与各配置源的配置，并解密 ENC(...) 形式的配置值，调用方需持有写锁
+func (i *Configure) rebuild() error {
+	merged := make(map[string]map[string]any)
+	for name := range i.cfgFileNameMap {
+		merged[name] = i.fileConfig(name)
+	}
+	for _, data := range i.sourceData {
+		for name, cfg := range data {
+			if merged[name] == nil {
+				merged[name] = make(map[string]any)
+			}
+			mergeMap(merged[name], cfg)
+		}
+	}
+
+	key, err := EncryptKeyFromEnv()
+	if err != nil {
+		return err
+	}
+
+	result := &ValidationError{}
+	encrypted := make(map[string][]string)
+	for _, name := range sortedKeys(merged) {
+		if keys := decryptMap(merged[name], "", key, result, i.fileName(name)); len(keys) > 0 {
+			encrypted[name] = keys
+		}
+	}
+	if len(result.Errors) > 0 {
+		return result
+	}
+
+	i.merged = merged
+	i.encrypted = encrypted
+	return nil
+}
+
+// fileConfig 返回配置目录中配置文件的配置副本，不包含内部使用的 abs-path、vip 字段
+func (i *Configure) fileConfig(name string) map[string]any {
+	result := make(map[string]any)
+	for k, v := range i.cfgFileNameMap[name] {
+		if k == "abs-path" || k == "vip" {
+			continue
+		}
+		result[k] = v
+	}
+	return copyMap(result)
+}
+
+// fileName 返回配置对应的文件名，仅存在于配置源中的配置没有文件，返回配置名称
+func (i *Configure) fileName(name string) string {
+	if cfg, ok := i.cfgFileNameMap[name]; ok {
+		if absPath, ok := cfg["abs-path"].(string); ok {
+			return filepath.Base(absPath)
+		}
+	}
+	return name
+}
+
+// raw 返回合并后的配置副本
+func (i *Configure) raw(name string) (map[string]any, bool) {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	cfg, ok := i.merged[name]
+	if !ok {
+		return nil, false
+	}
+	return copyMap(cfg), true
 }
 
 // loadEnvFiles 加载环境变量文件
@@ -79,6 +242,7 @@ func (i *Configure) loadEnvFiles() {
 				fmt.Printf("Warning: Failed to load env file %s: %v\n", envFile, err)
 			} else {
 				fmt.Printf("Loaded environment file: %s\n", envFile)
+				i.envFiles = append(i.envFiles, envFile)
 			}
 		}
 	}
@@ -99,7 +263,9 @@ func (i *Configure) getEnvironment() string {
 func (i *Configure) GetConfig(key string, v any) error {
 	var getter jsoniter.Any
 
-	marshal, err := jsoniter.Marshal(i.cfgFileNameMap)
+	i.lock.RLock()
+	marshal, err := jsoniter.Marshal(i.merged)
+	i.lock.RUnlock()
 	if err != nil {
 		return err
 	}
@@ -120,16 +286,122 @@ func (i *Configure) GetConfig(key string, v any) error {
 	return err
 }
 
-func (i *Configure) SaveConfig(fileName string, key string, value any) {
+// SaveConfig 修改配置目录中配置文件的配置项并写回文件，fileName 不含扩展名
+// 直接修改 yaml 节点，保留文件中的注释，环境变量等覆盖的值不会写入文件
+func (i *Configure) SaveConfig(fileName string, key string, value any) error {
+	i.lock.RLock()
 	cfg, ok := i.cfgFileNameMap[fileName]
-	if ok {
-		v := cfg["vip"].(*viper.Viper)
-		v.Set(key, value)
-		err := v.WriteConfig()
-		if err != nil {
-			fmt.Println("保存配置失败")
+	i.lock.RUnlock()
+	if !ok {
+		return fmt.Errorf("配置文件 %s 不存在", fileName)
+	}
+
+	absPath := cfg["abs-path"].(string)
+	if ext := filepath.Ext(absPath); ext != ".yaml" && ext != ".yml" {
+		return fmt.Errorf("只支持修改 yaml 配置文件: %s", filepath.Base(absPath))
+	}
+	info, err := os.Stat(absPath)
+	if err != nil {
+		return fmt.Errorf("保存配置失败: %w", err)
+	}
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return fmt.Errorf("保存配置失败: %w", err)
+	}
+
+	var doc yaml.Node
+	if err = yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("解析配置文件 %s 失败: %w", filepath.Base(absPath), err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if err = setYAMLValue(doc.Content[0], strings.Split(key, "."), value); err != nil {
+		return fmt.Errorf("保存配置失败: %w", err)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err = enc.Encode(&doc); err != nil {
+		return fmt.Errorf("保存配置失败: %w", err)
+	}
+	_ = enc.Close()
+	if err = os.WriteFile(absPath, keepBlankLines(data, doc.Content[0], buf.Bytes()), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("保存配置失败: %w", err)
+	}
+	return nil
+}
+
+// keepBlankLines 恢复顶层配置项之前的空行，yaml 编码时不保留空行
+func keepBlankLines(original []byte, root *yaml.Node, encoded []byte) []byte {
+	lines := strings.Split(string(original), "\n")
+	blank := make(map[string]bool)
+	for n := 0; n+1 < len(root.Content); n += 2 {
+		key := root.Content[n]
+		if key.Line == 0 {
+			continue
 		}
+		start := key.Line
+		if key.HeadComment != "" {
+			start -= strings.Count(key.HeadComment, "\n") + 1
+		}
+		if start >= 2 && strings.TrimSpace(lines[start-2]) == "" {
+			blank[key.Value] = true
+		}
+	}
+	if len(blank) == 0 {
+		return encoded
+	}
+
+	var result []string
+	for _, line := range strings.Split(string(encoded), "\n") {
+		if name, _, found := strings.Cut(line, ":"); found && blank[name] && len(result) > 0 {
+			// 空行插入到配置项的注释之前
+			at := len(result)
+			for at > 0 && strings.HasPrefix(result[at-1], "#") {
+				at--
+			}
+			if at > 0 && result[at-1] != "" {
+				result = append(result[:at], append([]string{""}, result[at:]...)...)
+			}
+		}
+		result = append(result, line)
+	}
+	return []byte(strings.Join(result, "\n"))
+}
+
+// setYAMLValue 按路径修改映射节点中的值，不存在的配置项逐级创建，保留原值上的注释
+func setYAMLValue(node *yaml.Node, path []string, value any) error {
+	if node.Kind != yaml.MappingNode {
+		return fmt.Errorf("配置项 %s 不是对象", path[0])
+	}
+
+	var target *yaml.Node
+	for n := 0; n+1 < len(node.Content); n += 2 {
+		if node.Content[n].Value == path[0] {
+			target = node.Content[n+1]
+			break
+		}
+	}
+	if target == nil {
+		target = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: path[0]}, target)
+	}
+	if len(path) > 1 {
+		if target.Kind == yaml.ScalarNode && target.Tag == "!!null" {
+			*target = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", LineComment: target.LineComment}
+		}
+		return setYAMLValue(target, path[1:], value)
+	}
+
+	var replaced yaml.Node
+	if err := replaced.Encode(value); err != nil {
+		return err
 	}
+	replaced.HeadComment, replaced.LineComment, replaced.FootComment = target.HeadComment, target.LineComment, target.FootComment
+	*target = replaced
+	return nil
 }
 
 // load 读取文件中的配置
@@ -169,6 +441,18 @@ func (i *Configure) load(fileName, cfgType string, c any) (string, *viper.Viper)
 	v.WatchConfig()
 	// Register a callback function to handle the changes
 	v.OnConfigChange(func(e fsnotify.Event) {
+		i.lock.Lock()
+		cfgMap := v.AllSettings()
+		cfgMap["abs-path"] = confFilePath
+		cfgMap["vip"] = v
+		i.cfgFileNameMap[fileName] = cfgMap
+		err := i.rebuild()
+		i.lock.Unlock()
+
+		if err != nil {
+			utils.PrintLnRed("配置文件 ", confFilePath, " 变更后合并失败，继续使用原配置: ", err.Error())
+			return
+		}
 		i.eventBus.Publish(ConfigChangeEvent, e)
 	})
 	return confFilePath, v
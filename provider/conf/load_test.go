@@ -0,0 +1,51 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigure_SaveConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "router.yaml")
+	content := `# 服务配置
+server:
+  # 端口
+  port: 8080 # 监听端口
+  host: "0.0.0.0"
+cors:
+  allowed-origins:
+    - "*"
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// 环境变量覆盖的值不应写入文件
+	t.Setenv("ROUTER_SERVER_HOST", "127.0.0.1")
+	c := &Configure{cfgFileNameMap: map[string]map[string]any{"router": {"abs-path": path}}}
+
+	if err := c.SaveConfig("router", "server.port", 9090); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SaveConfig("router", "jwt.secret", "abc"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SaveConfig("router", "server.port.value", 1); err == nil {
+		t.Error("setting a key under a scalar should fail")
+	}
+	if err := c.SaveConfig("missing", "a", 1); err == nil {
+		t.Error("missing file should fail")
+	}
+
+	data, _ := os.ReadFile(path)
+	got := string(data)
+	for _, want := range []string{"# 服务配置", "# 端口", "port: 9090 # 监听端口", `host: "0.0.0.0"`, "jwt:\n  secret: abc", `- "*"`} {
+		if !strings.Contains(got, want) {
+			t.Errorf("missing %q in\n%s", want, got)
+		}
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v", info.Mode())
+	}
+}
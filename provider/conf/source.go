@@ -0,0 +1,249 @@
+package conf
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cast"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigSource 配置来源，配置目录之外的配置都通过配置源加载
+// 返回的数据结构与配置目录保持一致：配置文件名(不含扩展名) => 配置内容
+type ConfigSource interface {
+	Name() string
+	Load() (map[string]map[string]any, error)
+}
+
+// WatchableSource 支持变更通知的配置源，Watch 会阻塞直到 ctx 结束
+type WatchableSource interface {
+	ConfigSource
+	Watch(ctx context.Context, onChange func(data map[string]map[string]any)) error
+}
+
+var (
+	sourcesLock       sync.RWMutex
+	registeredSources []ConfigSource
+)
+
+// AddSource 注册额外的配置源，需在创建应用之前调用
+// 配置合并的优先级从低到高：配置目录 < 环境变量配置的 KV 存储 < AddSource 注册的配置源(后注册的优先) < OWL_ 环境变量
+func AddSource(sources ...ConfigSource) {
+	sourcesLock.Lock()
+	registeredSources = append(registeredSources, sources...)
+	sourcesLock.Unlock()
+}
+
+// defaultSources 按优先级从低到高返回所有配置源
+func defaultSources() []ConfigSource {
+	var sources []ConfigSource
+
+	if kv := kvSourceFromEnv(); kv != nil {
+		sources = append(sources, kv)
+	}
+
+	sourcesLock.RLock()
+	sources = append(sources, registeredSources...)
+	sourcesLock.RUnlock()
+
+	return append(sources, NewEnvSource(EnvSourcePrefix))
+}
+
+// FileSource 目录配置源，用于加载配置目录之外的目录，比如容器中挂载的配置目录
+type FileSource struct {
+	dir string
+}
+
+func NewFileSource(dir string) *FileSource {
+	return &FileSource{dir: dir}
+}
+
+func (i *FileSource) Name() string {
+	return "file://" + i.dir
+}
+
+func (i *FileSource) Load() (map[string]map[string]any, error) {
+	data := make(map[string]map[string]any)
+	err := filepath.WalkDir(i.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		ext := strings.TrimPrefix(filepath.Ext(d.Name()), ".")
+		if !isSupportedExt(ext) {
+			return nil
+		}
+
+		v := viper.New()
+		v.SetConfigType(ext)
+		v.SetConfigFile(path)
+		if err := v.ReadInConfig(); err != nil {
+			return fmt.Errorf("读取配置文件 %s 失败: %w", path, err)
+		}
+		data[strings.TrimSuffix(d.Name(), "."+ext)] = v.AllSettings()
+		return nil
+	})
+	return data, err
+}
+
+func isSupportedExt(ext string) bool {
+	for _, e := range viper.SupportedExts {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+// EnvSourcePrefix 环境变量配置源使用的前缀
+const EnvSourcePrefix = "OWL"
+
+// EnvSource 环境变量配置源，不依赖配置文件，适用于只通过环境变量配置的容器环境
+// 命名规则：前缀_文件名__配置项__子配置项，单个下划线转换为中划线，如
+// OWL_ROUTER__SERVER__PORT=8080        => router.yaml  server.port
+// OWL_DATABASE__MAX_CONNS=100          => database.yaml max-conns
+type EnvSource struct {
+	prefix  string
+	environ func() []string
+}
+
+func NewEnvSource(prefix string) *EnvSource {
+	return &EnvSource{prefix: strings.ToUpper(prefix) + "_", environ: os.Environ}
+}
+
+func (i *EnvSource) Name() string {
+	return "env://" + i.prefix
+}
+
+func (i *EnvSource) Load() (map[string]map[string]any, error) {
+	data := make(map[string]map[string]any)
+	for _, kv := range i.environ() {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, i.prefix) {
+			continue
+		}
+		segments := strings.Split(strings.TrimPrefix(name, i.prefix), "__")
+		if len(segments) < 2 {
+			continue
+		}
+		for idx, seg := range segments {
+			segments[idx] = strings.ReplaceAll(strings.ToLower(seg), "_", "-")
+		}
+		file := segments[0]
+		if data[file] == nil {
+			data[file] = make(map[string]any)
+		}
+		setNested(data[file], segments[1:], ParseScalar(value))
+	}
+	return data, nil
+}
+
+// EnvKey 返回配置项对应的环境变量名称
+func (i *EnvSource) EnvKey(file, key string) string {
+	segments := append([]string{file}, strings.Split(key, ".")...)
+	for idx, seg := range segments {
+		segments[idx] = strings.ToUpper(strings.ReplaceAll(seg, "-", "_"))
+	}
+	return i.prefix + strings.Join(segments, "__")
+}
+
+// ParseScalar 将字符串解析为 yaml 标量，"8080" => 8080, "true" => true
+func ParseScalar(s string) any {
+	var v any
+	if err := yaml.Unmarshal([]byte(s), &v); err != nil || v == nil {
+		return s
+	}
+	switch v.(type) {
+	case map[string]any, []any:
+		// 复杂结构必须通过文档形式提供，此处保留原始字符串
+		return s
+	}
+	return v
+}
+
+// setNested 按照路径设置嵌套 map 中的值
+func setNested(m map[string]any, path []string, value any) {
+	for idx, key := range path {
+		if idx == len(path)-1 {
+			m[key] = value
+			return
+		}
+		next, ok := m[key].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			m[key] = next
+		}
+		m = next
+	}
+}
+
+// mergeMap 将 src 深度合并到 dst 中，src 的值优先
+func mergeMap(dst, src map[string]any) {
+	for k, v := range src {
+		srcMap, srcIsMap := toStringMap(v)
+		dstMap, dstIsMap := toStringMap(dst[k])
+		if srcIsMap && dstIsMap {
+			merged := copyMap(dstMap)
+			mergeMap(merged, srcMap)
+			dst[k] = merged
+			continue
+		}
+		if srcIsMap {
+			dst[k] = copyMap(srcMap)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+func copyMap(m map[string]any) map[string]any {
+	result := make(map[string]any, len(m))
+	for k, v := range m {
+		result[k] = copyValue(v)
+	}
+	return result
+}
+
+// copyValue 复制配置值，map 和切片深拷贝
+func copyValue(v any) any {
+	if sub, ok := toStringMap(v); ok {
+		return copyMap(sub)
+	}
+	if list, ok := v.([]any); ok {
+		result := make([]any, len(list))
+		for idx, item := range list {
+			result[idx] = copyValue(item)
+		}
+		return result
+	}
+	return v
+}
+
+func toStringMap(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, true
+	case map[any]any:
+		return cast.ToStringMap(m), true
+	}
+	return nil, false
+}
+
+// sortedKeys 返回排序后的 map 键
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,361 @@
+package conf
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/spf13/cast"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	KVProviderConsul = "consul"
+	KVProviderEtcd   = "etcd"
+	KVProviderLocal  = "local" // 本地文件模拟 KV 存储，用于开发环境
+)
+
+// KVOptions KV 配置源选项
+type KVOptions struct {
+	Provider string        // consul etcd local
+	Address  string        // consul/etcd 为服务地址，如 http://127.0.0.1:8500；local 为文件路径
+	Prefix   string        // 键前缀，默认 owl
+	Token    string        // 访问令牌，consul 使用 X-Consul-Token，etcd 使用 Authorization
+	Interval time.Duration // 轮询间隔，默认 30 秒
+	Timeout  time.Duration // 请求超时时间，默认 5 秒
+}
+
+// kvPair KV 存储中的一条记录
+type kvPair struct {
+	Key   string
+	Value []byte
+}
+
+// kvSourceFromEnv 根据环境变量创建 KV 配置源，未配置 OWL_CONFIG_KV_ADDR 时返回 nil
+// OWL_CONFIG_KV_PROVIDER  consul(默认) etcd local
+// OWL_CONFIG_KV_ADDR      服务地址或 local 模式下的文件路径
+// OWL_CONFIG_KV_PREFIX    键前缀，默认 owl
+// OWL_CONFIG_KV_TOKEN     访问令牌
+// OWL_CONFIG_KV_INTERVAL  轮询间隔(秒)，默认 30
+func kvSourceFromEnv() ConfigSource {
+	addr := os.Getenv("OWL_CONFIG_KV_ADDR")
+	if addr == "" {
+		return nil
+	}
+	opt := KVOptions{
+		Provider: os.Getenv("OWL_CONFIG_KV_PROVIDER"),
+		Address:  addr,
+		Prefix:   os.Getenv("OWL_CONFIG_KV_PREFIX"),
+		Token:    os.Getenv("OWL_CONFIG_KV_TOKEN"),
+		Interval: time.Duration(cast.ToInt(os.Getenv("OWL_CONFIG_KV_INTERVAL"))) * time.Second,
+	}
+	if opt.Provider == KVProviderLocal {
+		return NewLocalKVSource(opt)
+	}
+	return NewHTTPKVSource(opt)
+}
+
+func (o *KVOptions) setDefaults() {
+	if o.Provider == "" {
+		o.Provider = KVProviderConsul
+	}
+	if o.Prefix == "" {
+		o.Prefix = "owl"
+	}
+	o.Prefix = strings.Trim(o.Prefix, "/")
+	if o.Interval <= 0 {
+		o.Interval = 30 * time.Second
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 5 * time.Second
+	}
+}
+
+// HTTPKVSource 通过 HTTP 接口读取 consul 或 etcd(v3 JSON 网关) 中的配置，并轮询检测变更
+// 键的组织方式：
+// owl/router.yaml             值为完整的 yaml/json 文档
+// owl/router/server/port      值为单个配置项
+type HTTPKVSource struct {
+	opt    KVOptions
+	client *http.Client
+}
+
+func NewHTTPKVSource(opt KVOptions) *HTTPKVSource {
+	opt.setDefaults()
+	return &HTTPKVSource{
+		opt:    opt,
+		client: &http.Client{Timeout: opt.Timeout},
+	}
+}
+
+func (i *HTTPKVSource) Name() string {
+	return i.opt.Provider + "://" + strings.TrimRight(i.opt.Address, "/") + "/" + i.opt.Prefix
+}
+
+func (i *HTTPKVSource) Load() (map[string]map[string]any, error) {
+	pairs, err := i.fetch(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return pairsToConfig(i.opt.Prefix, pairs)
+}
+
+func (i *HTTPKVSource) Watch(ctx context.Context, onChange func(data map[string]map[string]any)) error {
+	return pollPairs(ctx, i.opt, i.fetch, onChange)
+}
+
+func (i *HTTPKVSource) fetch(ctx context.Context) ([]kvPair, error) {
+	switch i.opt.Provider {
+	case KVProviderConsul:
+		return i.fetchConsul(ctx)
+	case KVProviderEtcd:
+		return i.fetchEtcd(ctx)
+	default:
+		return nil, fmt.Errorf("不支持的 KV 存储类型: %s", i.opt.Provider)
+	}
+}
+
+func (i *HTTPKVSource) fetchConsul(ctx context.Context) ([]kvPair, error) {
+	// 带上分隔符，避免前缀 owl 匹配到 owlx/ 下的键
+	url := strings.TrimRight(i.opt.Address, "/") + "/v1/kv/" + i.opt.Prefix + "/?recurse=true"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if i.opt.Token != "" {
+		req.Header.Set("X-Consul-Token", i.opt.Token)
+	}
+
+	body, status, err := i.do(req)
+	if err != nil {
+		return nil, err
+	}
+	// 前缀下没有任何键
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("consul 返回状态码 %d: %s", status, string(body))
+	}
+
+	var entries []struct {
+		Key   string `json:"Key"`
+		Value []byte `json:"Value"` // consul 返回 base64，由 json 解码
+	}
+	if err = jsoniter.Unmarshal(body, &entries); err != nil {
+		return nil, err
+	}
+	pairs := make([]kvPair, 0, len(entries))
+	for _, e := range entries {
+		pairs = append(pairs, kvPair{Key: e.Key, Value: e.Value})
+	}
+	return pairs, nil
+}
+
+func (i *HTTPKVSource) fetchEtcd(ctx context.Context) ([]kvPair, error) {
+	prefix := []byte(i.opt.Prefix + "/")
+	payload, err := jsoniter.Marshal(map[string]string{
+		"key":       base64.StdEncoding.EncodeToString(prefix),
+		"range_end": base64.StdEncoding.EncodeToString(prefixRangeEnd(prefix)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	url := strings.TrimRight(i.opt.Address, "/") + "/v3/kv/range"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if i.opt.Token != "" {
+		req.Header.Set("Authorization", i.opt.Token)
+	}
+
+	body, status, err := i.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("etcd 返回状态码 %d: %s", status, string(body))
+	}
+
+	var resp struct {
+		Kvs []struct {
+			Key   []byte `json:"key"`
+			Value []byte `json:"value"`
+		} `json:"kvs"`
+	}
+	if err = jsoniter.Unmarshal(body, &resp); err != nil {
+		return nil, err
+	}
+	pairs := make([]kvPair, 0, len(resp.Kvs))
+	for _, e := range resp.Kvs {
+		pairs = append(pairs, kvPair{Key: string(e.Key), Value: e.Value})
+	}
+	return pairs, nil
+}
+
+func (i *HTTPKVSource) do(req *http.Request) ([]byte, int, error) {
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return body, resp.StatusCode, err
+}
+
+// prefixRangeEnd etcd 前缀查询的结束键，前缀最后一个字节加一
+func prefixRangeEnd(prefix []byte) []byte {
+	end := make([]byte, len(prefix))
+	copy(end, prefix)
+	for idx := len(end) - 1; idx >= 0; idx-- {
+		if end[idx] < 0xff {
+			end[idx]++
+			return end[:idx+1]
+		}
+	}
+	return []byte{0}
+}
+
+// LocalKVSource 本地文件模拟的 KV 存储，在没有 consul/etcd 的开发环境中代替 KV 存储使用
+// 文件为 yaml 格式，每一项的键与 KV 存储中的组织方式一致（不含前缀），如 `router/server/port: 8080`
+type LocalKVSource struct {
+	opt KVOptions
+}
+
+func NewLocalKVSource(opt KVOptions) *LocalKVSource {
+	opt.setDefaults()
+	return &LocalKVSource{opt: opt}
+}
+
+func (i *LocalKVSource) Name() string {
+	return KVProviderLocal + "://" + filepath.ToSlash(i.opt.Address)
+}
+
+func (i *LocalKVSource) Load() (map[string]map[string]any, error) {
+	pairs, err := i.fetch(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	return pairsToConfig(i.opt.Prefix, pairs)
+}
+
+func (i *LocalKVSource) Watch(ctx context.Context, onChange func(data map[string]map[string]any)) error {
+	return pollPairs(ctx, i.opt, i.fetch, onChange)
+}
+
+func (i *LocalKVSource) fetch(context.Context) ([]kvPair, error) {
+	content, err := os.ReadFile(i.opt.Address)
+	if err != nil {
+		return nil, err
+	}
+	var entries map[string]any
+	if err = yaml.Unmarshal(content, &entries); err != nil {
+		return nil, err
+	}
+	pairs := make([]kvPair, 0, len(entries))
+	for _, key := range sortedKeys(entries) {
+		pairs = append(pairs, kvPair{
+			Key:   i.opt.Prefix + "/" + strings.Trim(key, "/"),
+			Value: []byte(cast.ToString(entries[key])),
+		})
+	}
+	return pairs, nil
+}
+
+// pollPairs 定时拉取 KV 数据，内容发生变化时回调
+func pollPairs(ctx context.Context, opt KVOptions, fetch func(ctx context.Context) ([]kvPair, error), onChange func(data map[string]map[string]any)) error {
+	var lastHash string
+	if pairs, err := fetch(ctx); err == nil {
+		lastHash = hashPairs(pairs)
+	}
+
+	ticker := time.NewTicker(opt.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			pairs, err := fetch(ctx)
+			if err != nil {
+				// 拉取失败时保留当前配置，等待下一次轮询
+				continue
+			}
+			hash := hashPairs(pairs)
+			if hash == lastHash {
+				continue
+			}
+			data, err := pairsToConfig(opt.Prefix, pairs)
+			if err != nil {
+				continue
+			}
+			lastHash = hash
+			onChange(data)
+		}
+	}
+}
+
+func hashPairs(pairs []kvPair) string {
+	h := sha256.New()
+	for _, p := range pairs {
+		h.Write([]byte(p.Key))
+		h.Write([]byte{0})
+		h.Write(p.Value)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// pairsToConfig 将 KV 记录转换为配置数据
+func pairsToConfig(prefix string, pairs []kvPair) (map[string]map[string]any, error) {
+	data := make(map[string]map[string]any)
+	for _, p := range pairs {
+		// 目录键没有值
+		if strings.HasSuffix(p.Key, "/") {
+			continue
+		}
+		// 只接受前缀目录下的键，前缀 owl 不包含 owlx/ 下的键
+		key, ok := strings.CutPrefix(p.Key, prefix+"/")
+		if !ok {
+			continue
+		}
+		if key = strings.Trim(key, "/"); key == "" {
+			continue
+		}
+		segments := strings.Split(strings.ToLower(key), "/")
+
+		// 单个键保存完整的配置文档
+		if len(segments) == 1 {
+			name := strings.TrimSuffix(segments[0], filepath.Ext(segments[0]))
+			var doc map[string]any
+			if err := yaml.Unmarshal(p.Value, &doc); err != nil {
+				return nil, fmt.Errorf("解析配置 %s 失败: %w", p.Key, err)
+			}
+			if data[name] == nil {
+				data[name] = make(map[string]any)
+			}
+			mergeMap(data[name], doc)
+			continue
+		}
+
+		name := segments[0]
+		if data[name] == nil {
+			data[name] = make(map[string]any)
+		}
+		setNested(data[name], segments[1:], ParseScalar(string(p.Value)))
+	}
+	return data, nil
+}
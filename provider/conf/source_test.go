@@ -0,0 +1,125 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnvSource_Load(t *testing.T) {
+	src := NewEnvSource(EnvSourcePrefix)
+	src.environ = func() []string {
+		return []string{
+			"OWL_ROUTER__SERVER__PORT=9090",
+			"OWL_DATABASE__MAX_CONNS=50",
+			"OWL_CONFIG_KEY=ignored",
+			"PATH=/usr/bin",
+		}
+	}
+
+	data, err := src.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := data["router"]["server"].(map[string]any)
+	if server["port"] != 9090 {
+		t.Errorf("router.server.port = %v", server["port"])
+	}
+	if data["database"]["max-conns"] != 50 {
+		t.Errorf("database.max-conns = %v", data["database"]["max-conns"])
+	}
+	if _, ok := data["config"]; ok {
+		t.Errorf("OWL_CONFIG_KEY should not be treated as config")
+	}
+	if key := src.EnvKey("database", "max-conns"); key != "OWL_DATABASE__MAX_CONNS" {
+		t.Errorf("EnvKey = %s", key)
+	}
+}
+
+func TestPairsToConfig(t *testing.T) {
+	data, err := pairsToConfig("owl", []kvPair{
+		{Key: "owl/router.yaml", Value: []byte("mode: release\nserver:\n  host: 0.0.0.0\n  port: 8080\n")},
+		{Key: "owl/router/server/port", Value: []byte("9090")},
+		{Key: "owl/router/", Value: nil},
+		{Key: "owlx/router/mode", Value: []byte("debug")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := data["router"]["server"].(map[string]any)
+	// owlx/ 下的键不属于前缀 owl
+	if data["router"]["mode"] != "release" || server["host"] != "0.0.0.0" || server["port"] != 9090 {
+		t.Errorf("unexpected config: %v", data["router"])
+	}
+	if _, ok := data["x"]; ok || len(data) != 1 {
+		t.Errorf("unexpected config: %v", data)
+	}
+}
+
+func TestLocalKVSource_Load(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "kv.yaml")
+	err := os.WriteFile(file, []byte("database/host: 10.0.0.1\ndatabase/port: 5433\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := NewLocalKVSource(KVOptions{Address: file}).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data["database"]["host"] != "10.0.0.1" || data["database"]["port"] != 5433 {
+		t.Errorf("unexpected config: %v", data["database"])
+	}
+}
+
+func TestRebuild_PrecedenceAndDecrypt(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	secret, err := EncryptValue("s3cret", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EncryptKeyEnv, string(key))
+
+	c := &Configure{
+		cfgFileNameMap: map[string]map[string]any{
+			"database": {"host": "127.0.0.1", "port": 5432, "password": "plain", "abs-path": "/conf/database.yaml"},
+		},
+		sourceData: []map[string]map[string]any{
+			{"database": {"host": "kv-host", "password": secret, "replicas": []any{map[string]any{"password": secret}}}},
+			{"database": {"port": 6543}, "cache": {"ttl": 60}},
+		},
+	}
+	if err = c.rebuild(); err != nil {
+		t.Fatal(err)
+	}
+
+	db := c.merged["database"]
+	if db["host"] != "kv-host" || db["port"] != 6543 || db["password"] != "s3cret" {
+		t.Errorf("unexpected merged config: %v", db)
+	}
+	if _, ok := db["abs-path"]; ok {
+		t.Errorf("internal keys should not be merged")
+	}
+	if c.merged["cache"]["ttl"] != 60 {
+		t.Errorf("source only config should be merged")
+	}
+	if replica := db["replicas"].([]any)[0].(map[string]any); replica["password"] != "s3cret" {
+		t.Errorf("replicas.0.password = %v", replica["password"])
+	}
+	if keys := c.encrypted["database"]; len(keys) != 2 || keys[0] != "password" || keys[1] != "replicas.0.password" {
+		t.Errorf("encrypted keys = %v", c.encrypted)
+	}
+}
+
+func TestRebuild_MissingKey(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	secret, _ := EncryptValue("s3cret", key)
+	t.Setenv(EncryptKeyEnv, "")
+
+	c := &Configure{cfgFileNameMap: map[string]map[string]any{
+		"database": {"password": secret, "abs-path": "/conf/database.yaml"},
+	}}
+	if err := c.rebuild(); err == nil {
+		t.Fatal("expected error when key is missing")
+	}
+}
@@ -0,0 +1,159 @@
+package conf
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+)
+
+// FieldError 单个配置项的校验错误
+type FieldError struct {
+	File    string // 配置文件名，如 router.yaml
+	Key     string // 配置项路径，如 server.port
+	Message string // 错误描述
+}
+
+func (e FieldError) String() string {
+	if e.Key == "" {
+		return fmt.Sprintf("%s: %s", e.File, e.Message)
+	}
+	return fmt.Sprintf("%s: %s %s", e.File, e.Key, e.Message)
+}
+
+// ValidationError 配置校验错误，汇总所有配置文件中的错误一次性返回
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("配置校验失败，共 %d 处错误:", len(e.Errors)))
+	for _, fe := range e.Errors {
+		b.WriteString("\n  - ")
+		b.WriteString(fe.String())
+	}
+	return b.String()
+}
+
+func (e *ValidationError) add(file, key, msg string) {
+	e.Errors = append(e.Errors, FieldError{File: file, Key: key, Message: msg})
+}
+
+// mapstructure 的错误信息以 'key' 开头
+var decodeErrKeyRegexp = regexp.MustCompile(`^'([^']*)'\s*(.*)$`)
+
+// Validate 按照配置结构体校验已加载的配置文件
+// schemas 为 配置文件名 => 配置结构体指针，结构体使用 json 标签映射配置项，validate 标签声明校验规则
+func (i *Configure) Validate(schemas map[string]any) error {
+	result := &ValidationError{}
+	v := newConfigValidator()
+
+	files := make([]string, 0, len(schemas))
+	for file := range schemas {
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		schema := schemas[file]
+		typ := reflect.TypeOf(schema)
+		if typ == nil || typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Struct {
+			result.add(file, "", "配置结构体必须为结构体指针")
+			continue
+		}
+
+		name := strings.TrimSuffix(file, filepath.Ext(file))
+		raw, ok := i.raw(name)
+		if !ok {
+			result.add(file, "", "配置文件不存在")
+			continue
+		}
+
+		// 每次校验使用新的实例，避免污染调用方传入的结构体
+		target := reflect.New(typ.Elem()).Interface()
+		reported := make(map[string]struct{})
+
+		decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+			TagName: "json",
+			Result:  target,
+		})
+		if err != nil {
+			result.add(file, "", err.Error())
+			continue
+		}
+
+		if err = decoder.Decode(raw); err != nil {
+			var decodeErr *mapstructure.Error
+			if errors.As(err, &decodeErr) {
+				for _, msg := range decodeErr.Errors {
+					key, detail := splitDecodeError(msg)
+					reported[key] = struct{}{}
+					result.add(file, key, "类型错误: "+detail)
+				}
+			} else {
+				result.add(file, "", err.Error())
+			}
+		}
+
+		err = v.Struct(target)
+		var validationErrs validator.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			for _, fe := range validationErrs {
+				key := namespaceToKey(fe.Namespace())
+				// 类型错误的配置项已经报告过，不再重复报告
+				if _, ok := reported[key]; ok {
+					continue
+				}
+				result.add(file, key, describeFieldError(fe))
+			}
+		}
+	}
+
+	if len(result.Errors) > 0 {
+		return result
+	}
+	return nil
+}
+
+func newConfigValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(fld reflect.StructField) string {
+		name := strings.Split(fld.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			return fld.Name
+		}
+		return name
+	})
+	return v
+}
+
+// namespaceToKey 去掉命名空间中的结构体名称，RouterOptions.server.port => server.port
+func namespaceToKey(ns string) string {
+	if idx := strings.Index(ns, "."); idx >= 0 {
+		return ns[idx+1:]
+	}
+	return ns
+}
+
+func splitDecodeError(msg string) (string, string) {
+	matches := decodeErrKeyRegexp.FindStringSubmatch(msg)
+	if len(matches) != 3 {
+		return "", msg
+	}
+	return matches[1], matches[2]
+}
+
+func describeFieldError(fe validator.FieldError) string {
+	rule := fe.Tag()
+	if fe.Param() != "" {
+		rule += "=" + fe.Param()
+	}
+	return fmt.Sprintf("不满足校验规则 %s，当前值: %v", rule, fe.Value())
+}
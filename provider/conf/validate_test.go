@@ -0,0 +1,66 @@
+package conf
+
+import (
+	"errors"
+	"testing"
+)
+
+type testServer struct {
+	Host string `json:"host" validate:"required"`
+	Port int    `json:"port" validate:"min=1,max=65535"`
+}
+
+type testOptions struct {
+	Mode   string     `json:"mode" validate:"oneof=debug release"`
+	Server testServer `json:"server"`
+}
+
+func TestValidate_ReportsAllErrors(t *testing.T) {
+	c := &Configure{merged: map[string]map[string]any{
+		"router": {
+			"mode":   "prod",
+			"server": map[string]any{"host": "", "port": "abc"},
+		},
+	}}
+
+	err := c.Validate(map[string]any{
+		"router.yaml":  &testOptions{},
+		"missing.yaml": &testOptions{},
+		"invalid.yaml": testOptions{},
+	})
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+
+	got := make(map[string]bool)
+	for _, fe := range validationErr.Errors {
+		got[fe.File+"|"+fe.Key] = true
+	}
+
+	for _, want := range []string{
+		"router.yaml|mode",
+		"router.yaml|server.host",
+		"router.yaml|server.port",
+		"missing.yaml|",
+		"invalid.yaml|",
+	} {
+		if !got[want] {
+			t.Errorf("missing error %s, got %v", want, validationErr.Errors)
+		}
+	}
+}
+
+func TestValidate_Success(t *testing.T) {
+	c := &Configure{merged: map[string]map[string]any{
+		"router": {
+			"mode":   "debug",
+			"server": map[string]any{"host": "0.0.0.0", "port": 8080},
+		},
+	}}
+
+	if err := c.Validate(map[string]any{"router.yaml": &testOptions{}}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
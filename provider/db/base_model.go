@@ -39,7 +39,8 @@ func (i *BaseModel) BeforeCreate(tx *gorm.DB) (err error) {
 func (i *BaseModel) BeforeUpdate(tx *gorm.DB) (err error) {
 	ctx := tx.Statement.Context
 
-	i.UpdaterID = cast.ToString(ctx.Value("user_id"))
-	i.UpdaterName = cast.ToString(ctx.Value("username"))
+	// 使用 SetColumn 保证按 map 或指定字段更新时，修改人也会被写入
+	tx.Statement.SetColumn("UpdaterID", cast.ToString(ctx.Value("user_id")), true)
+	tx.Statement.SetColumn("UpdaterName", cast.ToString(ctx.Value("username")), true)
 	return
 }
@@ -2,7 +2,11 @@ package db
 
 import (
 	"context"
+	"reflect"
+
+	"github.com/spf13/cast"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ChangeStatus struct {
@@ -10,9 +14,20 @@ type ChangeStatus struct {
 	Status int  `json:"status"`
 }
 
+// trashedMode 软删除数据的查询方式
+type trashedMode int
+
+const (
+	withoutTrashed trashedMode = iota // 默认不包含已删除的数据
+	withTrashed                       // 包含已删除的数据
+	onlyTrashed                       // 只查询已删除的数据
+)
+
 type BaseRepository[T any] struct {
-	db  *gorm.DB
-	ctx context.Context
+	db        *gorm.DB
+	ctx       context.Context
+	trashed   trashedMode
+	skipCount bool
 }
 
 func NewBaseRepository[T any](db *gorm.DB) BaseRepository[T] {
@@ -21,45 +36,178 @@ func NewBaseRepository[T any](db *gorm.DB) BaseRepository[T] {
 	}
 }
 
+// WithContext 返回使用指定上下文的仓库，上下文中的 user_id、username 会被 BaseModel 的钩子写入创建人、修改人
+// 返回的是副本，不再修改当前仓库，仓库可以在请求间共享，需要链式调用 repo.WithContext(ctx).Create(data)
+// 单独调用 repo.WithContext(ctx) 后再调用 repo.Create(data) 不会使用该上下文
+func (i *BaseRepository[T]) WithContext(ctx context.Context) *BaseRepository[T] {
+	repo := *i
+	repo.ctx = ctx
+	return &repo
+}
+
+// WithTrashed 返回包含已软删除数据的仓库
+func (i *BaseRepository[T]) WithTrashed() *BaseRepository[T] {
+	repo := *i
+	repo.trashed = withTrashed
+	return &repo
+}
+
+// OnlyTrashed 返回只查询已软删除数据的仓库
+func (i *BaseRepository[T]) OnlyTrashed() *BaseRepository[T] {
+	repo := *i
+	repo.trashed = onlyTrashed
+	return &repo
+}
+
+// WithoutCount 返回分页查询时不执行 COUNT(*) 的仓库，Retrieve 返回的总数为 -1
+// 适用于数据量很大、前端不需要总页数的列表，router.PageSuccess 收到 -1 时响应中不包含总数
+func (i *BaseRepository[T]) WithoutCount() *BaseRepository[T] {
+	repo := *i
+	repo.skipCount = true
+	return &repo
+}
+
+// DB 返回携带上下文和软删除查询方式的 *gorm.DB，用于编写仓库中没有的查询
+// 上下文中有 Tx 开启的事务时，使用该事务
+func (i *BaseRepository[T]) DB() *gorm.DB {
+	db := FromContext(i.ctx, i.db)
+
+	switch i.trashed {
+	case withTrashed:
+		db = db.Unscoped()
+	case onlyTrashed:
+		db = db.Unscoped().Where("deleted_at IS NOT NULL")
+	}
+	return db
+}
+
+// Transaction 在事务中执行，fn 中的仓库使用同一个事务，返回错误时回滚
+// 上下文中已有事务时使用保存点嵌套在该事务中
+func (i *BaseRepository[T]) Transaction(fn func(repo *BaseRepository[T]) error) error {
+	return i.DB().Transaction(func(tx *gorm.DB) error {
+		repo := *i
+		repo.db = tx
+		return fn(&repo)
+	})
+}
+
+// Create 创建数据
+func (i *BaseRepository[T]) Create(data *T) error {
+	return i.DB().Create(data).Error
+}
+
+// BulkCreate 批量创建数据，batchSize 小于等于 0 时一次性写入
+func (i *BaseRepository[T]) BulkCreate(list []*T, batchSize int) error {
+	if len(list) == 0 {
+		return nil
+	}
+	if batchSize <= 0 {
+		return i.DB().Create(list).Error
+	}
+	return i.DB().CreateInBatches(list, batchSize).Error
+}
+
 // Save 保存或者更新， save 会保存所有字段，包括零值字段
 func (i *BaseRepository[T]) Save(data *T) error {
-	db := i.db.Model(data).Save(&data)
-	return db.Error
+	return i.DB().Save(data).Error
+}
+
+// Update 更新非零值字段
+func (i *BaseRepository[T]) Update(data *T) error {
+	return i.DB().Model(data).Updates(data).Error
+}
+
+// UpdateFields 按主键更新指定字段，包括零值，fields 的键为列名
+func (i *BaseRepository[T]) UpdateFields(id any, fields map[string]any) error {
+	return i.DB().Model(new(T)).Where("id = ?", id).Updates(fields).Error
+}
+
+// Upsert 插入数据，conflictColumns 冲突时更新 updateColumns，updateColumns 为空时更新全部字段
+func (i *BaseRepository[T]) Upsert(data *T, conflictColumns []string, updateColumns ...string) error {
+	onConflict := clause.OnConflict{UpdateAll: len(updateColumns) == 0}
+	for _, column := range conflictColumns {
+		onConflict.Columns = append(onConflict.Columns, clause.Column{Name: column})
+	}
+	if len(updateColumns) > 0 {
+		onConflict.DoUpdates = clause.AssignmentColumns(updateColumns)
+	}
+	return i.DB().Clauses(onConflict).Create(data).Error
 }
 
-// Delete 删除数据
+// Delete 删除数据，模型包含 DeletedAt 时为软删除，不受 WithTrashed、OnlyTrashed 影响，永久删除使用 ForceDelete
 func (i *BaseRepository[T]) Delete(ids ...any) error {
-	return i.db.Model(new(T)).Where("id in ?", ids).Delete(nil).Error
+	return FromContext(i.ctx, i.db).Where("id in ?", ids).Delete(new(T)).Error
 }
 
-func (i *BaseRepository[T]) WithContext(ctx context.Context) *BaseRepository[T] {
-	i.ctx = ctx
-	return i
+// Restore 恢复软删除的数据
+func (i *BaseRepository[T]) Restore(ids ...any) error {
+	return i.DB().Unscoped().Model(new(T)).Where("id in ?", ids).Update("deleted_at", nil).Error
+}
+
+// ForceDelete 永久删除数据，包括已软删除的数据
+func (i *BaseRepository[T]) ForceDelete(ids ...any) error {
+	return i.DB().Unscoped().Where("id in ?", ids).Delete(new(T)).Error
 }
 
 // Detail 获取详情
 func (i *BaseRepository[T]) Detail(id any) (*T, error) {
 	model := new(T)
-	db := i.db.Model(model).Where("id", id).First(model)
+	db := i.DB().Model(model).Where("id", id).First(model)
 	return model, db.Error
 }
 
-// Retrieve 获取详情
+// Retrieve 分页查询，使用 WithoutCount 时不查询总数，count 为 -1
 func (i *BaseRepository[T]) Retrieve(page, pageSize int, fn func(db *gorm.DB)) (count int64, list []T, err error) {
 	var model T
-	newDB := i.db.WithContext(i.ctx).Model(model)
+	newDB := i.DB().Model(model)
 	if fn != nil {
 		fn(newDB)
 	}
-	newDB.Count(&count)
+	if i.skipCount {
+		count = -1
+	} else {
+		newDB.Count(&count)
+	}
 	err = newDB.Scopes(Paginate(page, pageSize)).Find(&list).Error
 	return
 }
 
+// RetrieveByFilter 按查询条件结构体分页查询，条件规则见 ApplyFilter，条件无效时返回 ErrInvalidFilter
+func (i *BaseRepository[T]) RetrieveByFilter(page, pageSize int, filter any) (count int64, list []T, err error) {
+	return i.Retrieve(page, pageSize, func(db *gorm.DB) {
+		if err := ApplyFilter(db, filter); err != nil {
+			_ = db.AddError(err)
+		}
+	})
+}
+
+// RetrieveByCursor 按雪花 ID 倒序的游标分页，不使用 OFFSET，适合大表
+// cursor 为上一页返回的 nextCursor，为空时查询第一页；nextCursor 为空表示没有下一页
+// fn 中的排序不生效，始终按主键倒序
+func (i *BaseRepository[T]) RetrieveByCursor(cursor string, pageSize int, fn func(db *gorm.DB)) (list []T, nextCursor string, err error) {
+	pageSize = PageSize(pageSize)
+	newDB := i.DB().Model(new(T))
+	if fn != nil {
+		fn(newDB)
+	}
+	result := newDB.Scopes(CursorPaginate(cursor, pageSize)).Find(&list)
+	if err = result.Error; err != nil || len(list) <= pageSize {
+		return list, "", err
+	}
+
+	list = list[:pageSize]
+	schema := result.Statement.Schema
+	if schema == nil || schema.PrioritizedPrimaryField == nil {
+		return list, "", gorm.ErrPrimaryKeyRequired
+	}
+	id, _ := schema.PrioritizedPrimaryField.ValueOf(result.Statement.Context, reflect.ValueOf(&list[pageSize-1]).Elem())
+	return list, EncodeCursor(cast.ToUint64(id)), nil
+}
+
 // Unique 唯一性判断
 func (i *BaseRepository[T]) Unique(id uint, fn func(db *gorm.DB)) (*T, bool) {
 	model := new(T)
-	db := i.db.Model(model)
+	db := i.DB().Model(model)
 	var count int64
 	fn(db)
 	if id > 0 {
@@ -75,7 +223,7 @@ func (i *BaseRepository[T]) Unique(id uint, fn func(db *gorm.DB)) (*T, bool) {
 
 // ChangeStatus 更改状态，我们经常会需要单独的更改状态，比如禁用，启用等。
 func (i *BaseRepository[T]) ChangeStatus(req *ChangeStatus) error {
-	return i.db.Model(new(T)).
+	return i.DB().Model(new(T)).
 		Where("id", req.ID).
 		Update("status", req.Status).Error
 }
@@ -0,0 +1,196 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"bit-labs.cn/owl/utils"
+	"gorm.io/gorm"
+)
+
+type repoArticle struct {
+	BaseModel
+	Code   string `gorm:"uniqueIndex;size:32"`
+	Title  string
+	Status int
+}
+
+func newArticleRepo(t *testing.T) *BaseRepository[repoArticle] {
+	t.Helper()
+	if err := utils.InitSnowFlakeWorker(1, 3); err != nil {
+		t.Fatal(err)
+	}
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&repoArticle{}); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewBaseRepository[repoArticle](db)
+	ctx := context.WithValue(context.WithValue(context.Background(), "user_id", "1001"), "username", "admin")
+	return repo.WithContext(ctx)
+}
+
+func TestBaseRepository_ContextAndUpdate(t *testing.T) {
+	repo := newArticleRepo(t)
+
+	article := &repoArticle{Code: "a1", Title: "hello", Status: 1}
+	if err := repo.Create(article); err != nil {
+		t.Fatal(err)
+	}
+	if article.CreatorID != "1001" || article.CreatorName != "admin" {
+		t.Fatalf("creator = %s/%s", article.CreatorID, article.CreatorName)
+	}
+
+	if err := repo.UpdateFields(article.ID, map[string]any{"status": 0}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.Detail(article.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != 0 || got.UpdaterID != "1001" {
+		t.Fatalf("status = %d, updater = %s", got.Status, got.UpdaterID)
+	}
+
+	got.Title = "world"
+	if err = repo.Save(got); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = repo.Detail(article.ID); got.Title != "world" {
+		t.Fatalf("title = %s", got.Title)
+	}
+	// WithContext 返回副本，不修改原仓库的上下文
+	if other := repo.WithContext(context.Background()); other == repo || repo.ctx == other.ctx {
+		t.Fatal("WithContext should return a copy")
+	}
+}
+
+func TestBaseRepository_SoftDelete(t *testing.T) {
+	repo := newArticleRepo(t)
+	list := []*repoArticle{{Code: "a1"}, {Code: "a2"}, {Code: "a3"}}
+	if err := repo.BulkCreate(list, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.Delete(list[0].ID, list[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	count := func(r *BaseRepository[repoArticle]) int64 {
+		c, _, err := r.Retrieve(1, 10, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	if c := count(repo); c != 1 {
+		t.Fatalf("without trashed = %d", c)
+	}
+	if c := count(repo.WithTrashed()); c != 3 {
+		t.Fatalf("with trashed = %d", c)
+	}
+	if c := count(repo.OnlyTrashed()); c != 2 {
+		t.Fatalf("only trashed = %d", c)
+	}
+
+	if err := repo.Restore(list[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.ForceDelete(list[1].ID); err != nil {
+		t.Fatal(err)
+	}
+	if c := count(repo); c != 2 {
+		t.Fatalf("after restore = %d", c)
+	}
+	if c := count(repo.WithTrashed()); c != 2 {
+		t.Fatalf("after force delete = %d", c)
+	}
+
+	// WithTrashed 只影响查询，删除仍然为软删除
+	if err := repo.WithTrashed().Delete(list[2].ID); err != nil {
+		t.Fatal(err)
+	}
+	if c := count(repo.OnlyTrashed()); c != 1 {
+		t.Fatalf("with trashed delete should be soft, only trashed = %d", c)
+	}
+}
+
+func TestBaseRepository_TransactionAndUpsert(t *testing.T) {
+	repo := newArticleRepo(t)
+
+	errRollback := errors.New("rollback")
+	err := repo.Transaction(func(tx *BaseRepository[repoArticle]) error {
+		if err := tx.Create(&repoArticle{Code: "t1"}); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("err = %v", err)
+	}
+	if c, _, _ := repo.Retrieve(1, 10, nil); c != 0 {
+		t.Fatalf("transaction not rolled back, count = %d", c)
+	}
+
+	if err = repo.Upsert(&repoArticle{Code: "u1", Title: "v1"}, []string{"code"}, "title"); err != nil {
+		t.Fatal(err)
+	}
+	if err = repo.Upsert(&repoArticle{Code: "u1", Title: "v2"}, []string{"code"}, "title"); err != nil {
+		t.Fatal(err)
+	}
+	c, list, _ := repo.Retrieve(1, 10, nil)
+	if c != 1 || list[0].Title != "v2" {
+		t.Fatalf("upsert count = %d, list = %+v", c, list)
+	}
+}
+
+func TestBaseRepository_RetrieveByCursor(t *testing.T) {
+	repo := newArticleRepo(t)
+	var list []*repoArticle
+	for _, code := range []string{"c1", "c2", "c3", "c4", "c5"} {
+		list = append(list, &repoArticle{Code: code})
+	}
+	if err := repo.BulkCreate(list, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// fn 中的排序被忽略，始终按主键倒序
+	for _, fn := range []func(db *gorm.DB){nil, func(db *gorm.DB) { db.Order("code ASC") }} {
+		var codes []string
+		cursor := ""
+		for page := 0; page < 5; page++ {
+			items, next, err := repo.RetrieveByCursor(cursor, 2, fn)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, item := range items {
+				codes = append(codes, item.Code)
+			}
+			if next == "" {
+				break
+			}
+			cursor = next
+		}
+		if got := strings.Join(codes, ","); got != "c5,c4,c3,c2,c1" {
+			t.Fatalf("codes = %s", got)
+		}
+	}
+
+	if _, _, err := repo.RetrieveByCursor("bad", 2, nil); !errors.Is(err, ErrInvalidCursor) {
+		t.Fatalf("err = %v", err)
+	}
+
+	count, items, err := repo.WithoutCount().Retrieve(1, 2, nil)
+	if err != nil || count != -1 || len(items) != 2 {
+		t.Fatalf("without count = %d, %d items, err = %v", count, len(items), err)
+	}
+}
+
+func TestPageSize(t *testing.T) {
+	cases := map[int]int{0: 10, -1: 10, 20: 20, 100: 100, 101: 100, 5000: 100}
+	for in, want := range cases {
+		if got := PageSize(in); got != want {
+			t.Errorf("PageSize(%d) = %d, want %d", in, got, want)
+		}
+	}
+}
@@ -1,23 +1,70 @@
 package db
 
 import (
+	"sync/atomic"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	defaultPageSize atomic.Int64
+	maxPageSize     atomic.Int64
 )
 
+func init() {
+	defaultPageSize.Store(10)
+	maxPageSize.Store(100)
+}
+
+// SetPageSize 设置默认每页数量和每页最大数量，小于等于 0 的值不修改
+func SetPageSize(defaultSize, maxSize int) {
+	if defaultSize > 0 {
+		defaultPageSize.Store(int64(defaultSize))
+	}
+	if maxSize > 0 {
+		maxPageSize.Store(int64(maxSize))
+	}
+}
+
+// PageSize 修正每页数量，小于等于 0 时使用默认值，超过最大值时使用最大值
+func PageSize(pageSize int) int {
+	switch {
+	case pageSize <= 0:
+		return int(defaultPageSize.Load())
+	case pageSize > int(maxPageSize.Load()):
+		return int(maxPageSize.Load())
+	}
+	return pageSize
+}
+
 func Paginate(page, pageSize int) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
-		if page == 0 {
+		if page <= 0 {
 			page = 1
 		}
-
-		switch {
-		case pageSize > 100:
-			pageSize = 1000
-		case pageSize <= 0:
-			pageSize = 10
-		}
+		pageSize = PageSize(pageSize)
 
 		offset := (page - 1) * pageSize
 		return db.Offset(offset).Limit(pageSize)
 	}
 }
+
+// CursorPaginate 按主键倒序的游标分页，cursor 为上一页返回的游标，为空时从第一页开始
+// 多查询一条数据用于判断是否还有下一页，游标无效时返回 ErrInvalidCursor
+// 游标只记录主键，查询中已有的排序(如过滤条件的 sort)会被清除，否则游标条件与排序不一致
+func CursorPaginate(cursor string, pageSize int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		delete(db.Statement.Clauses, "ORDER BY")
+		primaryKey := clause.Column{Table: clause.CurrentTable, Name: clause.PrimaryKey}
+		if cursor != "" {
+			id, err := DecodeCursor(cursor)
+			if err != nil {
+				_ = db.AddError(err)
+				return db
+			}
+			db = db.Where(clause.Lt{Column: primaryKey, Value: id})
+		}
+		return db.Order(clause.OrderByColumn{Column: primaryKey, Desc: true}).Limit(PageSize(pageSize) + 1)
+	}
+}
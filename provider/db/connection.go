@@ -0,0 +1,111 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"bit-labs.cn/owl/contract/log"
+	"gorm.io/gorm"
+)
+
+// DefaultConnection 默认连接名称，对应 database.yaml 顶层的配置
+const DefaultConnection = "default"
+
+// Manager 数据库连接管理器，按名称获取连接，连接在第一次使用时创建
+//
+//	reporting := manager.Connection("reporting")
+//
+// 命名连接同时以 dig.Name 注册到容器中，可以通过 `name:"reporting"` 标签注入
+type Manager struct {
+	opt     *Options
+	log     log.Logger
+	prepare func(name string, opt *Options)
+	lock    sync.Mutex
+	conns   map[string]*gorm.DB
+}
+
+// NewManager prepare 在创建连接之前调整配置，如 sqlite 文件路径，可以为空
+func NewManager(opt *Options, l log.Logger, prepare func(name string, opt *Options)) *Manager {
+	return &Manager{
+		opt:     opt,
+		log:     l,
+		prepare: prepare,
+		conns:   make(map[string]*gorm.DB),
+	}
+}
+
+// Connection 获取命名连接，连接未配置或连接失败时 panic
+func (i *Manager) Connection(name string) *gorm.DB {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if conn, ok := i.conns[name]; ok {
+		return conn
+	}
+
+	opt, ok := i.Options(name)
+	if !ok {
+		panic(fmt.Sprintf("数据库连接 %s 未配置，请检查 database.yaml 中的 connections", name))
+	}
+	if i.prepare != nil {
+		i.prepare(name, opt)
+	}
+
+	conn := InitDB(opt, i.log)
+	i.conns[name] = conn
+	if name == DefaultConnection {
+		setDefaultDB(conn)
+	}
+	return conn
+}
+
+// Default 获取默认连接
+func (i *Manager) Default() *gorm.DB {
+	return i.Connection(DefaultConnection)
+}
+
+// Options 返回命名连接的配置副本
+func (i *Manager) Options(name string) (*Options, bool) {
+	if name == DefaultConnection {
+		opt := *i.opt
+		opt.Connections = nil
+		return &opt, true
+	}
+	opt, ok := i.opt.Connections[name]
+	if !ok {
+		return nil, false
+	}
+	opt.Connections = nil
+	return &opt, true
+}
+
+// Names 返回所有连接名称，默认连接排在第一位
+func (i *Manager) Names() []string {
+	return i.opt.ConnectionNames()
+}
+
+// ConnectionNames 返回配置中的所有连接名称，默认连接排在第一位
+func (i *Options) ConnectionNames() []string {
+	names := make([]string, 0, len(i.Connections))
+	for name := range i.Connections {
+		if name != DefaultConnection {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return append([]string{DefaultConnection}, names...)
+}
+
+// HasReplicas 默认连接或任意命名连接配置了从库
+func (i *Options) HasReplicas() bool {
+	if len(i.Replicas) > 0 {
+		return true
+	}
+	for _, conn := range i.Connections {
+		if len(conn.Replicas) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -1,10 +1,37 @@
 package db
 
-import "gorm.io/gorm"
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
 
 type Connector interface {
 	Open(cfg *gorm.Config) (*gorm.DB, error) // 连接数据库
+	Dialector() gorm.Dialector               // 返回 gorm 方言，读写分离时用于创建从库连接
 	Options() *Options                       // 返回配置
 	DefaultOptions() *Options                // 返回默认配置
 	GetDSN() string                          // 获取 DSN
 }
+
+// NewConnector 根据数据库类型创建连接器
+func NewConnector(opt *Options) (Connector, error) {
+	switch opt.Driver {
+	case Mysql:
+		return NewMysqlConnector(opt), nil
+	case Pgsql:
+		return NewPgSqlGetter(opt), nil
+	case Sqlite:
+		return NewSqliteGetter(opt), nil
+	case SQLServer:
+		return NewSqlServerConnector(opt), nil
+	case ClickHouse:
+		return NewClickHouseConnector(opt), nil
+	case DM:
+		return NewDMConnector(opt)
+	case Oracle:
+		return NewOracleConnector(opt)
+	default:
+		return nil, fmt.Errorf("不支持的数据库类型: %s", opt.Driver)
+	}
+}
@@ -0,0 +1,58 @@
+package db
+
+import (
+	"fmt"
+	"net/url"
+
+	"gorm.io/driver/clickhouse"
+	"gorm.io/gorm"
+)
+
+type clickHouseConnector struct {
+	opt *Options
+}
+
+func NewClickHouseConnector(opt *Options) *clickHouseConnector {
+	return &clickHouseConnector{opt: opt}
+}
+
+func (i *clickHouseConnector) Open(cfg *gorm.Config) (*gorm.DB, error) {
+	openDb, err := gorm.Open(i.Dialector(), cfg)
+	return openDb, err
+}
+
+func (i *clickHouseConnector) Dialector() gorm.Dialector {
+	return clickhouse.Open(i.GetDSN())
+}
+
+func (i *clickHouseConnector) Options() *Options {
+	if i.opt != nil {
+		return i.opt
+	}
+	return i.DefaultOptions()
+}
+
+func (i *clickHouseConnector) DefaultOptions() *Options {
+	return &Options{
+		Host:         "127.0.0.1",
+		Port:         9000,
+		Username:     "default",
+		Driver:       ClickHouse,
+		Database:     "default",
+		Query:        "dial_timeout=10s&read_timeout=20s",
+		MaxIdleConns: 10,
+		MaxConns:     100,
+	}
+}
+
+// GetDSN clickhouse://用户名:密码@主机:端口/数据库?其他参数
+func (i *clickHouseConnector) GetDSN() string {
+	dsn := url.URL{
+		Scheme:   "clickhouse",
+		User:     url.UserPassword(i.opt.Username, i.opt.Password),
+		Host:     fmt.Sprintf("%s:%d", i.opt.Host, i.opt.Port),
+		Path:     "/" + i.opt.Database,
+		RawQuery: i.opt.Query,
+	}
+	return dsn.String()
+}
@@ -0,0 +1,127 @@
+package db
+
+import (
+	"fmt"
+	"net/url"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// 达梦、Oracle 的 gorm 方言依赖厂商驱动，框架不直接引入，由项目引入驱动后注册
+var (
+	dialectorsLock sync.RWMutex
+	dialectors     = make(map[Driver]func(dsn string) gorm.Dialector)
+)
+
+// RegisterDialector 注册数据库方言，框架负责根据配置生成 DSN，如
+//
+//	db.RegisterDialector(db.DM, func(dsn string) gorm.Dialector { return dameng.Open(dsn) })
+func RegisterDialector(driver Driver, open func(dsn string) gorm.Dialector) {
+	dialectorsLock.Lock()
+	dialectors[driver] = open
+	dialectorsLock.Unlock()
+}
+
+func registeredDialector(driver Driver) (func(dsn string) gorm.Dialector, bool) {
+	dialectorsLock.RLock()
+	defer dialectorsLock.RUnlock()
+	open, ok := dialectors[driver]
+	return open, ok
+}
+
+// dialectorConnector 使用注册的方言连接数据库
+type dialectorConnector struct {
+	opt      *Options
+	open     func(dsn string) gorm.Dialector
+	dsn      func(opt *Options) string
+	defaults *Options
+}
+
+func newDialectorConnector(opt *Options, defaults *Options, dsn func(opt *Options) string) (*dialectorConnector, error) {
+	open, ok := registeredDialector(opt.Driver)
+	if !ok {
+		return nil, fmt.Errorf("数据库类型 %s 的方言未注册，请引入对应的 gorm 驱动并调用 db.RegisterDialector 注册", opt.Driver)
+	}
+	return &dialectorConnector{opt: opt, open: open, dsn: dsn, defaults: defaults}, nil
+}
+
+// NewDMConnector 达梦数据库连接器，database 对应模式名
+func NewDMConnector(opt *Options) (Connector, error) {
+	return newDialectorConnector(opt, &Options{
+		Host:         "127.0.0.1",
+		Port:         5236,
+		Username:     "SYSDBA",
+		Driver:       DM,
+		MaxIdleConns: 10,
+		MaxConns:     100,
+	}, dmDSN)
+}
+
+// NewOracleConnector Oracle 连接器，database 对应服务名
+func NewOracleConnector(opt *Options) (Connector, error) {
+	return newDialectorConnector(opt, &Options{
+		Host:         "127.0.0.1",
+		Port:         1521,
+		Username:     "system",
+		Driver:       Oracle,
+		Database:     "ORCLPDB1",
+		MaxIdleConns: 10,
+		MaxConns:     100,
+	}, oracleDSN)
+}
+
+func (i *dialectorConnector) Open(cfg *gorm.Config) (*gorm.DB, error) {
+	openDb, err := gorm.Open(i.Dialector(), cfg)
+	return openDb, err
+}
+
+func (i *dialectorConnector) Dialector() gorm.Dialector {
+	return i.open(i.GetDSN())
+}
+
+func (i *dialectorConnector) Options() *Options {
+	if i.opt != nil {
+		return i.opt
+	}
+	return i.DefaultOptions()
+}
+
+func (i *dialectorConnector) DefaultOptions() *Options {
+	return i.defaults
+}
+
+func (i *dialectorConnector) GetDSN() string {
+	return i.dsn(i.opt)
+}
+
+// dmDSN dm://用户名:密码@主机:端口?schema=模式&其他参数
+func dmDSN(opt *Options) string {
+	query := url.Values{}
+	schema := opt.Schema
+	if schema == "" {
+		schema = opt.Database
+	}
+	if schema != "" {
+		query.Set("schema", schema)
+	}
+	dsn := url.URL{
+		Scheme:   "dm",
+		User:     url.UserPassword(opt.Username, opt.Password),
+		Host:     fmt.Sprintf("%s:%d", opt.Host, opt.Port),
+		RawQuery: joinQuery(query.Encode(), opt.Query),
+	}
+	return dsn.String()
+}
+
+// oracleDSN oracle://用户名:密码@主机:端口/服务名?其他参数
+func oracleDSN(opt *Options) string {
+	dsn := url.URL{
+		Scheme:   "oracle",
+		User:     url.UserPassword(opt.Username, opt.Password),
+		Host:     fmt.Sprintf("%s:%d", opt.Host, opt.Port),
+		Path:     "/" + opt.Database,
+		RawQuery: opt.Query,
+	}
+	return dsn.String()
+}
@@ -15,10 +15,14 @@ func NewMysqlConnector(opt *Options) *MysqlConnector {
 	return &MysqlConnector{opt: opt}
 }
 func (i *MysqlConnector) Open(cfg *gorm.Config) (*gorm.DB, error) {
-	openDb, err := gorm.Open(mysql.Open(i.GetDSN()), cfg)
+	openDb, err := gorm.Open(i.Dialector(), cfg)
 	return openDb, err
 }
 
+func (i *MysqlConnector) Dialector() gorm.Dialector {
+	return mysql.Open(i.GetDSN())
+}
+
 func (i *MysqlConnector) Options() *Options {
 	if i.opt != nil {
 		return i.opt
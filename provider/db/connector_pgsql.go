@@ -15,10 +15,14 @@ func NewPgSqlGetter(opt *Options) *pgsqlConnector {
 	return &pgsqlConnector{opt}
 }
 func (i *pgsqlConnector) Open(cfg *gorm.Config) (*gorm.DB, error) {
-	openDb, err := gorm.Open(postgres.Open(i.GetDSN()), cfg)
+	openDb, err := gorm.Open(i.Dialector(), cfg)
 	return openDb, err
 }
 
+func (i *pgsqlConnector) Dialector() gorm.Dialector {
+	return postgres.Open(i.GetDSN())
+}
+
 func (i *pgsqlConnector) Options() *Options {
 	if i.opt != nil {
 		return i.opt
@@ -13,9 +13,12 @@ func NewSqliteGetter(opt *Options) *sqliteGetter {
 	return &sqliteGetter{opt}
 }
 func (i *sqliteGetter) Open(cfg *gorm.Config) (*gorm.DB, error) {
-	openDb, err := gorm.Open(sqlite.Open(i.GetDSN()), cfg)
+	openDb, err := gorm.Open(i.Dialector(), cfg)
 	return openDb, err
+}
 
+func (i *sqliteGetter) Dialector() gorm.Dialector {
+	return sqlite.Open(i.GetDSN())
 }
 func (i *sqliteGetter) Options() *Options {
 	if i.opt != nil {
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"fmt"
+	"net/url"
+
+	"gorm.io/driver/sqlserver"
+	"gorm.io/gorm"
+)
+
+type sqlServerConnector struct {
+	opt *Options
+}
+
+func NewSqlServerConnector(opt *Options) *sqlServerConnector {
+	return &sqlServerConnector{opt: opt}
+}
+
+func (i *sqlServerConnector) Open(cfg *gorm.Config) (*gorm.DB, error) {
+	openDb, err := gorm.Open(i.Dialector(), cfg)
+	return openDb, err
+}
+
+func (i *sqlServerConnector) Dialector() gorm.Dialector {
+	return sqlserver.Open(i.GetDSN())
+}
+
+func (i *sqlServerConnector) Options() *Options {
+	if i.opt != nil {
+		return i.opt
+	}
+	return i.DefaultOptions()
+}
+
+func (i *sqlServerConnector) DefaultOptions() *Options {
+	return &Options{
+		Host:         "127.0.0.1",
+		Port:         1433,
+		Username:     "sa",
+		Driver:       SQLServer,
+		Database:     "master",
+		MaxIdleConns: 10,
+		MaxConns:     100,
+	}
+}
+
+// GetDSN sqlserver://用户名:密码@主机:端口?database=数据库&其他参数
+func (i *sqlServerConnector) GetDSN() string {
+	query := url.Values{}
+	query.Set("database", i.opt.Database)
+	dsn := url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(i.opt.Username, i.opt.Password),
+		Host:     fmt.Sprintf("%s:%d", i.opt.Host, i.opt.Port),
+		RawQuery: joinQuery(query.Encode(), i.opt.Query),
+	}
+	return dsn.String()
+}
+
+// joinQuery 拼接连接字符串查询参数
+func joinQuery(query, extra string) string {
+	switch {
+	case query == "":
+		return extra
+	case extra == "":
+		return query
+	default:
+		return query + "&" + extra
+	}
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestNewConnector_DSN(t *testing.T) {
+	cases := []struct {
+		opt  Options
+		want string
+	}{
+		{
+			opt:  Options{Driver: SQLServer, Host: "db", Port: 1433, Username: "sa", Password: "p@ss", Database: "owl", Query: "encrypt=disable"},
+			want: "sqlserver://sa:p%40ss@db:1433?database=owl&encrypt=disable",
+		},
+		{
+			opt:  Options{Driver: ClickHouse, Host: "ch", Port: 9000, Username: "default", Database: "logs", Query: "dial_timeout=10s"},
+			want: "clickhouse://default:@ch:9000/logs?dial_timeout=10s",
+		},
+	}
+	for _, c := range cases {
+		connector, err := NewConnector(&c.opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dsn := connector.GetDSN(); dsn != c.want {
+			t.Errorf("%s dsn = %s, want %s", c.opt.Driver, dsn, c.want)
+		}
+	}
+}
+
+func TestNewConnector_RegisteredDialector(t *testing.T) {
+	opt := &Options{Driver: Oracle, Host: "ora", Port: 1521, Username: "system", Password: "oracle", Database: "ORCLPDB1"}
+	if _, err := NewConnector(opt); err == nil || !strings.Contains(err.Error(), "RegisterDialector") {
+		t.Fatalf("expected unregistered dialector error, got %v", err)
+	}
+
+	// 测试注册的方言不保留到其他测试
+	t.Cleanup(func() {
+		dialectorsLock.Lock()
+		delete(dialectors, DM)
+		dialectorsLock.Unlock()
+	})
+	var gotDSN string
+	RegisterDialector(DM, func(dsn string) gorm.Dialector {
+		gotDSN = dsn
+		return sqlite.Open(":memory:")
+	})
+	connector, err := NewConnector(&Options{Driver: DM, Host: "dm", Port: 5236, Username: "SYSDBA", Password: "SYSDBA001", Schema: "OWL"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = connector.Open(&gorm.Config{}); err != nil {
+		t.Fatal(err)
+	}
+	if gotDSN != "dm://SYSDBA:SYSDBA001@dm:5236?schema=OWL" {
+		t.Errorf("dm dsn = %s", gotDSN)
+	}
+}
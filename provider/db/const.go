@@ -8,3 +8,4 @@ const Sqlite Driver = "sqlite"
 const DM Driver = "dm" // 达梦数据库
 const Oracle Driver = "oracle"
 const ClickHouse Driver = "clickhouse"
+const SQLServer Driver = "sqlserver"
@@ -0,0 +1,184 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"bit-labs.cn/owl/provider/router"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type articleFilter struct {
+	Title string `form:"title" filter:"title,like"`
+}
+
+type articleModule struct{}
+
+func (articleModule) ModuleName() (en string, zh string) { return "article", "文章" }
+
+func TestCrud(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router.RegisterErrorMapper(mapError)
+	repo := newArticleRepo(t)
+
+	var created []string
+	crud := router.NewCrud[repoArticle, articleFilter](repo).
+		CreateFields("Code", "Title").
+		UpdateFields("Title").
+		BeforeCreate(func(ctx *gin.Context, data *repoArticle) error {
+			if data.Code == "" {
+				return errors.New("编码不能为空")
+			}
+			return nil
+		}).
+		AfterCreate(func(ctx *gin.Context, data *repoArticle) {
+			created = append(created, data.Code)
+		})
+
+	engine := gin.New()
+	builder := router.NewRouteInfoBuilder("cms", articleModule{}, engine.Group("/api"), router.MenuOption{})
+	builder.Crud("/articles", crud)
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return w
+	}
+
+	if w := do(http.MethodPost, "/api/articles", `{"Code":"a1","Title":"hello","Status":9}`); w.Code != http.StatusOK {
+		t.Fatalf("create = %d %s", w.Code, w.Body.String())
+	}
+	do(http.MethodPost, "/api/articles", `{"Code":"a2","Title":"world"}`)
+	if w := do(http.MethodPost, "/api/articles", `{"Title":"no code"}`); w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "编码不能为空") {
+		t.Fatalf("create without code = %d %s", w.Code, w.Body.String())
+	}
+
+	var list struct {
+		router.PageResp
+		Data struct {
+			List []repoArticle `json:"list"`
+		} `json:"data"`
+	}
+	w := do(http.MethodGet, "/api/articles?page=1&pageSize=10&title=ell", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || list.Total != 1 || len(list.Data.List) != 1 {
+		t.Fatalf("retrieve = %s", w.Body.String())
+	}
+	article := list.Data.List[0]
+	// Status 不在允许提交的字段中
+	if article.Status != 0 || len(created) != 2 {
+		t.Fatalf("article = %+v, created = %v", article, created)
+	}
+
+	id := strconv.FormatUint(uint64(article.ID), 10)
+	if w = do(http.MethodPut, "/api/articles/"+id, `{"Code":"changed","Title":"hi"}`); w.Code != http.StatusOK {
+		t.Fatalf("update = %d %s", w.Code, w.Body.String())
+	}
+	got, _ := repo.Detail(article.ID)
+	if got.Title != "hi" || got.Code != "a1" {
+		t.Fatalf("after update = %+v", got)
+	}
+
+	if w = do(http.MethodPut, "/api/articles/123456", `{"Title":"missing"}`); w.Code != http.StatusNotFound {
+		t.Fatalf("update missing = %d %s", w.Code, w.Body.String())
+	}
+
+	if w = do(http.MethodDelete, "/api/articles/"+id, ""); w.Code != http.StatusOK {
+		t.Fatalf("delete = %d", w.Code)
+	}
+	if w = do(http.MethodGet, "/api/articles/"+id, ""); w.Code != http.StatusNotFound || !strings.Contains(w.Body.String(), router.CodeNotFound) {
+		t.Fatalf("detail after delete = %d", w.Code)
+	}
+
+	menu := builder.GetMenu()
+	if len(menu.Children) != 5 || menu.Children[3].Name != "Update" || menu.Children[3].Meta.Title != "修改文章" {
+		t.Fatalf("menu = %+v", menu.Children)
+	}
+	if deps := menu.Children[3].DependentsPermission; len(deps) != 2 || deps[1] != "cms:article:Detail" {
+		t.Fatalf("deps = %v", deps)
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	defer gin.SetMode(gin.TestMode)
+	router.RegisterErrorMapper(mapError)
+
+	engine := gin.New()
+	engine.Use(router.ErrorHandler(nil))
+	engine.GET("/missing", func(c *gin.Context) {
+		_ = c.Error(fmt.Errorf("查询文章: %w", gorm.ErrRecordNotFound))
+	})
+	engine.GET("/internal", func(c *gin.Context) {
+		_ = c.Error(errors.New("dial tcp 10.0.0.1:5432: connection refused"))
+	})
+	engine.GET("/validate", func(c *gin.Context) {
+		var req struct {
+			Name string `json:"name" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&req); err != nil {
+			_ = c.Error(router.BindError(err))
+		}
+	})
+
+	cases := map[string]struct {
+		status int
+		code   string
+	}{
+		"/missing":  {http.StatusNotFound, router.CodeNotFound},
+		"/internal": {http.StatusInternalServerError, router.CodeInternal},
+		"/validate": {http.StatusBadRequest, router.CodeValidation},
+	}
+	for path, want := range cases {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, strings.NewReader(`{}`)))
+		var resp router.Resp
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		if w.Code != want.status || resp.Code != want.code || resp.Success {
+			t.Errorf("%s = %d %s", path, w.Code, w.Body.String())
+		}
+		if strings.Contains(w.Body.String(), "10.0.0.1") {
+			t.Errorf("%s leaks internal error: %s", path, w.Body.String())
+		}
+		if path == "/validate" && resp.Details == nil {
+			t.Errorf("%s missing details: %s", path, w.Body.String())
+		}
+	}
+}
+
+func TestCrud_ProtectedFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router.RegisterErrorMapper(mapError)
+	repo := newArticleRepo(t)
+
+	engine := gin.New()
+	router.NewRouteInfoBuilder("cms", articleModule{}, engine.Group("/api"), router.MenuOption{}).
+		Crud("/articles", router.NewCrud[repoArticle, articleFilter](repo))
+
+	body := `{"id":"42","Code":"p1","Title":"t","creatorID":"1","creatorName":"hacker","deletedAt":"2020-01-01T00:00:00Z"}`
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/articles", strings.NewReader(body)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("create = %d %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data repoArticle `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	got, err := repo.Detail(resp.Data.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 未设置允许提交的字段时，BaseModel 的字段由系统维护
+	if got.ID == 42 || got.Code != "p1" || got.CreatorName == "hacker" || got.DeletedAt != nil {
+		t.Fatalf("article = %+v", got)
+	}
+}
@@ -0,0 +1,265 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+
+	"bit-labs.cn/owl/provider/router"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// DataScope 数据权限范围
+type DataScope string
+
+const (
+	DataScopeAll             DataScope = "all"               // 全部数据
+	DataScopeOwn             DataScope = "own"               // 仅本人创建的数据
+	DataScopeDept            DataScope = "dept"              // 本部门数据
+	DataScopeDeptAndChildren DataScope = "dept_and_children" // 本部门及下级部门数据
+	DataScopeCustom          DataScope = "custom"            // 自定义 SQL 条件
+)
+
+const (
+	// DataScopeContextKey 上下文中保存当前用户数据权限的键
+	DataScopeContextKey = "data_scope"
+	// dataScopeSkipKey 上下文中跳过数据权限的键
+	dataScopeSkipKey = "data_scope_skip"
+)
+
+// DataScopeUser 当前用户的数据权限，由 DataScopeResolver 根据登录用户和角色计算
+type DataScopeUser struct {
+	UserID     string
+	DeptID     string
+	DeptIDs    []string  // 本部门及下级部门，DataScopeDeptAndChildren 使用
+	Scope      DataScope // 多个角色时由解析器合并为范围最大的一个
+	CustomSQL  string    // DataScopeCustom 使用，如 "dept_id IN (SELECT dept_id FROM role_dept WHERE role_id IN ?)"
+	CustomArgs []any
+	SuperAdmin bool // 超管不限制
+}
+
+// DataScopeModel 模型实现该接口后启用数据权限，查询、更新、删除时按当前用户的数据权限过滤
+// creator 为创建人列名，dept 为部门列名，dept 为空时部门范围只能看到本人的数据
+//
+//	func (i *Order) DataScopeColumns() (creator, dept string) {
+//		return "creator_id", "dept_id"
+//	}
+type DataScopeModel interface {
+	DataScopeColumns() (creator, dept string)
+}
+
+// DataScopeResolver 根据请求上下文返回当前用户的数据权限，返回 nil 时不限制，如后台任务
+type DataScopeResolver func(ctx context.Context) (*DataScopeUser, error)
+
+var (
+	dataScopeResolverLock sync.RWMutex
+	dataScopeResolver     DataScopeResolver
+
+	dataScopeColumnsCache sync.Map // reflect.Type -> *dataScopeColumns
+)
+
+type dataScopeColumns struct {
+	creator string
+	dept    string
+}
+
+// SetDataScopeResolver 设置数据权限解析器，通常由认证模块在 Register 中调用
+func SetDataScopeResolver(resolver DataScopeResolver) {
+	dataScopeResolverLock.Lock()
+	dataScopeResolver = resolver
+	dataScopeResolverLock.Unlock()
+}
+
+// WithDataScope 在上下文中指定数据权限，优先于解析器
+func WithDataScope(ctx context.Context, user *DataScopeUser) context.Context {
+	return context.WithValue(ctx, DataScopeContextKey, user)
+}
+
+// SkipDataScope 返回不受数据权限限制的上下文，用于统计、导出等需要全部数据的场景
+func SkipDataScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dataScopeSkipKey, true)
+}
+
+// DataScopePlugin 对实现 DataScopeModel 的模型追加数据权限条件，创建时自动填充部门列
+// 原生 SQL(Raw/Exec) 不受影响
+type DataScopePlugin struct{}
+
+func (i *DataScopePlugin) Name() string {
+	return "owl:data_scope"
+}
+
+func (i *DataScopePlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("owl:data_scope", fillDataScopeDept); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("owl:data_scope", applyDataScope); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("owl:data_scope", applyDataScope); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("owl:data_scope", applyWriteDataScope); err != nil {
+		return err
+	}
+	return cb.Delete().Before("gorm:delete").Register("owl:data_scope", applyWriteDataScope)
+}
+
+// applyWriteDataScope 更新、删除没有任何条件时不追加数据权限条件，由 gorm 返回 ErrMissingWhereClause
+func applyWriteDataScope(db *gorm.DB) {
+	if HasWriteCondition(db) {
+		applyDataScope(db)
+	}
+}
+
+func applyDataScope(db *gorm.DB) {
+	columns := modelDataScopeColumns(db.Statement.Schema)
+	if columns == nil || db.Error != nil {
+		return
+	}
+	user, err := currentDataScopeUser(db.Statement.Context)
+	if err != nil {
+		_ = db.AddError(err)
+		return
+	}
+	if user == nil {
+		return
+	}
+
+	expr, err := columns.expression(user)
+	if err != nil {
+		_ = db.AddError(err)
+		return
+	}
+	if expr == nil {
+		return
+	}
+
+	AndWhere(db.Statement, expr)
+}
+
+// fillDataScopeDept 创建数据时部门列为空则填充当前用户的部门
+func fillDataScopeDept(db *gorm.DB) {
+	columns := modelDataScopeColumns(db.Statement.Schema)
+	if columns == nil || columns.dept == "" || db.Error != nil {
+		return
+	}
+	user, err := currentDataScopeUser(db.Statement.Context)
+	if err != nil || user == nil || user.DeptID == "" {
+		return
+	}
+	field := db.Statement.Schema.LookUpField(columns.dept)
+	if field == nil {
+		return
+	}
+
+	ctx := db.Statement.Context
+	setIfZero := func(v reflect.Value) {
+		if _, zero := field.ValueOf(ctx, v); zero {
+			_ = field.Set(ctx, v, user.DeptID)
+		}
+	}
+	switch rv := reflect.Indirect(db.Statement.ReflectValue); rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for n := 0; n < rv.Len(); n++ {
+			setIfZero(reflect.Indirect(rv.Index(n)))
+		}
+	case reflect.Struct:
+		setIfZero(rv)
+	}
+}
+
+func (i *dataScopeColumns) expression(user *DataScopeUser) (clause.Expression, error) {
+	creator := clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: i.creator}, Value: user.UserID}
+	dept := clause.Column{Table: clause.CurrentTable, Name: i.dept}
+
+	switch user.Scope {
+	case DataScopeAll:
+		return nil, nil
+	case DataScopeDept:
+		if i.dept == "" {
+			return creator, nil
+		}
+		return clause.Eq{Column: dept, Value: user.DeptID}, nil
+	case DataScopeDeptAndChildren:
+		if i.dept == "" {
+			return creator, nil
+		}
+		ids := make([]any, 0, len(user.DeptIDs)+1)
+		ids = append(ids, user.DeptID)
+		for _, id := range user.DeptIDs {
+			if id != user.DeptID {
+				ids = append(ids, id)
+			}
+		}
+		return clause.IN{Column: dept, Values: ids}, nil
+	case DataScopeCustom:
+		if user.CustomSQL == "" {
+			return nil, errors.New("自定义数据权限缺少 SQL 条件")
+		}
+		return clause.Expr{SQL: "(" + user.CustomSQL + ")", Vars: user.CustomArgs}, nil
+	default:
+		// 未配置或未知的范围按最小权限处理
+		return creator, nil
+	}
+}
+
+// currentDataScopeUser 获取当前用户的数据权限，超管、超管接口、跳过数据权限时返回 nil
+func currentDataScopeUser(ctx context.Context) (*DataScopeUser, error) {
+	if ctx == nil {
+		return nil, nil
+	}
+	if skip, _ := ctx.Value(dataScopeSkipKey).(bool); skip {
+		return nil, nil
+	}
+	if level, _ := ctx.Value(router.AccessLevelContextKey).(router.AccessLevel); level == router.AccessSuperAdmin {
+		return nil, nil
+	}
+
+	user, ok := ctx.Value(DataScopeContextKey).(*DataScopeUser)
+	if !ok {
+		dataScopeResolverLock.RLock()
+		resolver := dataScopeResolver
+		dataScopeResolverLock.RUnlock()
+		if resolver == nil {
+			return nil, nil
+		}
+
+		var err error
+		if user, err = resolver(ctx); err != nil {
+			return nil, err
+		}
+		// 同一请求只解析一次
+		if c, ok := ctx.(*gin.Context); ok {
+			c.Set(DataScopeContextKey, user)
+		}
+	}
+	if user == nil || user.SuperAdmin {
+		return nil, nil
+	}
+	return user, nil
+}
+
+func modelDataScopeColumns(s *schema.Schema) *dataScopeColumns {
+	if s == nil {
+		return nil
+	}
+	if columns, ok := dataScopeColumnsCache.Load(s.ModelType); ok {
+		return columns.(*dataScopeColumns)
+	}
+
+	var columns *dataScopeColumns
+	if model, ok := reflect.New(s.ModelType).Interface().(DataScopeModel); ok {
+		creator, dept := model.DataScopeColumns()
+		columns = &dataScopeColumns{creator: creator, dept: dept}
+		if columns.creator == "" {
+			columns.creator = "creator_id"
+		}
+	}
+	dataScopeColumnsCache.Store(s.ModelType, columns)
+	return columns
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"bit-labs.cn/owl/provider/router"
+	"gorm.io/gorm"
+)
+
+type scopedOrder struct {
+	ID        uint `gorm:"primarykey"`
+	CreatorID string
+	DeptID    string
+	Title     string
+}
+
+func (i *scopedOrder) DataScopeColumns() (creator, dept string) {
+	return "creator_id", "dept_id"
+}
+
+func TestDataScopePlugin(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.Use(&DataScopePlugin{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&scopedOrder{}); err != nil {
+		t.Fatal(err)
+	}
+
+	bob := &DataScopeUser{UserID: "bob", DeptID: "d2", Scope: DataScopeOwn}
+	if err := db.WithContext(WithDataScope(context.Background(), bob)).Create(&scopedOrder{CreatorID: "bob", Title: "bob"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&[]scopedOrder{
+		{CreatorID: "alice", DeptID: "d1", Title: "alice"},
+		{CreatorID: "carol", DeptID: "d1", Title: "carol"},
+		{CreatorID: "dave", DeptID: "d3", Title: "dave"},
+	})
+
+	count := func(ctx context.Context) int64 {
+		t.Helper()
+		var c int64
+		if err := db.WithContext(ctx).Model(&scopedOrder{}).Where("title = ?", "dave").Or("title <> ?", "dave").Count(&c).Error; err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	cases := []struct {
+		name string
+		ctx  context.Context
+		want int64
+	}{
+		{"no user", context.Background(), 4},
+		{"all", WithDataScope(context.Background(), &DataScopeUser{UserID: "alice", Scope: DataScopeAll}), 4},
+		{"own", WithDataScope(context.Background(), bob), 1},
+		{"dept", WithDataScope(context.Background(), &DataScopeUser{UserID: "alice", DeptID: "d1", Scope: DataScopeDept}), 2},
+		{"dept and children", WithDataScope(context.Background(), &DataScopeUser{UserID: "alice", DeptID: "d1", DeptIDs: []string{"d2"}, Scope: DataScopeDeptAndChildren}), 3},
+		{"custom", WithDataScope(context.Background(), &DataScopeUser{Scope: DataScopeCustom, CustomSQL: "dept_id IN ?", CustomArgs: []any{[]string{"d3"}}}), 1},
+		{"unknown", WithDataScope(context.Background(), &DataScopeUser{UserID: "alice"}), 1},
+		{"super admin", WithDataScope(context.Background(), &DataScopeUser{UserID: "root", SuperAdmin: true}), 4},
+		{"skip", SkipDataScope(WithDataScope(context.Background(), bob)), 4},
+		{"super admin route", context.WithValue(WithDataScope(context.Background(), bob), router.AccessLevelContextKey, router.AccessSuperAdmin), 4},
+	}
+	for _, c := range cases {
+		if got := count(c.ctx); got != c.want {
+			t.Errorf("%s: count = %d, want %d", c.name, got, c.want)
+		}
+	}
+
+	// 部门列由当前用户填充
+	var order scopedOrder
+	db.Where("creator_id = ?", "bob").First(&order)
+	if order.DeptID != "d2" {
+		t.Errorf("dept = %q", order.DeptID)
+	}
+
+	// 更新、删除同样受限
+	ctx := WithDataScope(context.Background(), bob)
+	if rows := db.WithContext(ctx).Where("1 = 1").Delete(&scopedOrder{}).RowsAffected; rows != 1 {
+		t.Errorf("delete rows = %d", rows)
+	}
+	if c := count(context.Background()); c != 3 {
+		t.Errorf("remaining = %d", c)
+	}
+
+	// 没有条件的更新、删除仍然由 gorm 拦截，不会修改权限范围内的全部数据
+	if err := db.WithContext(ctx).Model(&scopedOrder{}).Update("title", "x").Error; !errors.Is(err, gorm.ErrMissingWhereClause) {
+		t.Errorf("update without where = %v", err)
+	}
+	if err := db.WithContext(ctx).Delete(&scopedOrder{}).Error; !errors.Is(err, gorm.ErrMissingWhereClause) {
+		t.Errorf("delete without where = %v", err)
+	}
+	// 按模型主键更新时追加数据权限条件
+	var dave scopedOrder
+	db.Where("creator_id = ?", "dave").First(&dave)
+	if rows := db.WithContext(ctx).Model(&dave).Update("title", "x").RowsAffected; rows != 0 {
+		t.Errorf("update out of scope rows = %d", rows)
+	}
+
+	SetDataScopeResolver(func(ctx context.Context) (*DataScopeUser, error) {
+		return &DataScopeUser{UserID: "alice", DeptID: "d1", Scope: DataScopeDept}, nil
+	})
+	defer SetDataScopeResolver(nil)
+	if c := count(context.Background()); c != 2 {
+		t.Errorf("resolver count = %d", c)
+	}
+}
@@ -2,16 +2,20 @@ package db
 
 import (
 	_ "embed"
+	"errors"
 	"path/filepath"
 
 	"bit-labs.cn/owl"
 	"bit-labs.cn/owl/contract/foundation"
 	"bit-labs.cn/owl/contract/log"
 	"bit-labs.cn/owl/provider/conf"
+	"bit-labs.cn/owl/provider/router"
+	"go.uber.org/dig"
 	"gorm.io/gorm"
 )
 
 var _ foundation.ServiceProvider = (*DBServiceProvider)(nil)
+var _ foundation.CommandProvider = (*DBServiceProvider)(nil)
 
 type DBServiceProvider struct {
 	app foundation.Application
@@ -22,21 +26,70 @@ func (i *DBServiceProvider) Description() string {
 }
 
 func (i *DBServiceProvider) Register() {
-
-	i.app.Register(func(c *conf.Configure, l log.Logger) *gorm.DB {
-
+	// 配置在创建连接时读取，首次运行时 database.yaml 在 Register 之后才会生成
+	i.app.Register(func(c *conf.Configure, l log.Logger) *Manager {
 		var opt Options
 		err := c.GetConfig("database", &opt)
 		owl.PanicIf(err)
 
-		l.Debug("连接数据库", "配置信息", opt.Host, opt.Port)
+		SetPageSize(opt.DefaultPageSize, opt.MaxPageSize)
+		stickyEnabled.Store(opt.HasReplicas())
+
+		return NewManager(&opt, l, func(name string, o *Options) {
+			l.Debug("连接数据库", "连接", name, "配置信息", o.Host, o.Port)
 
-		if opt.Driver == Sqlite {
-			opt.Host = filepath.Join(i.app.GetConfigPath(), opt.Host)
-			l.Debug("use sqlite, path:", opt.Host)
-		}
-		return InitDB(&opt, l)
+			if o.Driver == Sqlite {
+				o.Host = filepath.Join(i.app.GetConfigPath(), o.Host)
+				l.Debug("use sqlite, path:", o.Host)
+			}
+		})
 	})
+
+	// 默认连接
+	i.app.Register(func(m *Manager) *gorm.DB {
+		return m.Default()
+	})
+
+	// 命名连接，通过 `name:"reporting"` 标签注入
+	for _, name := range i.connectionNames() {
+		err := i.app.Provide(func(m *Manager) *gorm.DB {
+			return m.Connection(name)
+		}, dig.Name(name))
+		owl.PanicIf(err)
+	}
+
+	router.RegisterErrorMapper(mapError)
+
+	// 记录每个请求的查询次数和耗时，输出到访问日志
+	router.RegisterMiddleware(QueryStatsMiddleware())
+
+	// 读写分离时，同一请求内写操作之后的读操作读取主库，是否开启在创建 Manager 时根据配置确定
+	router.RegisterMiddleware(stickyMiddleware())
+}
+
+// connectionNames 命名连接的名称，配置文件尚未生成时没有命名连接
+func (i *DBServiceProvider) connectionNames() []string {
+	var opt Options
+	err := i.app.Invoke(func(c *conf.Configure) error {
+		return c.GetConfig("database", &opt)
+	})
+	if err != nil {
+		return nil
+	}
+	return opt.ConnectionNames()[1:]
+}
+
+// mapError 将数据库错误转换为对应的响应，如记录不存在返回 404
+func mapError(err error) *router.AppError {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return router.ErrNotFound.WithCause(err)
+	case errors.Is(err, ErrVersionConflict):
+		return router.ErrConflict.WithCause(err)
+	case errors.Is(err, ErrInvalidFilter), errors.Is(err, ErrInvalidCursor):
+		return router.ErrBadRequest.WithCause(err)
+	}
+	return nil
 }
 
 func (i *DBServiceProvider) Boot() {
@@ -51,3 +104,9 @@ func (i *DBServiceProvider) Conf() map[string]string {
 		"database.yaml": databaseYaml,
 	}
 }
+
+func (i *DBServiceProvider) ConfSchema() map[string]any {
+	return map[string]any{
+		"database.yaml": &Options{},
+	}
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// DefaultFactorySeed 工厂默认的随机种子，保证每次生成的数据一致
+const DefaultFactorySeed int64 = 1
+
+// Factory 模型工厂，根据定义生成模型，支持状态和临时修改
+//
+//	userFactory := db.NewFactory(func(f *db.Faker) *User {
+//		return &User{Name: f.Name(), Mobile: f.Mobile(), Status: 1}
+//	}).DefineState("disabled", func(f *db.Faker, u *User) {
+//		u.Status = 0
+//	})
+//	users, err := userFactory.State("disabled").Create(tx, 10)
+type Factory[T any] struct {
+	definition func(f *Faker) *T
+	states     map[string]func(f *Faker, m *T)
+	modifiers  []func(f *Faker, m *T)
+	faker      *Faker
+}
+
+func NewFactory[T any](definition func(f *Faker) *T) *Factory[T] {
+	return &Factory[T]{
+		definition: definition,
+		states:     make(map[string]func(f *Faker, m *T)),
+		faker:      NewFaker(DefaultFactorySeed),
+	}
+}
+
+// DefineState 定义状态，状态在基础定义之后修改模型
+func (i *Factory[T]) DefineState(name string, fn func(f *Faker, m *T)) *Factory[T] {
+	i.states[name] = fn
+	return i
+}
+
+// State 返回应用了指定状态的新工厂，未定义的状态会 panic
+func (i *Factory[T]) State(names ...string) *Factory[T] {
+	clone := i.clone()
+	for _, name := range names {
+		fn, ok := i.states[name]
+		if !ok {
+			panic(fmt.Sprintf("工厂状态 %s 未定义", name))
+		}
+		clone.modifiers = append(clone.modifiers, fn)
+	}
+	return clone
+}
+
+// With 返回应用了临时修改的新工厂
+func (i *Factory[T]) With(fn func(f *Faker, m *T)) *Factory[T] {
+	clone := i.clone()
+	clone.modifiers = append(clone.modifiers, fn)
+	return clone
+}
+
+// Seed 返回使用指定随机种子的新工厂
+func (i *Factory[T]) Seed(seed int64) *Factory[T] {
+	clone := i.clone()
+	clone.faker = NewFaker(seed)
+	return clone
+}
+
+// Faker 工厂使用的随机数据生成器
+func (i *Factory[T]) Faker() *Faker {
+	return i.faker
+}
+
+// MakeOne 生成一个模型，不写入数据库
+func (i *Factory[T]) MakeOne() *T {
+	m := i.definition(i.faker)
+	for _, fn := range i.modifiers {
+		fn(i.faker, m)
+	}
+	return m
+}
+
+// Make 生成 n 个模型，不写入数据库
+func (i *Factory[T]) Make(n int) []*T {
+	list := make([]*T, 0, n)
+	for range n {
+		list = append(list, i.MakeOne())
+	}
+	return list
+}
+
+// CreateOne 生成一个模型并写入数据库
+func (i *Factory[T]) CreateOne(db *gorm.DB) (*T, error) {
+	m := i.MakeOne()
+	return m, db.Create(m).Error
+}
+
+// Create 生成 n 个模型并批量写入数据库
+func (i *Factory[T]) Create(db *gorm.DB, n int) ([]*T, error) {
+	list := i.Make(n)
+	if len(list) == 0 {
+		return list, nil
+	}
+	return list, db.CreateInBatches(list, 100).Error
+}
+
+// clone 状态和修改在新工厂中追加，随机数生成器共享，保证同一工厂连续生成的数据不重复
+func (i *Factory[T]) clone() *Factory[T] {
+	return &Factory[T]{
+		definition: i.definition,
+		states:     i.states,
+		modifiers:  append([]func(f *Faker, m *T){}, i.modifiers...),
+		faker:      i.faker,
+	}
+}
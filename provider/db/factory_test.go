@@ -0,0 +1,54 @@
+package db
+
+import "testing"
+
+type factoryUser struct {
+	ID     uint `gorm:"primarykey"`
+	Name   string
+	Mobile string
+	Status int
+}
+
+func newUserFactory() *Factory[factoryUser] {
+	return NewFactory(func(f *Faker) *factoryUser {
+		return &factoryUser{Name: f.Name(), Mobile: f.Mobile(), Status: 1}
+	}).DefineState("disabled", func(f *Faker, u *factoryUser) {
+		u.Status = 0
+	})
+}
+
+func TestFactory_Reproducible(t *testing.T) {
+	a := newUserFactory().Make(3)
+	b := newUserFactory().Make(3)
+	for idx := range a {
+		if *a[idx] != *b[idx] {
+			t.Fatalf("factory with same seed generated different data: %+v != %+v", a[idx], b[idx])
+		}
+	}
+	if len(a[0].Mobile) != 11 {
+		t.Errorf("mobile = %s", a[0].Mobile)
+	}
+	if c := newUserFactory().Seed(2).MakeOne(); *c == *a[0] {
+		t.Errorf("different seed should generate different data")
+	}
+}
+
+func TestFactory_StateAndCreate(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&factoryUser{}); err != nil {
+		t.Fatal(err)
+	}
+
+	users, err := newUserFactory().State("disabled").With(func(f *Faker, u *factoryUser) {
+		u.Name = "张三"
+	}).Create(db, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count int64
+	db.Model(&factoryUser{}).Where("status = ? AND name = ?", 0, "张三").Count(&count)
+	if count != 5 || users[0].ID == 0 {
+		t.Fatalf("created %d users, first id %d", count, users[0].ID)
+	}
+}
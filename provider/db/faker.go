@@ -0,0 +1,144 @@
+package db
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Faker 随机数据生成器，相同的种子生成相同的数据，便于测试和演示环境复现
+type Faker struct {
+	r *rand.Rand
+}
+
+func NewFaker(seed int64) *Faker {
+	return &Faker{r: rand.New(rand.NewSource(seed))}
+}
+
+var (
+	fakerSurnames   = []string{"王", "李", "张", "刘", "陈", "杨", "赵", "黄", "周", "吴", "徐", "孙", "胡", "朱", "高", "林", "何", "郭", "马", "罗"}
+	fakerGivenNames = []string{"伟", "芳", "娜", "敏", "静", "丽", "强", "磊", "军", "洋", "勇", "艳", "杰", "娟", "涛", "明", "超", "秀英", "霞", "平", "刚", "桂英", "浩然", "子涵", "欣怡", "梓轩"}
+	fakerCities     = []string{"北京", "上海", "广州", "深圳", "杭州", "成都", "南京", "武汉", "西安", "重庆", "苏州", "天津"}
+	fakerWords      = []string{"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit", "sed", "do", "eiusmod", "tempor", "incididunt", "ut", "labore", "et", "dolore", "magna", "aliqua"}
+	fakerDomains    = []string{"example.com", "example.org", "example.net", "test.com"}
+	fakerLetters    = "abcdefghijklmnopqrstuvwxyz"
+)
+
+// Int 返回 [min, max] 之间的整数
+func (i *Faker) Int(min, max int) int {
+	if max <= min {
+		return min
+	}
+	return min + i.r.Intn(max-min+1)
+}
+
+// Float 返回 [min, max) 之间的浮点数
+func (i *Faker) Float(min, max float64) float64 {
+	return min + i.r.Float64()*(max-min)
+}
+
+// Bool 返回随机布尔值
+func (i *Faker) Bool() bool {
+	return i.r.Intn(2) == 1
+}
+
+// Element 从给定的字符串中随机选择一个
+func (i *Faker) Element(items ...string) string {
+	if len(items) == 0 {
+		return ""
+	}
+	return items[i.r.Intn(len(items))]
+}
+
+// Letters 返回 n 个随机小写字母
+func (i *Faker) Letters(n int) string {
+	b := make([]byte, n)
+	for idx := range b {
+		b[idx] = fakerLetters[i.r.Intn(len(fakerLetters))]
+	}
+	return string(b)
+}
+
+// Numerify 将 # 替换为随机数字，如 "NO.####" => "NO.3812"
+func (i *Faker) Numerify(format string) string {
+	var b strings.Builder
+	for _, r := range format {
+		if r == '#' {
+			b.WriteByte(byte('0' + i.r.Intn(10)))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Name 随机中文姓名
+func (i *Faker) Name() string {
+	return i.Element(fakerSurnames...) + i.Element(fakerGivenNames...)
+}
+
+// Username 随机用户名
+func (i *Faker) Username() string {
+	return i.Letters(i.Int(4, 8)) + i.Numerify("###")
+}
+
+// Email 随机邮箱
+func (i *Faker) Email() string {
+	return i.Username() + "@" + i.Element(fakerDomains...)
+}
+
+// Mobile 随机手机号
+func (i *Faker) Mobile() string {
+	return fmt.Sprintf("1%d%s", i.Int(3, 9), i.Numerify("#########"))
+}
+
+// City 随机城市
+func (i *Faker) City() string {
+	return i.Element(fakerCities...)
+}
+
+// Word 随机单词
+func (i *Faker) Word() string {
+	return i.Element(fakerWords...)
+}
+
+// Sentence 由 n 个单词组成的句子
+func (i *Faker) Sentence(n int) string {
+	words := make([]string, n)
+	for idx := range words {
+		words[idx] = i.Word()
+	}
+	sentence := strings.Join(words, " ")
+	if sentence == "" {
+		return ""
+	}
+	return strings.ToUpper(sentence[:1]) + sentence[1:] + "."
+}
+
+// Paragraph 由 n 个句子组成的段落
+func (i *Faker) Paragraph(n int) string {
+	sentences := make([]string, n)
+	for idx := range sentences {
+		sentences[idx] = i.Sentence(i.Int(6, 12))
+	}
+	return strings.Join(sentences, " ")
+}
+
+// Time 返回 [from, to) 之间的时间
+func (i *Faker) Time(from, to time.Time) time.Time {
+	d := to.Sub(from)
+	if d <= 0 {
+		return from
+	}
+	return from.Add(time.Duration(i.r.Int63n(int64(d))))
+}
+
+// UUID 随机 UUID(v4 格式)
+func (i *Faker) UUID() string {
+	b := make([]byte, 16)
+	i.r.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,504 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"bit-labs.cn/owl/utils"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/spf13/cast"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// ErrInvalidFilter 客户端传入的查询条件或排序参数无效
+var ErrInvalidFilter = errors.New("查询条件无效")
+
+// 过滤操作符
+const (
+	FilterEq      = "eq"      // 等于，默认
+	FilterNe      = "ne"      // 不等于
+	FilterGt      = "gt"      // 大于
+	FilterGte     = "gte"     // 大于等于
+	FilterLt      = "lt"      // 小于
+	FilterLte     = "lte"     // 小于等于
+	FilterLike    = "like"    // 包含
+	FilterPrefix  = "prefix"  // 以该值开头
+	FilterSuffix  = "suffix"  // 以该值结尾
+	FilterIn      = "in"      // 在列表中，值为切片、JSON 数组或逗号分隔的字符串
+	FilterNotIn   = "notin"   // 不在列表中
+	FilterBetween = "between" // 区间，值为两个元素的切片或 "a,b"，一侧为空时只限制另一侧
+	FilterNull    = "null"    // 值为 true 时 IS NULL，false 时 IS NOT NULL
+)
+
+// filterSpec filter 标签的解析结果
+type filterSpec struct {
+	columns []string
+	op      string
+	group   string
+}
+
+// ApplyFilter 根据结构体的 filter、sort 标签生成查询条件，db 需要先调用 Model 设置模型
+//
+// 只有带 filter 标签的字段参与过滤，零值字段忽略，指针字段非 nil 时即使是零值也参与过滤。
+// 列名必须是模型中存在的字段，客户端只能传值，不能决定查询哪一列。
+// 标签在每种结构体第一次使用时检查，错误时返回 ErrInvalidFilter；like、prefix、suffix 的值中的 % 和 _ 按普通字符匹配
+//
+//	type UserFilter struct {
+//		router.PageReq
+//		Name      string   `form:"name" filter:"name,like"`
+//		Status    *int     `form:"status" filter:"status"`
+//		CreatedAt []string `form:"createdAt[]" filter:"created_at,between"`
+//		Keyword   string   `form:"keyword" filter:"name|mobile,like"`  // name LIKE ? OR mobile LIKE ?
+//		DeptID    string   `form:"deptID" filter:"dept_id,eq,or=dept"` // 相同 or 分组的字段之间为 OR
+//		Leader    string   `form:"leader" filter:"leader_id,eq,or=dept"`
+//		RoleName  string   `form:"roleName" filter:"Roles.name"`         // 关联过滤，Roles 为模型中的关联字段
+//		Sort      string   `form:"sort" sort:"created_at,name"`          // sort=-createdAt,name，只能按列出的列排序
+//	}
+func ApplyFilter(db *gorm.DB, filter any) error {
+	v := reflect.Indirect(reflect.ValueOf(filter))
+	if !v.IsValid() || v.Kind() != reflect.Struct {
+		return nil
+	}
+	if db.Statement.Model == nil {
+		return errors.New("ApplyFilter 需要先调用 Model 设置模型")
+	}
+	if err := db.Statement.Parse(db.Statement.Model); err != nil {
+		return err
+	}
+
+	if err := checkFilterType(v.Type(), db.Statement.Schema); err != nil {
+		return err
+	}
+
+	b := &filterBuilder{db: db, schema: db.Statement.Schema, groups: map[string][]clause.Expression{}}
+	if err := b.walk(v); err != nil {
+		return err
+	}
+	for _, group := range b.groupOrder {
+		db.Where(clause.Or(b.groups[group]...))
+	}
+	return nil
+}
+
+// Filter ApplyFilter 的 Scopes 写法，条件无效时错误记录在 db.Error 中
+//
+//	db.Model(&User{}).Scopes(db.Filter(req)).Find(&list)
+func Filter(filter any) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if err := ApplyFilter(db, filter); err != nil {
+			_ = db.AddError(err)
+		}
+		return db
+	}
+}
+
+type filterBuilder struct {
+	db         *gorm.DB
+	schema     *schema.Schema
+	groups     map[string][]clause.Expression
+	groupOrder []string
+}
+
+func (i *filterBuilder) walk(v reflect.Value) error {
+	t := v.Type()
+	for n := 0; n < t.NumField(); n++ {
+		field, value := t.Field(n), v.Field(n)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag, hasFilter := field.Tag.Lookup("filter")
+		sortColumns, hasSort := field.Tag.Lookup("sort")
+
+		// 嵌入的结构体，如 router.PageReq
+		if field.Anonymous && !hasFilter && !hasSort {
+			if value.Kind() == reflect.Ptr {
+				if value.IsNil() {
+					continue
+				}
+				value = value.Elem()
+			}
+			if value.Kind() == reflect.Struct {
+				if err := i.walk(value); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				continue
+			}
+			value = value.Elem()
+		} else if value.IsZero() {
+			continue
+		}
+		if (value.Kind() == reflect.Slice || value.Kind() == reflect.Array) && value.Len() == 0 {
+			continue
+		}
+
+		switch {
+		case hasSort:
+			if err := i.sort(sortColumns, cast.ToString(value.Interface())); err != nil {
+				return err
+			}
+		case hasFilter && tag != "-":
+			spec, err := parseFilterTag(tag)
+			if err != nil {
+				return fmt.Errorf("%w: 字段 %s 的 filter 标签错误: %w", ErrInvalidFilter, field.Name, err)
+			}
+			if err = i.add(field.Name, spec, value.Interface()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (i *filterBuilder) add(fieldName string, spec filterSpec, value any) error {
+	exprs := make([]clause.Expression, 0, len(spec.columns))
+	for _, column := range spec.columns {
+		expr, err := i.condition(column, spec.op, value)
+		if err != nil {
+			return fmt.Errorf("%w: %s %w", ErrInvalidFilter, utils.FirstLower(fieldName), err)
+		}
+		exprs = append(exprs, expr)
+	}
+
+	var expr clause.Expression = exprs[0]
+	if len(exprs) > 1 {
+		expr = clause.Or(exprs...)
+	}
+	if spec.group == "" {
+		i.db.Where(expr)
+		return nil
+	}
+	if _, ok := i.groups[spec.group]; !ok {
+		i.groupOrder = append(i.groupOrder, spec.group)
+	}
+	i.groups[spec.group] = append(i.groups[spec.group], expr)
+	return nil
+}
+
+// condition 生成单列的条件，Relation.column 形式的列名生成关联子查询
+func (i *filterBuilder) condition(name, op string, value any) (clause.Expression, error) {
+	relation, column, ok := strings.Cut(name, ".")
+	if !ok {
+		field := i.schema.LookUpField(name)
+		if field == nil || field.DBName == "" {
+			return nil, fmt.Errorf("模型 %s 没有字段 %s", i.schema.Name, name)
+		}
+		return i.buildCondition(clause.Column{Table: clause.CurrentTable, Name: field.DBName}, op, value)
+	}
+	return i.relationCondition(relation, column, op, value)
+}
+
+// relationCondition 关联过滤，生成 IN 子查询，支持 belongs to、has one、has many、many to many
+func (i *filterBuilder) relationCondition(name, column, op string, value any) (clause.Expression, error) {
+	rel, field, err := lookUpRelationField(i.schema, name, column)
+	if err != nil {
+		return nil, err
+	}
+
+	cond, err := i.buildCondition(clause.Column{Table: clause.CurrentTable, Name: field.DBName}, op, value)
+	if err != nil {
+		return nil, err
+	}
+	newDB := func() *gorm.DB {
+		return i.db.Session(&gorm.Session{NewDB: true})
+	}
+	sub := newDB().Model(reflect.New(rel.FieldSchema.ModelType).Interface()).Where(cond)
+
+	var ownerColumn string
+	switch rel.Type {
+	case schema.BelongsTo:
+		for _, ref := range rel.References {
+			if ref.PrimaryKey != nil && !ref.OwnPrimaryKey {
+				ownerColumn = ref.ForeignKey.DBName
+				sub = sub.Select(ref.PrimaryKey.DBName)
+			}
+		}
+	case schema.HasOne, schema.HasMany:
+		for _, ref := range rel.References {
+			if ref.OwnPrimaryKey {
+				ownerColumn = ref.PrimaryKey.DBName
+				sub = sub.Select(ref.ForeignKey.DBName)
+			} else if ref.PrimaryValue != "" {
+				// 多态关联的类型字段
+				sub = sub.Where(clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: ref.ForeignKey.DBName}, Value: ref.PrimaryValue})
+			}
+		}
+	case schema.Many2Many:
+		var joinOwner, joinRelated string
+		for _, ref := range rel.References {
+			if ref.OwnPrimaryKey {
+				ownerColumn, joinOwner = ref.PrimaryKey.DBName, ref.ForeignKey.DBName
+			} else if ref.PrimaryKey != nil {
+				joinRelated = ref.ForeignKey.DBName
+				sub = sub.Select(ref.PrimaryKey.DBName)
+			}
+		}
+		sub = newDB().Table(rel.JoinTable.Table).Select(joinOwner).
+			Where(clause.Expr{SQL: "? IN (?)", Vars: []any{clause.Column{Name: joinRelated}, sub}})
+	}
+	if ownerColumn == "" {
+		return nil, fmt.Errorf("关联 %s.%s 不支持过滤", i.schema.Name, name)
+	}
+	return clause.Expr{SQL: "? IN (?)", Vars: []any{clause.Column{Table: clause.CurrentTable, Name: ownerColumn}, sub}}, nil
+}
+
+// sort 解析 -createdAt,name 形式的排序参数，- 为倒序，列名可以是驼峰或下划线
+func (i *filterBuilder) sort(allowed, value string) error {
+	whitelist := make(map[string]bool)
+	for _, column := range strings.Split(allowed, ",") {
+		whitelist[strings.TrimSpace(column)] = true
+	}
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		desc := strings.HasPrefix(item, "-")
+		name := utils.Cc2Udl(strings.TrimLeft(item, "+-"))
+
+		field := i.schema.LookUpField(name)
+		if !whitelist[name] || field == nil || field.DBName == "" {
+			return fmt.Errorf("%w: 不支持按 %s 排序", ErrInvalidFilter, strings.TrimLeft(item, "+-"))
+		}
+		i.db.Order(clause.OrderByColumn{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Desc: desc})
+	}
+	return nil
+}
+
+// parseFilterTag 解析 filter:"name|mobile,like,or=keyword"
+func parseFilterTag(tag string) (filterSpec, error) {
+	parts := strings.Split(tag, ",")
+	spec := filterSpec{op: FilterEq}
+	for _, column := range strings.Split(parts[0], "|") {
+		if column = strings.TrimSpace(column); column != "" {
+			spec.columns = append(spec.columns, column)
+		}
+	}
+	if len(spec.columns) == 0 {
+		return spec, errors.New("缺少列名")
+	}
+
+	for _, part := range parts[1:] {
+		part = strings.TrimSpace(part)
+		if group, ok := strings.CutPrefix(part, "or="); ok {
+			spec.group = group
+			continue
+		}
+		switch part {
+		case FilterEq, FilterNe, FilterGt, FilterGte, FilterLt, FilterLte, FilterLike, FilterPrefix,
+			FilterSuffix, FilterIn, FilterNotIn, FilterBetween, FilterNull:
+			spec.op = part
+		default:
+			return spec, fmt.Errorf("不支持的操作符 %s", part)
+		}
+	}
+	return spec, nil
+}
+
+func (i *filterBuilder) buildCondition(column clause.Column, op string, value any) (clause.Expression, error) {
+	switch op {
+	case FilterNe:
+		return clause.Neq{Column: column, Value: value}, nil
+	case FilterGt:
+		return clause.Gt{Column: column, Value: value}, nil
+	case FilterGte:
+		return clause.Gte{Column: column, Value: value}, nil
+	case FilterLt:
+		return clause.Lt{Column: column, Value: value}, nil
+	case FilterLte:
+		return clause.Lte{Column: column, Value: value}, nil
+	case FilterLike:
+		return i.like(column, "%"+i.escapeLike(value)+"%"), nil
+	case FilterPrefix:
+		return i.like(column, i.escapeLike(value)+"%"), nil
+	case FilterSuffix:
+		return i.like(column, "%"+i.escapeLike(value)), nil
+	case FilterIn:
+		return clause.IN{Column: column, Values: filterValues(value)}, nil
+	case FilterNotIn:
+		return clause.Not(clause.IN{Column: column, Values: filterValues(value)}), nil
+	case FilterBetween:
+		values := filterValues(value)
+		if len(values) != 2 {
+			return nil, errors.New("需要两个值")
+		}
+		var exprs []clause.Expression
+		if cast.ToString(values[0]) != "" {
+			exprs = append(exprs, clause.Gte{Column: column, Value: values[0]})
+		}
+		if cast.ToString(values[1]) != "" {
+			exprs = append(exprs, clause.Lte{Column: column, Value: values[1]})
+		}
+		if len(exprs) == 0 {
+			return nil, errors.New("需要两个值")
+		}
+		return clause.And(exprs...), nil
+	case FilterNull:
+		isNull, err := cast.ToBoolE(value)
+		if err != nil {
+			return nil, errors.New("需要布尔值")
+		}
+		if isNull {
+			return clause.Eq{Column: column, Value: nil}, nil
+		}
+		return clause.Neq{Column: column, Value: nil}, nil
+	default:
+		return clause.Eq{Column: column, Value: value}, nil
+	}
+}
+
+// likeEscape like 条件的转义字符，反斜杠在 MySQL 和 PostgreSQL 字符串中的含义不同，使用 !
+const likeEscape = "!"
+
+// escapeLike 转义值中的通配符，clickhouse 不支持 ESCAPE，使用默认的反斜杠转义
+func (i *filterBuilder) escapeLike(value any) string {
+	escape := likeEscape
+	if i.db.Dialector.Name() == "clickhouse" {
+		escape = `\`
+	}
+	return strings.NewReplacer(escape, escape+escape, "%", escape+"%", "_", escape+"_").Replace(cast.ToString(value))
+}
+
+func (i *filterBuilder) like(column clause.Column, pattern string) clause.Expression {
+	if i.db.Dialector.Name() == "clickhouse" {
+		return clause.Like{Column: column, Value: pattern}
+	}
+	return clause.Expr{SQL: "? LIKE ? ESCAPE '" + likeEscape + "'", Vars: []any{column, pattern}}
+}
+
+// filterTypeKey 查询条件结构体和模型的组合
+type filterTypeKey struct {
+	filter reflect.Type
+	model  reflect.Type
+}
+
+var filterTypeCache sync.Map // filterTypeKey -> error
+
+// checkFilterType 检查查询条件结构体的 filter、sort 标签，列名和关联需要在模型中存在，每种组合只检查一次
+func checkFilterType(t reflect.Type, s *schema.Schema) error {
+	key := filterTypeKey{filter: t, model: s.ModelType}
+	if value, ok := filterTypeCache.Load(key); ok {
+		err, _ := value.(error)
+		return err
+	}
+	err := checkFilterFields(t, s)
+	if err != nil {
+		err = fmt.Errorf("%w: %s %w", ErrInvalidFilter, t.String(), err)
+	}
+	filterTypeCache.Store(key, err)
+	return err
+}
+
+func checkFilterFields(t reflect.Type, s *schema.Schema) error {
+	for n := 0; n < t.NumField(); n++ {
+		field := t.Field(n)
+		if !field.IsExported() {
+			continue
+		}
+		tag, hasFilter := field.Tag.Lookup("filter")
+		sortColumns, hasSort := field.Tag.Lookup("sort")
+
+		if field.Anonymous && !hasFilter && !hasSort {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := checkFilterFields(ft, s); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		switch {
+		case hasSort:
+			for _, column := range strings.Split(sortColumns, ",") {
+				if f := s.LookUpField(strings.TrimSpace(column)); f == nil || f.DBName == "" {
+					return fmt.Errorf("字段 %s 的 sort 标签错误: 模型 %s 没有字段 %s", field.Name, s.Name, column)
+				}
+			}
+		case hasFilter && tag != "-":
+			spec, err := parseFilterTag(tag)
+			if err != nil {
+				return fmt.Errorf("字段 %s 的 filter 标签错误: %w", field.Name, err)
+			}
+			for _, name := range spec.columns {
+				if err = checkFilterColumn(s, name); err != nil {
+					return fmt.Errorf("字段 %s 的 filter 标签错误: %w", field.Name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func checkFilterColumn(s *schema.Schema, name string) error {
+	relation, column, ok := strings.Cut(name, ".")
+	if !ok {
+		if field := s.LookUpField(name); field == nil || field.DBName == "" {
+			return fmt.Errorf("模型 %s 没有字段 %s", s.Name, name)
+		}
+		return nil
+	}
+	rel, _, err := lookUpRelationField(s, relation, column)
+	if err != nil {
+		return err
+	}
+	switch rel.Type {
+	case schema.BelongsTo, schema.HasOne, schema.HasMany, schema.Many2Many:
+		return nil
+	}
+	return fmt.Errorf("关联 %s.%s 不支持过滤", s.Name, relation)
+}
+
+func lookUpRelationField(s *schema.Schema, name, column string) (*schema.Relationship, *schema.Field, error) {
+	rel, ok := s.Relationships.Relations[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("模型 %s 没有关联 %s", s.Name, name)
+	}
+	field := rel.FieldSchema.LookUpField(column)
+	if field == nil || field.DBName == "" {
+		return nil, nil, fmt.Errorf("模型 %s 没有字段 %s", rel.FieldSchema.Name, column)
+	}
+	return rel, field, nil
+}
+
+// filterValues 将切片、JSON 数组字符串、逗号分隔的字符串转为值列表
+func filterValues(value any) []any {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		values := make([]any, v.Len())
+		for n := range values {
+			values[n] = v.Index(n).Interface()
+		}
+		return values
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return []any{value}
+	}
+	var values []any
+	if strings.HasPrefix(strings.TrimSpace(str), "[") {
+		if jsoniter.Unmarshal([]byte(str), &values) == nil {
+			return values
+		}
+	}
+	for _, item := range strings.Split(str, ",") {
+		values = append(values, strings.TrimSpace(item))
+	}
+	return values
+}
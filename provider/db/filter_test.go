@@ -0,0 +1,156 @@
+package db
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+type filterDept struct {
+	ID   uint `gorm:"primarykey"`
+	Name string
+}
+
+type filterRole struct {
+	ID   uint `gorm:"primarykey"`
+	Name string
+}
+
+type filterUser struct {
+	ID     uint `gorm:"primarykey"`
+	Name   string
+	Mobile string
+	Age    int
+	DeptID uint
+	Dept   *filterDept
+	Roles  []filterRole `gorm:"many2many:filter_user_role"`
+}
+
+type userFilter struct {
+	Name     string   `form:"name" filter:"name,like"`
+	Keyword  string   `form:"keyword" filter:"name|mobile,like"`
+	Age      []int    `form:"age[]" filter:"age,between"`
+	MaxAge   *int     `form:"maxAge" filter:"age,lte"`
+	IDs      string   `form:"ids" filter:"id,in"`
+	DeptName string   `form:"deptName" filter:"Dept.name"`
+	RoleName string   `form:"roleName" filter:"Roles.name"`
+	MinAge   int      `form:"minAge" filter:"age,gte,or=young"`
+	Mobiles  []string `form:"mobiles" filter:"mobile,in,or=young"`
+	Sort     string   `form:"sort" sort:"age,name"`
+}
+
+func TestApplyFilter(t *testing.T) {
+	db := openTestDB(t)
+	if err := db.AutoMigrate(&filterDept{}, &filterRole{}, &filterUser{}); err != nil {
+		t.Fatal(err)
+	}
+	dev, ops := filterDept{Name: "研发"}, filterDept{Name: "运维"}
+	admin := filterRole{Name: "admin"}
+	db.Create(&[]*filterDept{&dev, &ops})
+	db.Create(&admin)
+	db.Create(&[]filterUser{
+		{Name: "张三", Mobile: "13800000001", Age: 20, DeptID: dev.ID, Roles: []filterRole{admin}},
+		{Name: "李四", Mobile: "13800000002", Age: 30, DeptID: dev.ID},
+		{Name: "张五", Mobile: "13900000003", Age: 40, DeptID: ops.ID},
+	})
+
+	names := func(f *userFilter) string {
+		t.Helper()
+		var list []filterUser
+		if err := db.Model(&filterUser{}).Scopes(Filter(f)).Order("id").Find(&list).Error; err != nil {
+			t.Fatal(err)
+		}
+		var result []string
+		for _, u := range list {
+			result = append(result, u.Name)
+		}
+		return strings.Join(result, ",")
+	}
+
+	maxAge := 30
+	cases := []struct {
+		filter *userFilter
+		want   string
+	}{
+		{&userFilter{}, "张三,李四,张五"},
+		{&userFilter{Name: "张"}, "张三,张五"},
+		{&userFilter{Keyword: "139"}, "张五"},
+		{&userFilter{Age: []int{20, 30}}, "张三,李四"},
+		{&userFilter{MaxAge: &maxAge}, "张三,李四"},
+		{&userFilter{IDs: "1,3"}, "张三,张五"},
+		{&userFilter{DeptName: "研发"}, "张三,李四"},
+		{&userFilter{RoleName: "admin"}, "张三"},
+		{&userFilter{MinAge: 40, Mobiles: []string{"13800000002"}}, "李四,张五"},
+		{&userFilter{Name: "张", Sort: "-age"}, "张三,张五"},
+	}
+	for _, c := range cases {
+		if got := names(c.filter); got != c.want {
+			t.Errorf("filter %+v = %s, want %s", c.filter, got, c.want)
+		}
+	}
+
+	// 通配符按普通字符匹配
+	db.Create(&filterUser{Name: "满100%减!", Age: 35, DeptID: ops.ID})
+	for name, want := range map[string]string{"%": "满100%减!", "_": "", "0%减!": "满100%减!", "0_减": ""} {
+		if got := names(&userFilter{Name: name}); got != want {
+			t.Errorf("like %q = %s, want %s", name, got, want)
+		}
+	}
+
+	var list []filterUser
+	if err := db.Model(&filterUser{}).Scopes(Filter(&userFilter{Sort: "-age,name"})).Find(&list).Error; err != nil || list[0].Age != 40 {
+		t.Fatalf("sort = %+v, err = %v", list, err)
+	}
+
+	for _, f := range []*userFilter{{Sort: "mobile"}, {Sort: "age;drop table filter_user"}} {
+		err := db.Model(&filterUser{}).Scopes(Filter(f)).Find(&list).Error
+		if !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("sort %q err = %v", f.Sort, err)
+		}
+	}
+
+	type badBetween struct {
+		Age string `filter:"age,between"`
+	}
+	err := db.Model(&filterUser{}).Scopes(Filter(&badBetween{Age: "20"})).Find(&list).Error
+	if !errors.Is(err, ErrInvalidFilter) {
+		t.Errorf("between err = %v", err)
+	}
+
+	// 标签错误时不论字段是否有值都返回错误，不会 panic
+	type badColumn struct {
+		Nickname string `filter:"nickname"`
+	}
+	type badRelation struct {
+		Company string `filter:"Company.name,like"`
+	}
+	type badSort struct {
+		Sort string `sort:"age,password"`
+	}
+	for _, f := range []any{&badColumn{}, &badColumn{Nickname: "a"}, &badRelation{Company: "a"}, &badSort{}} {
+		err = db.Model(&filterUser{}).Scopes(Filter(f)).Find(&list).Error
+		if !errors.Is(err, ErrInvalidFilter) {
+			t.Errorf("%T err = %v", f, err)
+		}
+	}
+}
+
+func TestParseFilterTag(t *testing.T) {
+	spec, err := parseFilterTag("name|mobile,like,or=keyword")
+	if err != nil || strings.Join(spec.columns, "|") != "name|mobile" || spec.op != FilterLike || spec.group != "keyword" {
+		t.Fatalf("spec = %+v, err = %v", spec, err)
+	}
+	if _, err = parseFilterTag("name,contains"); err == nil {
+		t.Fatal("unknown operator should fail")
+	}
+
+	db := openTestDB(t)
+	sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&filterUser{}).Scopes(Filter(&userFilter{MaxAge: new(int)})).Find(&[]filterUser{})
+	})
+	if !strings.Contains(sql, "`filter_users`.`age` <= 0") {
+		t.Fatalf("sql = %s", sql)
+	}
+}
@@ -15,6 +15,7 @@ type OwlGormLogger struct {
 	log                     logContract.Logger
 	level                   logger.LogLevel
 	slowThreshold           time.Duration
+	repeatThreshold         int
 	ignoreRecordNotFoundErr bool
 }
 
@@ -25,10 +26,23 @@ func NewOwlGormLogger(l logContract.Logger) *OwlGormLogger {
 		log:                     l,
 		level:                   logger.Info,
 		slowThreshold:           200 * time.Millisecond,
+		repeatThreshold:         10,
 		ignoreRecordNotFoundErr: true,
 	}
 }
 
+// SetSlowThreshold 设置慢查询阈值，小于等于 0 时不记录慢查询
+func (i *OwlGormLogger) SetSlowThreshold(d time.Duration) *OwlGormLogger {
+	i.slowThreshold = d
+	return i
+}
+
+// SetRepeatThreshold 同一请求中相同形状的语句执行超过 n 次时警告可能存在 N+1 查询，小于等于 0 时不检测
+func (i *OwlGormLogger) SetRepeatThreshold(n int) *OwlGormLogger {
+	i.repeatThreshold = n
+	return i
+}
+
 func (i *OwlGormLogger) LogMode(level logger.LogLevel) logger.Interface {
 	n := *i
 	n.level = level
@@ -72,14 +86,26 @@ func (i *OwlGormLogger) Error(ctx context.Context, msg string, data ...interface
 }
 
 func (i *OwlGormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
-	if i.level <= logger.Silent || i.log == nil {
+	elapsed := time.Since(begin)
+	stats := QueryStatsFromContext(ctx)
+	if stats == nil && (i.level <= logger.Silent || i.log == nil) {
 		return
 	}
 
-	elapsed := time.Since(begin)
 	sql, rows := fc()
 	requestID := getRequestID(ctx)
 
+	// 请求内的查询统计不受日志级别影响
+	if stats != nil {
+		shape, times := stats.record(sql, elapsed)
+		if i.repeatThreshold > 0 && times == i.repeatThreshold+1 && i.level >= logger.Warn && i.log != nil {
+			i.log.WithContext(ctx).Warning("同一请求中重复执行相同的 SQL，可能存在 N+1 查询", "requestId", requestID, "次数", times, "sql:", shape)
+		}
+	}
+	if i.level <= logger.Silent || i.log == nil {
+		return
+	}
+
 	if err != nil {
 		if i.ignoreRecordNotFoundErr && errors.Is(err, gorm.ErrRecordNotFound) {
 			return
@@ -9,8 +9,26 @@ import (
 	jsoniter "github.com/json-iterator/go"
 	"github.com/spf13/cast"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// AndWhere 在回调中追加必须满足的条件，已有 OR 条件时整体加括号，避免 a OR b AND cond
+func AndWhere(stmt *gorm.Statement, exprs ...clause.Expression) {
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok {
+			for _, e := range where.Exprs {
+				if _, ok := e.(clause.OrConditions); ok {
+					where.Exprs = []clause.Expression{clause.And(where.Exprs...)}
+					c.Expression = where
+					stmt.Clauses["WHERE"] = c
+					break
+				}
+			}
+		}
+	}
+	stmt.AddClause(clause.Where{Exprs: exprs})
+}
+
 // GetMenuModelsByIDs 获取菜单模型，gorm 多对多关联写数据
 
 func GetModelsByIDs[T any](modelIDs []string) []T {
@@ -37,6 +55,8 @@ func GetModelsByIDs[T any](modelIDs []string) []T {
 // NameLike = name like
 // AgeGt = where age >
 // AgeLt = where age <
+//
+// Deprecated: 字段名直接拼接到 SQL 中，使用 ApplyFilter 或 Filter 代替
 func AppendWhereFromStruct(db *gorm.DB, s any) {
 	result := make(map[string]interface{})
 	v := reflect.ValueOf(s)
@@ -64,7 +84,7 @@ func AppendWhereFromStruct(db *gorm.DB, s any) {
 		case "lt":
 			db.Where(fmt.Sprintf("%s < ?", realField), value)
 		case "lte":
-			db.Where(fmt.Sprintf("%s < ?", realField), value)
+			db.Where(fmt.Sprintf("%s <= ?", realField), value)
 		case "in":
 			str, ok := value.(string)
 
@@ -147,3 +167,43 @@ func getNonZeroFields(v reflect.Value, result map[string]interface{}) {
 		}
 	}
 }
+
+// HasWriteCondition 更新、删除语句是否有 WHERE 条件，或者 gorm:update、gorm:delete 会按模型的主键追加条件
+// 回调为更新、删除追加条件前需要检查，否则会绕过 gorm 的 ErrMissingWhereClause 检查，修改全部数据
+func HasWriteCondition(db *gorm.DB) bool {
+	stmt := db.Statement
+	if db.AllowGlobalUpdate {
+		return true
+	}
+	if c, ok := stmt.Clauses["WHERE"]; ok {
+		if where, ok := c.Expression.(clause.Where); ok && len(where.Exprs) > 0 {
+			return true
+		}
+	}
+	if stmt.Schema == nil || !stmt.ReflectValue.IsValid() {
+		return false
+	}
+	switch stmt.ReflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for n := 0; n < stmt.ReflectValue.Len(); n++ {
+			if hasPrimaryValue(stmt, reflect.Indirect(stmt.ReflectValue.Index(n))) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		return hasPrimaryValue(stmt, stmt.ReflectValue)
+	}
+	return false
+}
+
+func hasPrimaryValue(stmt *gorm.Statement, v reflect.Value) bool {
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+	for _, field := range stmt.Schema.PrimaryFields {
+		if _, zero := field.ValueOf(stmt.Context, v); !zero {
+			return true
+		}
+	}
+	return false
+}
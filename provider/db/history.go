@@ -0,0 +1,238 @@
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+	"github.com/spf13/cast"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// HistoryModel 模型实现该接口后，修改单条数据时在 model_histories 表中记录字段变更
+// 按主键或按条件更新都会记录，条件匹配多条数据的批量更新不记录
+// 返回不需要记录的列名，如密码
+//
+//	func (i *Article) HistoryIgnoreColumns() []string {
+//		return []string{"content"}
+//	}
+//
+// 需要注册 HistoryMigration 创建历史表
+type HistoryModel interface {
+	HistoryIgnoreColumns() []string
+}
+
+// FieldChange 字段变更前后的值
+type FieldChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+// ModelHistory 数据修改历史
+type ModelHistory struct {
+	ID           uint      `gorm:"primarykey" json:"id,string"`
+	Table        string    `gorm:"size:64;index:idx_model_histories_record" json:"table"`
+	RecordID     string    `gorm:"size:64;index:idx_model_histories_record" json:"recordID"`
+	Changes      string    `gorm:"type:text" json:"changes"` // JSON，列名 -> FieldChange
+	OperatorID   string    `gorm:"size:64" json:"operatorID"`
+	OperatorName string    `gorm:"size:64" json:"operatorName"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+func (ModelHistory) TableName() string {
+	return "model_histories"
+}
+
+// Diff 解析字段变更
+func (i *ModelHistory) Diff() (map[string]FieldChange, error) {
+	changes := make(map[string]FieldChange)
+	err := jsoniter.UnmarshalFromString(i.Changes, &changes)
+	return changes, err
+}
+
+// HistoryMigration 创建历史表的迁移，由使用历史记录的子应用注册
+//
+//	db.RegisterMigrations("admin", db.HistoryMigration("20240601000000"))
+func HistoryMigration(version string) *Migration {
+	return &Migration{
+		Version:     version,
+		Description: "创建数据修改历史表",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&ModelHistory{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&ModelHistory{})
+		},
+	}
+}
+
+// historyIgnoreColumns 始终不记录的列
+var historyIgnoreColumns = []string{"updated_at", "updater_id", "updater_name"}
+
+const historySnapshotKey = "owl:history_snapshot"
+
+type historySnapshot struct {
+	recordID any
+	old      map[string]any
+}
+
+var historyColumnsCache sync.Map // reflect.Type -> map[string]bool
+
+// HistoryPlugin 记录实现 HistoryModel 的模型的字段变更
+type HistoryPlugin struct{}
+
+func (i *HistoryPlugin) Name() string {
+	return "owl:history"
+}
+
+func (i *HistoryPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Update().Before("gorm:update").Register("owl:history_snapshot", snapshotHistory); err != nil {
+		return err
+	}
+	// 在默认事务提交之前写入，写入失败时回滚
+	return cb.Update().After("gorm:update").Before("gorm:commit_or_rollback_transaction").
+		Register("owl:history_record", recordHistory)
+}
+
+// snapshotHistory 更新之前读取数据，只处理更新单条数据
+// 主键从模型中获取，没有时按更新条件查询，如 UpdateFields、ChangeStatus 等 Model(new(T)).Where("id = ?") 的更新
+func snapshotHistory(db *gorm.DB) {
+	stmt := db.Statement
+	ignore := historyColumns(stmt.Schema)
+	if ignore == nil || db.Error != nil || stmt.DryRun || stmt.Schema.PrioritizedPrimaryField == nil {
+		return
+	}
+	id, ok := historyRecordID(db)
+	if !ok {
+		return
+	}
+
+	old, err := loadHistoryRow(db, id)
+	if err != nil {
+		return
+	}
+	stmt.Settings.Store(historySnapshotKey, &historySnapshot{recordID: id, old: old})
+}
+
+// historyRecordID 获取更新的记录的主键，条件匹配多条数据时不记录
+func historyRecordID(db *gorm.DB) (any, bool) {
+	stmt := db.Statement
+	rv := reflect.Indirect(stmt.ReflectValue)
+	if rv.Kind() == reflect.Struct {
+		if id, zero := stmt.Schema.PrioritizedPrimaryField.ValueOf(stmt.Context, rv); !zero {
+			return id, true
+		}
+	}
+
+	where, ok := stmt.Clauses["WHERE"].Expression.(clause.Where)
+	if !ok || len(where.Exprs) == 0 {
+		return nil, false
+	}
+	var ids []any
+	err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).
+		Model(reflect.New(stmt.Schema.ModelType).Interface()).Clauses(where).
+		Limit(2).Pluck(stmt.Schema.PrioritizedPrimaryField.DBName, &ids).Error
+	if err != nil || len(ids) != 1 {
+		return nil, false
+	}
+	return ids[0], true
+}
+
+func recordHistory(db *gorm.DB) {
+	stmt := db.Statement
+	value, ok := stmt.Settings.LoadAndDelete(historySnapshotKey)
+	if !ok || db.Error != nil || db.RowsAffected == 0 {
+		return
+	}
+	snapshot := value.(*historySnapshot)
+	ignore := historyColumns(stmt.Schema)
+
+	current, err := loadHistoryRow(db, snapshot.recordID)
+	if err != nil {
+		_ = db.AddError(fmt.Errorf("读取修改后的数据失败: %w", err))
+		return
+	}
+
+	changes := make(map[string]FieldChange)
+	for column, newValue := range current {
+		if ignore[column] {
+			continue
+		}
+		oldValue := snapshot.old[column]
+		if historyValue(oldValue) != historyValue(newValue) {
+			changes[column] = FieldChange{Old: normalizeHistoryValue(oldValue), New: normalizeHistoryValue(newValue)}
+		}
+	}
+	if len(changes) == 0 {
+		return
+	}
+
+	data, err := jsoniter.MarshalToString(changes)
+	if err != nil {
+		_ = db.AddError(err)
+		return
+	}
+	ctx := stmt.Context
+	history := &ModelHistory{
+		Table:        stmt.Table,
+		RecordID:     cast.ToString(snapshot.recordID),
+		Changes:      data,
+		OperatorID:   cast.ToString(ctx.Value("user_id")),
+		OperatorName: cast.ToString(ctx.Value("username")),
+	}
+	if err = db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Create(history).Error; err != nil {
+		_ = db.AddError(fmt.Errorf("记录修改历史失败: %w", err))
+	}
+}
+
+func loadHistoryRow(db *gorm.DB, id any) (map[string]any, error) {
+	stmt := db.Statement
+	row := make(map[string]any)
+	primaryKey := clause.Column{Table: clause.CurrentTable, Name: stmt.Schema.PrioritizedPrimaryField.DBName}
+	err := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Table(stmt.Table).
+		Where(clause.Eq{Column: primaryKey, Value: id}).Take(&row).Error
+	return row, err
+}
+
+// historyColumns 模型未实现 HistoryModel 时返回 nil，否则返回不记录的列
+func historyColumns(s *schema.Schema) map[string]bool {
+	if s == nil {
+		return nil
+	}
+	if columns, ok := historyColumnsCache.Load(s.ModelType); ok {
+		return columns.(map[string]bool)
+	}
+
+	var columns map[string]bool
+	if model, ok := reflect.New(s.ModelType).Interface().(HistoryModel); ok {
+		columns = make(map[string]bool)
+		for _, column := range append(model.HistoryIgnoreColumns(), historyIgnoreColumns...) {
+			columns[column] = true
+		}
+		if field := versionField(s); field != nil {
+			columns[field.DBName] = true
+		}
+	}
+	historyColumnsCache.Store(s.ModelType, columns)
+	return columns
+}
+
+// normalizeHistoryValue 驱动返回的 []byte 转为字符串，便于比较和序列化
+func normalizeHistoryValue(value any) any {
+	if b, ok := value.([]byte); ok {
+		return string(b)
+	}
+	return value
+}
+
+func historyValue(value any) string {
+	if t, ok := value.(time.Time); ok {
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+	return fmt.Sprint(normalizeHistoryValue(value))
+}
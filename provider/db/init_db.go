@@ -1,6 +1,7 @@
 package db
 
 import (
+	"sync"
 	"time"
 
 	"bit-labs.cn/owl/contract/log"
@@ -14,15 +15,24 @@ type Options struct {
 	Username     string `json:"username"`
 	Password     string `json:"password"`
 	Host         string `json:"host"`
-	Port         int    `json:"port"`
-	Driver       Driver `json:"driver"` // 数据库类型
+	Port         int    `json:"port" validate:"omitempty,min=1,max=65535"`
+	Driver       Driver `json:"driver" validate:"required,oneof=mysql pgsql sqlite dm oracle clickhouse sqlserver"` // 数据库类型
 	Database     string `json:"database"`
 	Schema       string `json:"schema"`
 	Charset      string `json:"charset"`
 	Query        string `json:"query"`
-	MaxIdleConns int    `json:"max-idle-conns"`
-	MaxConns     int    `json:"max-conns"`
+	MaxIdleConns int    `json:"max-idle-conns" validate:"min=0"`
+	MaxConns     int    `json:"max-conns" validate:"min=0"`
 	TimeZone     string `json:"time-zone"`
+
+	DefaultPageSize int `json:"default-page-size" validate:"min=0"` // 默认每页数量
+	MaxPageSize     int `json:"max-page-size" validate:"min=0"`     // 每页最大数量，超过时使用该值
+
+	SlowThreshold   *int `json:"slow-threshold" validate:"omitempty,min=0"`   // 慢查询阈值，毫秒，0 不记录，默认 200
+	RepeatThreshold *int `json:"repeat-threshold" validate:"omitempty,min=0"` // 同一请求相同语句执行超过该次数时警告 N+1，0 不检测，默认 10
+
+	Replicas    []Options          `json:"replicas"`                    // 从库，未配置的字段继承主库
+	Connections map[string]Options `json:"connections" validate:"dive"` // 命名连接，仅在默认连接中配置
 }
 
 type CustomReplacer struct {
@@ -33,18 +43,31 @@ func (r CustomReplacer) Replace(name string) string {
 	return r.f(name)
 }
 
+var (
+	pluginsLock       sync.RWMutex
+	registeredPlugins []gorm.Plugin
+)
+
+// RegisterPlugin 注册应用到所有连接的 gorm 插件，需在连接创建之前调用，通常在服务提供者的 Register 中
+func RegisterPlugin(plugins ...gorm.Plugin) {
+	pluginsLock.Lock()
+	registeredPlugins = append(registeredPlugins, plugins...)
+	pluginsLock.Unlock()
+}
+
 func InitDB(opt *Options, log log.Logger, plugins ...gorm.Plugin) *gorm.DB {
 
-	var dbGetter Connector
-	switch opt.Driver {
-	case Mysql:
-		dbGetter = NewMysqlConnector(opt)
-	case Pgsql:
-		dbGetter = NewPgSqlGetter(opt)
-	case Sqlite:
-		dbGetter = NewSqliteGetter(opt)
-	default:
-		panic("不支持的数据库类型")
+	dbGetter, err := NewConnector(opt)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	gormLogger := NewOwlGormLogger(log)
+	if opt.SlowThreshold != nil {
+		gormLogger.SetSlowThreshold(time.Duration(*opt.SlowThreshold) * time.Millisecond)
+	}
+	if opt.RepeatThreshold != nil {
+		gormLogger.SetRepeatThreshold(*opt.RepeatThreshold)
 	}
 
 	gormCfg := &gorm.Config{
@@ -56,17 +79,31 @@ func InitDB(opt *Options, log log.Logger, plugins ...gorm.Plugin) *gorm.DB {
 		},
 
 		SkipDefaultTransaction: true,
-		Logger:                 NewOwlGormLogger(log).LogMode(logger.Info),
+		Logger:                 gormLogger.LogMode(logger.Info),
 	}
 
 	var openDb *gorm.DB
-	var err error
 	openDb, err = dbGetter.Open(gormCfg)
 
 	if err != nil {
 		panic("数据库连接失败，请检查数据库是否启动，配置是否错误" + err.Error())
 	}
 
+	// 配置了从库时，读操作路由到从库，写操作路由到主库
+	if len(opt.Replicas) > 0 {
+		resolver, err := newReplicaResolver(opt)
+		if err != nil {
+			panic("从库配置错误" + err.Error())
+		}
+		plugins = append([]gorm.Plugin{resolver, &StickyPlugin{}}, plugins...)
+	}
+	// 使用 db.WithContext(ctx) 的查询自动加入 Tx 开启的事务，实现 DataScopeModel 的模型按数据权限过滤，
+	// Version 字段乐观锁，实现 HistoryModel 的模型记录修改历史
+	plugins = append([]gorm.Plugin{&TxPlugin{}, &DataScopePlugin{}, &OptimisticLockPlugin{}, &HistoryPlugin{}}, plugins...)
+	pluginsLock.RLock()
+	plugins = append(plugins, registeredPlugins...)
+	pluginsLock.RUnlock()
+
 	for _, plugin := range plugins {
 		err = openDb.Use(plugin)
 		if err != nil {
@@ -0,0 +1,148 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"bit-labs.cn/owl/provider/redis"
+	"bit-labs.cn/owl/utils"
+	"github.com/spf13/cobra"
+	"go.uber.org/dig"
+	"gorm.io/gorm"
+)
+
+// Commands 数据库迁移相关命令
+func (i *DBServiceProvider) Commands() []*cobra.Command {
+	return []*cobra.Command{
+		i.newMigrateCommand(),
+		i.newMigrateRollbackCommand(),
+		i.newMigrateStatusCommand(),
+		i.newMigrateFreshCommand(),
+	}
+}
+
+// migrator 从容器中创建迁移器，已注册 Redis 时使用 Redis 分布式锁，否则使用数据库锁
+func (i *DBServiceProvider) migrator() (*Migrator, error) {
+	type deps struct {
+		dig.In
+		DB     *gorm.DB
+		Locker redis.LockerFactory `optional:"true"`
+	}
+
+	var m *Migrator
+	err := i.app.Invoke(func(d deps) {
+		m = NewMigrator(d.DB, d.Locker)
+	})
+	return m, err
+}
+
+func (i *DBServiceProvider) newMigrateCommand() *cobra.Command {
+	var step int
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "执行数据库迁移",
+		Long:  "按版本号顺序执行所有未执行的迁移，多个副本同时执行时只有一个副本会获得迁移锁",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := i.migrator()
+			if err != nil {
+				return err
+			}
+			executed, err := m.Migrate(context.Background(), step)
+			printMigrations("已执行", executed)
+			if err == nil && len(executed) == 0 {
+				utils.PrintLnGreen("没有需要执行的迁移")
+			}
+			return err
+		},
+	}
+	cmd.Flags().IntVar(&step, "step", 0, "最多执行的迁移数量，0 表示全部")
+	return cmd
+}
+
+func (i *DBServiceProvider) newMigrateRollbackCommand() *cobra.Command {
+	var step int
+	cmd := &cobra.Command{
+		Use:   "migrate:rollback",
+		Short: "回滚数据库迁移",
+		Long:  "默认回滚最后一批执行的迁移，指定 --step 时按版本号倒序回滚指定数量的迁移",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := i.migrator()
+			if err != nil {
+				return err
+			}
+			rolledBack, err := m.Rollback(context.Background(), step)
+			printMigrations("已回滚", rolledBack)
+			if err == nil && len(rolledBack) == 0 {
+				utils.PrintLnGreen("没有需要回滚的迁移")
+			}
+			return err
+		},
+	}
+	cmd.Flags().IntVar(&step, "step", 0, "回滚的迁移数量，0 表示最后一批")
+	return cmd
+}
+
+func (i *DBServiceProvider) newMigrateStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "migrate:status",
+		Short: "查看数据库迁移状态",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := i.migrator()
+			if err != nil {
+				return err
+			}
+			statuses, err := m.Status(context.Background())
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "版本\t子应用\t描述\t状态\t批次\t执行时间")
+			for _, s := range statuses {
+				state, batch, appliedAt := "未执行", "-", "-"
+				if s.Applied {
+					state = "已执行"
+					batch = fmt.Sprint(s.Batch)
+					appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+				}
+				if s.Missing {
+					state = "已执行(未注册)"
+				}
+				_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", s.Version, s.App, s.Description, state, batch, appliedAt)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func (i *DBServiceProvider) newMigrateFreshCommand() *cobra.Command {
+	var force bool
+	cmd := &cobra.Command{
+		Use:   "migrate:fresh",
+		Short: "删除所有表并重新执行迁移",
+		Long:  "删除数据库中的所有表后重新执行全部迁移，会清空所有数据，仅用于开发和测试环境",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !force {
+				return errors.New("该操作会删除数据库中的所有表，确认执行请添加 --force")
+			}
+			m, err := i.migrator()
+			if err != nil {
+				return err
+			}
+			executed, err := m.Fresh(context.Background())
+			printMigrations("已执行", executed)
+			return err
+		},
+	}
+	cmd.Flags().BoolVar(&force, "force", false, "确认删除所有表")
+	return cmd
+}
+
+func printMigrations(action string, migrations []*Migration) {
+	for _, m := range migrations {
+		utils.PrintLnGreen(fmt.Sprintf("%s %s [%s] %s", action, m.Version, m.app, m.Description))
+	}
+}
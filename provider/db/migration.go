@@ -0,0 +1,217 @@
+package db
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration 版本化的数据库迁移，Up/Down 与 UpSQL/DownSQL 二选一
+// 版本号全局唯一，按字典序执行，建议使用时间戳作为版本号，如 20240601120000
+type Migration struct {
+	Version     string
+	Description string
+	Up          func(tx *gorm.DB) error
+	Down        func(tx *gorm.DB) error
+	UpSQL       string
+	DownSQL     string
+	// WithoutTransaction 不在事务中执行，如 pgsql 的 CREATE INDEX CONCURRENTLY
+	WithoutTransaction bool
+
+	app string
+}
+
+// App 迁移所属的子应用
+func (i *Migration) App() string {
+	return i.app
+}
+
+// SchemaMigration 已执行的迁移记录
+type SchemaMigration struct {
+	Version     string    `gorm:"primaryKey;size:64"`
+	App         string    `gorm:"size:64;index"`
+	Description string    `gorm:"size:255"`
+	Batch       int       `gorm:"index"`
+	AppliedAt   time.Time `gorm:"not null"`
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+var (
+	migrationsLock       sync.RWMutex
+	registeredMigrations = make(map[string]*Migration)
+)
+
+// RegisterMigrations 注册子应用的迁移，一般在子应用的 Bootstrap 中调用，版本号重复时 panic
+func RegisterMigrations(app string, migrations ...*Migration) {
+	migrationsLock.Lock()
+	defer migrationsLock.Unlock()
+
+	for _, m := range migrations {
+		if m.Version == "" {
+			panic(fmt.Sprintf("子应用 %s 的迁移缺少版本号", app))
+		}
+		if m.Up == nil && m.UpSQL == "" {
+			panic(fmt.Sprintf("迁移 %s 缺少 Up 或 UpSQL", m.Version))
+		}
+		if exists, ok := registeredMigrations[m.Version]; ok {
+			panic(fmt.Sprintf("迁移版本号 %s 重复，已被子应用 %s 注册", m.Version, exists.app))
+		}
+		m.app = app
+		registeredMigrations[m.Version] = m
+	}
+}
+
+// RegisterSQLMigrations 从目录中加载 SQL 迁移并注册，通常配合 embed.FS 使用
+func RegisterSQLMigrations(app string, fsys fs.FS, dir string) {
+	migrations, err := LoadSQLMigrations(fsys, dir)
+	if err != nil {
+		panic(err)
+	}
+	RegisterMigrations(app, migrations...)
+}
+
+// GetMigrations 按版本号排序返回所有已注册的迁移
+func GetMigrations() []*Migration {
+	migrationsLock.RLock()
+	defer migrationsLock.RUnlock()
+
+	result := make([]*Migration, 0, len(registeredMigrations))
+	for _, m := range registeredMigrations {
+		result = append(result, m)
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return result[a].Version < result[b].Version
+	})
+	return result
+}
+
+// LoadSQLMigrations 加载目录中的 SQL 迁移文件
+// 文件命名：版本号_描述.up.sql 与 版本号_描述.down.sql，如 20240601120000_create_user.up.sql
+func LoadSQLMigrations(fsys fs.FS, dir string) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[string]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+
+		base := strings.TrimSuffix(name, ".sql")
+		direction := path.Ext(base)
+		if direction != ".up" && direction != ".down" {
+			return nil, fmt.Errorf("迁移文件 %s 命名错误，应以 .up.sql 或 .down.sql 结尾", name)
+		}
+		base = strings.TrimSuffix(base, direction)
+		version, description, _ := strings.Cut(base, "_")
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Description: strings.ReplaceAll(description, "_", " ")}
+			byVersion[version] = m
+		}
+		if direction == ".up" {
+			m.UpSQL = string(content)
+		} else {
+			m.DownSQL = string(content)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.UpSQL == "" {
+			return nil, fmt.Errorf("迁移 %s 缺少 .up.sql 文件", m.Version)
+		}
+		migrations = append(migrations, m)
+	}
+	sort.Slice(migrations, func(a, b int) bool {
+		return migrations[a].Version < migrations[b].Version
+	})
+	return migrations, nil
+}
+
+func (i *Migration) up(tx *gorm.DB) error {
+	if i.Up != nil {
+		return i.Up(tx)
+	}
+	return execSQL(tx, i.UpSQL)
+}
+
+func (i *Migration) down(tx *gorm.DB) error {
+	if i.Down != nil {
+		return i.Down(tx)
+	}
+	if i.DownSQL == "" {
+		return fmt.Errorf("迁移 %s 不支持回滚", i.Version)
+	}
+	return execSQL(tx, i.DownSQL)
+}
+
+// execSQL 逐条执行 SQL 语句，部分驱动不支持一次执行多条语句
+func execSQL(tx *gorm.DB, sql string) error {
+	for _, stmt := range splitSQL(sql) {
+		if err := tx.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitSQL 按分号拆分 SQL 语句，忽略引号中的分号和 -- 注释
+func splitSQL(sql string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+		quote      rune
+		comment    bool
+	)
+	runes := []rune(sql)
+	for idx := 0; idx < len(runes); idx++ {
+		r := runes[idx]
+		switch {
+		case comment:
+			if r == '\n' {
+				comment = false
+				current.WriteRune(r)
+			}
+			continue
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"' || r == '`':
+			quote = r
+		case r == '-' && idx+1 < len(runes) && runes[idx+1] == '-':
+			comment = true
+			continue
+		case r == ';':
+			if stmt := strings.TrimSpace(current.String()); stmt != "" {
+				statements = append(statements, stmt)
+			}
+			current.Reset()
+			continue
+		}
+		current.WriteRune(r)
+	}
+	if stmt := strings.TrimSpace(current.String()); stmt != "" {
+		statements = append(statements, stmt)
+	}
+	return statements
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"bit-labs.cn/owl/provider/redis"
+	"gorm.io/gorm"
+)
+
+// migrationLockKey 迁移锁的键，多个副本同时启动时只有获得锁的副本执行迁移
+const migrationLockKey = "owl:schema_migrations:lock"
+
+// migrationLock 迁移锁，持有期间需要定期续期
+type migrationLock interface {
+	TryLock(ctx context.Context) error
+	Refresh(ctx context.Context) error
+	Unlock()
+}
+
+// acquireMigrationLock 获取迁移锁并定期续期，超时返回错误，返回的函数用于释放锁
+func acquireMigrationLock(ctx context.Context, lock migrationLock, timeout time.Duration) (func(), error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := lock.TryLock(ctx)
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("获取迁移锁超时，可能有其他副本正在执行迁移: %w", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+
+	refreshCtx, cancel := context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(3 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-refreshCtx.Done():
+				return
+			case <-ticker.C:
+				_ = lock.Refresh(refreshCtx)
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		lock.Unlock()
+	}, nil
+}
+
+// redisMigrationLock 基于 Redis 分布式锁的迁移锁
+type redisMigrationLock struct {
+	locker redis.Locker
+}
+
+func (i *redisMigrationLock) TryLock(context.Context) error {
+	return i.locker.Lock(migrationLockKey)
+}
+
+func (i *redisMigrationLock) Refresh(context.Context) error {
+	if extender, ok := i.locker.(interface{ Extend() error }); ok {
+		return extender.Extend()
+	}
+	return nil
+}
+
+func (i *redisMigrationLock) Unlock() {
+	i.locker.Unlock()
+}
+
+// SchemaMigrationLock 未配置 Redis 时使用数据库表实现的迁移锁
+type SchemaMigrationLock struct {
+	ID       uint      `gorm:"primaryKey;autoIncrement:false"`
+	Owner    string    `gorm:"size:128"`
+	LockedAt time.Time `gorm:"not null"`
+}
+
+func (SchemaMigrationLock) TableName() string {
+	return "schema_migrations_lock"
+}
+
+// dbLockStaleAfter 超过此时间未续期的锁视为持有者已退出
+const dbLockStaleAfter = time.Minute
+
+// dbMigrationLock 基于数据库唯一主键的迁移锁
+type dbMigrationLock struct {
+	db    *gorm.DB
+	owner string
+}
+
+func newDBMigrationLock(db *gorm.DB) *dbMigrationLock {
+	host, _ := os.Hostname()
+	return &dbMigrationLock{db: db, owner: fmt.Sprintf("%s:%d", host, os.Getpid())}
+}
+
+func (i *dbMigrationLock) TryLock(ctx context.Context) error {
+	tx := i.db.WithContext(ctx)
+	if err := tx.AutoMigrate(&SchemaMigrationLock{}); err != nil {
+		return err
+	}
+	tx.Where("locked_at < ?", time.Now().Add(-dbLockStaleAfter)).Delete(&SchemaMigrationLock{})
+	return tx.Create(&SchemaMigrationLock{ID: 1, Owner: i.owner, LockedAt: time.Now()}).Error
+}
+
+func (i *dbMigrationLock) Refresh(ctx context.Context) error {
+	return i.db.WithContext(ctx).Model(&SchemaMigrationLock{}).
+		Where("id = ? AND owner = ?", 1, i.owner).
+		Update("locked_at", time.Now()).Error
+}
+
+func (i *dbMigrationLock) Unlock() {
+	i.db.Where("id = ? AND owner = ?", 1, i.owner).Delete(&SchemaMigrationLock{})
+}
@@ -0,0 +1,262 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"bit-labs.cn/owl/provider/redis"
+	"gorm.io/gorm"
+)
+
+// Migrator 执行已注册的迁移，并在 schema_migrations 表中记录执行结果
+type Migrator struct {
+	db          *gorm.DB
+	lock        migrationLock
+	lockTimeout time.Duration
+}
+
+// NewMigrator 创建迁移器，locker 为空时使用数据库表作为迁移锁
+func NewMigrator(db *gorm.DB, locker redis.LockerFactory) *Migrator {
+	var lock migrationLock = newDBMigrationLock(db)
+	if locker != nil {
+		lock = &redisMigrationLock{locker: locker.New()}
+	}
+	return &Migrator{db: db, lock: lock, lockTimeout: 10 * time.Minute}
+}
+
+// MigrationStatus 迁移的执行状态
+type MigrationStatus struct {
+	Version     string
+	App         string
+	Description string
+	Applied     bool
+	Batch       int
+	AppliedAt   *time.Time
+	Missing     bool // 已执行但当前没有注册，通常是迁移被删除或子应用未启用
+}
+
+// Migrate 执行未执行的迁移，step 大于 0 时最多执行 step 个，返回本次执行的迁移
+func (i *Migrator) Migrate(ctx context.Context, step int) ([]*Migration, error) {
+	var executed []*Migration
+	err := i.withLock(ctx, func(db *gorm.DB) (err error) {
+		executed, err = i.migrate(db, step)
+		return err
+	})
+	return executed, err
+}
+
+// migrate 执行未执行的迁移，调用方需持有迁移锁
+func (i *Migrator) migrate(db *gorm.DB, step int) ([]*Migration, error) {
+	applied, err := i.applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := 1
+	for _, record := range applied {
+		batch = max(batch, record.Batch+1)
+	}
+
+	var executed []*Migration
+	for _, m := range GetMigrations() {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if step > 0 && len(executed) >= step {
+			break
+		}
+		if err = i.run(db, m, batch); err != nil {
+			return executed, fmt.Errorf("执行迁移 %s 失败: %w", m.Version, err)
+		}
+		executed = append(executed, m)
+	}
+	return executed, nil
+}
+
+// Rollback 回滚迁移，step 为 0 时回滚最后一批，否则按版本号倒序回滚 step 个，返回本次回滚的迁移
+func (i *Migrator) Rollback(ctx context.Context, step int) ([]*Migration, error) {
+	var rolledBack []*Migration
+	err := i.withLock(ctx, func(db *gorm.DB) error {
+		var records []SchemaMigration
+		if err := db.Order("batch DESC, version DESC").Find(&records).Error; err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+
+		registered := make(map[string]*Migration)
+		for _, m := range GetMigrations() {
+			registered[m.Version] = m
+		}
+
+		lastBatch := records[0].Batch
+		for idx, record := range records {
+			if step > 0 && idx >= step {
+				break
+			}
+			if step == 0 && record.Batch != lastBatch {
+				break
+			}
+			m, ok := registered[record.Version]
+			if !ok {
+				return fmt.Errorf("迁移 %s 未注册，无法回滚", record.Version)
+			}
+			if err := i.revert(db, m); err != nil {
+				return fmt.Errorf("回滚迁移 %s 失败: %w", m.Version, err)
+			}
+			rolledBack = append(rolledBack, m)
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+// Fresh 删除数据库中的所有表后重新执行全部迁移，两步持有同一个迁移锁，其他副本不会在中间执行迁移
+func (i *Migrator) Fresh(ctx context.Context) ([]*Migration, error) {
+	var executed []*Migration
+	err := i.withLock(ctx, func(db *gorm.DB) (err error) {
+		if err = i.dropAllTables(db); err != nil {
+			return err
+		}
+		executed, err = i.migrate(db, 0)
+		return err
+	})
+	return executed, err
+}
+
+// Status 返回所有迁移的执行状态，按版本号排序
+func (i *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	db := i.db.WithContext(ctx)
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return nil, err
+	}
+	applied, err := i.applied(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []MigrationStatus
+	for _, m := range GetMigrations() {
+		status := MigrationStatus{Version: m.Version, App: m.app, Description: m.Description}
+		if record, ok := applied[m.Version]; ok {
+			status.Applied = true
+			status.Batch = record.Batch
+			status.AppliedAt = &record.AppliedAt
+			delete(applied, m.Version)
+		}
+		result = append(result, status)
+	}
+	for _, version := range sortedVersions(applied) {
+		record := applied[version]
+		result = append(result, MigrationStatus{
+			Version:     record.Version,
+			App:         record.App,
+			Description: record.Description,
+			Applied:     true,
+			Batch:       record.Batch,
+			AppliedAt:   &record.AppliedAt,
+			Missing:     true,
+		})
+	}
+	return result, nil
+}
+
+func (i *Migrator) withLock(ctx context.Context, fn func(db *gorm.DB) error) error {
+	unlock, err := acquireMigrationLock(ctx, i.lock, i.lockTimeout)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	db := i.db.WithContext(ctx)
+	if err = db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return err
+	}
+	return fn(db)
+}
+
+func (i *Migrator) applied(db *gorm.DB) (map[string]SchemaMigration, error) {
+	var records []SchemaMigration
+	if err := db.Find(&records).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[string]SchemaMigration, len(records))
+	for _, record := range records {
+		result[record.Version] = record
+	}
+	return result, nil
+}
+
+func (i *Migrator) run(db *gorm.DB, m *Migration, batch int) error {
+	record := &SchemaMigration{
+		Version:     m.Version,
+		App:         m.app,
+		Description: m.Description,
+		Batch:       batch,
+		AppliedAt:   time.Now(),
+	}
+	if m.WithoutTransaction {
+		if err := m.up(db); err != nil {
+			return err
+		}
+		return db.Create(record).Error
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := m.up(tx); err != nil {
+			return err
+		}
+		return tx.Create(record).Error
+	})
+}
+
+func (i *Migrator) revert(db *gorm.DB, m *Migration) error {
+	if m.WithoutTransaction {
+		if err := m.down(db); err != nil {
+			return err
+		}
+		return db.Delete(&SchemaMigration{}, "version = ?", m.Version).Error
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := m.down(tx); err != nil {
+			return err
+		}
+		return tx.Delete(&SchemaMigration{}, "version = ?", m.Version).Error
+	})
+}
+
+// dropAllTables 删除除迁移锁之外的所有表，mysql 需要在同一连接中关闭外键检查
+func (i *Migrator) dropAllTables(db *gorm.DB) error {
+	tables, err := db.Migrator().GetTables()
+	if err != nil {
+		return err
+	}
+	return db.Connection(func(conn *gorm.DB) error {
+		if conn.Dialector.Name() == "mysql" {
+			if err := conn.Exec("SET FOREIGN_KEY_CHECKS = 0").Error; err != nil {
+				return err
+			}
+			defer conn.Exec("SET FOREIGN_KEY_CHECKS = 1")
+		}
+		for _, table := range tables {
+			if table == (SchemaMigrationLock{}).TableName() {
+				continue
+			}
+			if err := conn.Migrator().DropTable(table); err != nil {
+				return fmt.Errorf("删除表 %s 失败: %w", table, err)
+			}
+		}
+		return conn.AutoMigrate(&SchemaMigration{})
+	})
+}
+
+func sortedVersions(m map[string]SchemaMigration) []string {
+	versions := make([]string, 0, len(m))
+	for v := range m {
+		versions = append(versions, v)
+	}
+	sort.Strings(versions)
+	return versions
+}
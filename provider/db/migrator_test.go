@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestSplitSQL(t *testing.T) {
+	stmts := splitSQL("-- 创建表;\nCREATE TABLE a (name TEXT DEFAULT 'x;y');\nINSERT INTO a VALUES ('1');\n")
+	if len(stmts) != 2 {
+		t.Fatalf("got %d statements: %q", len(stmts), stmts)
+	}
+	if stmts[0] != "CREATE TABLE a (name TEXT DEFAULT 'x;y')" {
+		t.Errorf("stmts[0] = %q", stmts[0])
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	fsys := fstest.MapFS{
+		"migrations/20240101000001_create_post.up.sql":   {Data: []byte("CREATE TABLE post (id INTEGER PRIMARY KEY, title TEXT);")},
+		"migrations/20240101000001_create_post.down.sql": {Data: []byte("DROP TABLE post;")},
+	}
+	RegisterSQLMigrations("blog", fsys, "migrations")
+	RegisterMigrations("blog", &Migration{
+		Version:     "20240101000002",
+		Description: "add post author",
+		Up: func(tx *gorm.DB) error {
+			return tx.Exec("ALTER TABLE post ADD COLUMN author TEXT").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Exec("ALTER TABLE post DROP COLUMN author").Error
+		},
+	})
+
+	db := openTestDB(t)
+	m := NewMigrator(db, nil)
+	ctx := context.Background()
+
+	executed, err := m.Migrate(ctx, 1)
+	if err != nil || len(executed) != 1 {
+		t.Fatalf("migrate step 1: %v, %d", err, len(executed))
+	}
+	if executed, err = m.Migrate(ctx, 0); err != nil || len(executed) != 1 {
+		t.Fatalf("migrate rest: %v, %d", err, len(executed))
+	}
+	if !db.Migrator().HasColumn("post", "author") {
+		t.Fatal("author column not created")
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 || !statuses[0].Applied || statuses[1].Batch != 2 {
+		t.Fatalf("unexpected status: %+v", statuses)
+	}
+
+	// 默认只回滚最后一批
+	rolledBack, err := m.Rollback(ctx, 0)
+	if err != nil || len(rolledBack) != 1 || rolledBack[0].Version != "20240101000002" {
+		t.Fatalf("rollback: %v, %v", err, rolledBack)
+	}
+	if db.Migrator().HasColumn("post", "author") {
+		t.Fatal("author column not dropped")
+	}
+
+	lock := &countingLock{migrationLock: m.lock}
+	m.lock = lock
+	if _, err = m.Fresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// 删除表和重新迁移之间不释放锁
+	if lock.locked != 1 || lock.unlocked != 1 {
+		t.Fatalf("fresh locked %d times, unlocked %d times", lock.locked, lock.unlocked)
+	}
+	var count int64
+	db.Model(&SchemaMigration{}).Count(&count)
+	if count != 2 {
+		t.Fatalf("fresh applied %d migrations", count)
+	}
+}
+
+type countingLock struct {
+	migrationLock
+	locked, unlocked int
+}
+
+func (i *countingLock) TryLock(ctx context.Context) error {
+	i.locked++
+	return i.migrationLock.TryLock(ctx)
+}
+
+func (i *countingLock) Unlock() {
+	i.unlocked++
+	i.migrationLock.Unlock()
+}
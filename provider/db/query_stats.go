@@ -0,0 +1,89 @@
+package db
+
+import (
+	"context"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// QueryStatsContextKey 请求上下文中保存数据库查询统计的键，访问日志据此输出查询次数和耗时
+const QueryStatsContextKey = "db_query_stats"
+
+// QueryStats 单个请求的数据库查询统计
+type QueryStats struct {
+	count    atomic.Int64
+	duration atomic.Int64
+	lock     sync.Mutex
+	shapes   map[string]int
+}
+
+func NewQueryStats() *QueryStats {
+	return &QueryStats{shapes: make(map[string]int)}
+}
+
+// Count 查询次数
+func (i *QueryStats) Count() int64 {
+	return i.count.Load()
+}
+
+// Duration 查询总耗时
+func (i *QueryStats) Duration() time.Duration {
+	return time.Duration(i.duration.Load())
+}
+
+// record 记录一次查询，返回相同形状的语句在本次请求中的执行次数
+func (i *QueryStats) record(sql string, elapsed time.Duration) (shape string, times int) {
+	i.count.Add(1)
+	i.duration.Add(int64(elapsed))
+
+	shape = sqlShape(sql)
+	i.lock.Lock()
+	i.shapes[shape]++
+	times = i.shapes[shape]
+	i.lock.Unlock()
+	return shape, times
+}
+
+// WithQueryStats 返回带查询统计的上下文，用于请求之外的场景，如队列任务
+func WithQueryStats(ctx context.Context) (context.Context, *QueryStats) {
+	stats := NewQueryStats()
+	return context.WithValue(ctx, QueryStatsContextKey, stats), stats
+}
+
+// QueryStatsFromContext 获取上下文中的查询统计，没有时返回 nil
+func QueryStatsFromContext(ctx context.Context) *QueryStats {
+	if ctx == nil {
+		return nil
+	}
+	stats, _ := ctx.Value(QueryStatsContextKey).(*QueryStats)
+	return stats
+}
+
+// QueryStatsMiddleware 为每个请求记录数据库查询次数和耗时
+func QueryStatsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, stats := WithQueryStats(c.Request.Context())
+		c.Set(QueryStatsContextKey, stats)
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+var (
+	sqlStringRegexp = regexp.MustCompile(`'(?:[^']|'')*'`)
+	sqlNumberRegexp = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	sqlListRegexp   = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	sqlSpaceRegexp  = regexp.MustCompile(`\s+`)
+)
+
+// sqlShape 将 SQL 中的字面量替换为 ?，用于识别同一语句的重复执行
+func sqlShape(sql string) string {
+	sql = sqlStringRegexp.ReplaceAllString(sql, "?")
+	sql = sqlNumberRegexp.ReplaceAllString(sql, "?")
+	sql = sqlListRegexp.ReplaceAllString(sql, "(?)")
+	return sqlSpaceRegexp.ReplaceAllString(sql, " ")
+}
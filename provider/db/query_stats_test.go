@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	logContract "bit-labs.cn/owl/contract/log"
+	"gorm.io/gorm/logger"
+)
+
+// warningLogger 只记录警告日志
+type warningLogger struct {
+	warnings []string
+}
+
+func (i *warningLogger) WithContext(ctx context.Context) logContract.Logger { return i }
+func (i *warningLogger) Emergency(content ...interface{})                   {}
+func (i *warningLogger) Alert(content ...interface{})                       {}
+func (i *warningLogger) Critical(content ...interface{})                    {}
+func (i *warningLogger) Error(content ...interface{})                       {}
+func (i *warningLogger) Notice(content ...interface{})                      {}
+func (i *warningLogger) Info(content ...interface{})                        {}
+func (i *warningLogger) Debug(content ...interface{})                       {}
+func (i *warningLogger) Warning(content ...interface{}) {
+	i.warnings = append(i.warnings, fmt.Sprint(content...))
+}
+
+func TestSqlShape(t *testing.T) {
+	a := sqlShape("SELECT * FROM `user` WHERE id = 12 AND name = 'o''neil' AND t1.role_id IN (1,2, 3)")
+	b := sqlShape("SELECT * FROM `user` WHERE id = 7 AND name = 'bob'  AND t1.role_id IN (4)")
+	if a != b {
+		t.Fatalf("shape mismatch:\n%s\n%s", a, b)
+	}
+	if want := "SELECT * FROM `user` WHERE id = ? AND name = ? AND t1.role_id IN (?)"; a != want {
+		t.Fatalf("shape = %s", a)
+	}
+}
+
+func TestOwlGormLogger_QueryStats(t *testing.T) {
+	l := &warningLogger{}
+	db := openTestDB(t)
+	db.Logger = NewOwlGormLogger(l).SetRepeatThreshold(2).SetSlowThreshold(time.Hour).LogMode(logger.Warn)
+	if err := db.AutoMigrate(&replicaPost{}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, stats := WithQueryStats(context.Background())
+	for id := 1; id <= 4; id++ {
+		var post replicaPost
+		db.WithContext(ctx).Where("id = ?", id).Find(&post)
+	}
+	db.WithContext(ctx).Create(&replicaPost{Title: "hello"})
+
+	if stats.Count() != 5 || stats.Duration() <= 0 {
+		t.Fatalf("count = %d, duration = %s", stats.Count(), stats.Duration())
+	}
+	if len(l.warnings) != 1 || !strings.Contains(l.warnings[0], "N+1") {
+		t.Fatalf("warnings = %q", l.warnings)
+	}
+}
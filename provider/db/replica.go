@@ -0,0 +1,154 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// newReplicaResolver 根据从库配置创建 dbresolver 插件
+func newReplicaResolver(opt *Options) (*dbresolver.DBResolver, error) {
+	var replicas []gorm.Dialector
+	for _, replica := range opt.ReplicaOptions() {
+		connector, err := NewConnector(replica)
+		if err != nil {
+			return nil, err
+		}
+		replicas = append(replicas, connector.Dialector())
+	}
+
+	return dbresolver.Register(dbresolver.Config{
+		Replicas: replicas,
+		Policy:   dbresolver.RandomPolicy{},
+	}).
+		SetMaxIdleConns(opt.MaxIdleConns).
+		SetMaxOpenConns(opt.MaxConns).
+		SetConnMaxLifetime(time.Hour), nil
+}
+
+// ReplicaOptions 返回从库的完整配置，从库未配置的字段继承主库
+func (i *Options) ReplicaOptions() []*Options {
+	result := make([]*Options, 0, len(i.Replicas))
+	for _, replica := range i.Replicas {
+		result = append(result, i.Inherit(replica))
+	}
+	return result
+}
+
+// Inherit 返回 override 中非零字段覆盖当前配置后的副本，不包含从库和命名连接
+func (i *Options) Inherit(override Options) *Options {
+	merged := *i
+	merged.Replicas = nil
+	merged.Connections = nil
+
+	src := reflect.ValueOf(override)
+	dst := reflect.ValueOf(&merged).Elem()
+	for idx := 0; idx < src.NumField(); idx++ {
+		name := src.Type().Field(idx).Name
+		if name == "Replicas" || name == "Connections" || src.Field(idx).IsZero() {
+			continue
+		}
+		dst.Field(idx).Set(src.Field(idx))
+	}
+	return &merged
+}
+
+// StickyContextKey 请求上下文中记录是否发生过写操作的键
+const StickyContextKey = "db_sticky"
+
+// stickyState 同一请求内发生写操作后，后续的读操作改为读取主库，避免主从延迟导致读不到刚写入的数据
+type stickyState struct {
+	wrote atomic.Bool
+}
+
+// WithSticky 返回开启写后读主库的上下文
+func WithSticky(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(StickyContextKey).(*stickyState); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, StickyContextKey, &stickyState{})
+}
+
+// StickyMiddleware 为每个请求开启写后读主库，仓库需要使用请求的上下文，如 repo.WithContext(ctx)
+func StickyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		state := &stickyState{}
+		c.Set(StickyContextKey, state)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), StickyContextKey, state))
+		c.Next()
+	}
+}
+
+// stickyEnabled 配置了从库时为 true，由 DBServiceProvider 创建 Manager 时设置
+var stickyEnabled atomic.Bool
+
+func stickyMiddleware() gin.HandlerFunc {
+	sticky := StickyMiddleware()
+	return func(c *gin.Context) {
+		if !stickyEnabled.Load() {
+			c.Next()
+			return
+		}
+		sticky(c)
+	}
+}
+
+// StickyPlugin 写后读主库插件，需要与 dbresolver 一起使用
+type StickyPlugin struct{}
+
+func (i *StickyPlugin) Name() string {
+	return "owl:sticky"
+}
+
+func (i *StickyPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().After("gorm:create").Register("owl:sticky_mark", markWrote); err != nil {
+		return err
+	}
+	if err := cb.Update().After("gorm:update").Register("owl:sticky_mark", markWrote); err != nil {
+		return err
+	}
+	if err := cb.Delete().After("gorm:delete").Register("owl:sticky_mark", markWrote); err != nil {
+		return err
+	}
+	if err := cb.Raw().After("gorm:raw").Register("owl:sticky_mark", markRawWrote); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("owl:sticky_read", readPrimaryAfterWrite); err != nil {
+		return err
+	}
+	return cb.Row().Before("gorm:row").Register("owl:sticky_read", readPrimaryAfterWrite)
+}
+
+func stickyFromContext(ctx context.Context) *stickyState {
+	if ctx == nil {
+		return nil
+	}
+	state, _ := ctx.Value(StickyContextKey).(*stickyState)
+	return state
+}
+
+func markWrote(db *gorm.DB) {
+	if state := stickyFromContext(db.Statement.Context); state != nil && db.Error == nil {
+		state.wrote.Store(true)
+	}
+}
+
+func markRawWrote(db *gorm.DB) {
+	if strings.HasPrefix(strings.ToLower(strings.TrimSpace(db.Statement.SQL.String())), "select") {
+		return
+	}
+	markWrote(db)
+}
+
+func readPrimaryAfterWrite(db *gorm.DB) {
+	if state := stickyFromContext(db.Statement.Context); state != nil && state.wrote.Load() {
+		dbresolver.Write.ModifyStatement(db.Statement)
+	}
+}
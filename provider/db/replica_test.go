@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+type replicaPost struct {
+	ID    uint `gorm:"primarykey"`
+	Title string
+}
+
+func TestOptions_ReplicaOptions(t *testing.T) {
+	opt := &Options{Driver: Mysql, Host: "primary", Port: 3306, Username: "root", Replicas: []Options{{Host: "replica"}}}
+	replicas := opt.ReplicaOptions()
+	if len(replicas) != 1 {
+		t.Fatalf("got %d replicas", len(replicas))
+	}
+	if r := replicas[0]; r.Host != "replica" || r.Port != 3306 || r.Username != "root" || r.Driver != Mysql || r.Replicas != nil {
+		t.Errorf("replica options = %+v", r)
+	}
+}
+
+func TestStickyPlugin(t *testing.T) {
+	dir := t.TempDir()
+	primary, replica := filepath.Join(dir, "primary.db"), filepath.Join(dir, "replica.db")
+
+	db, err := gorm.Open(sqlite.Open(primary), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 从库只建表不写数据，模拟主从延迟
+	replicaDB, err := gorm.Open(sqlite.Open(replica), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, conn := range []*gorm.DB{db, replicaDB} {
+		if err = conn.AutoMigrate(&replicaPost{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = db.Use(dbresolver.Register(dbresolver.Config{Replicas: []gorm.Dialector{sqlite.Open(replica)}})); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.Use(&StickyPlugin{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = db.Create(&replicaPost{Title: "hello"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var count int64
+	db.Model(&replicaPost{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("read without sticky context should go to replica, got %d rows", count)
+	}
+
+	ctx := WithSticky(context.Background())
+	var posts []replicaPost
+	db.WithContext(ctx).Find(&posts)
+	if len(posts) != 0 {
+		t.Fatalf("read before write should go to replica, got %d rows", len(posts))
+	}
+
+	if err = db.WithContext(ctx).Create(&replicaPost{Title: "world"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	db.WithContext(ctx).Find(&posts)
+	if len(posts) != 2 {
+		t.Fatalf("read after write should go to primary, got %d rows", len(posts))
+	}
+}
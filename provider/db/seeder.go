@@ -0,0 +1,26 @@
+package db
+
+import (
+	"bit-labs.cn/owl/contract"
+	"gorm.io/gorm"
+)
+
+var _ contract.Seeder = (*SeederFunc)(nil)
+
+// SeederFunc 使用函数实现的数据填充
+type SeederFunc struct {
+	name string
+	fn   func(db *gorm.DB) error
+}
+
+func NewSeeder(name string, fn func(db *gorm.DB) error) *SeederFunc {
+	return &SeederFunc{name: name, fn: fn}
+}
+
+func (i *SeederFunc) Name() string {
+	return i.name
+}
+
+func (i *SeederFunc) Run(db *gorm.DB) error {
+	return i.fn(db)
+}
@@ -0,0 +1,217 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// TxContextKey 上下文中保存当前事务的键
+const TxContextKey = "db_tx"
+
+// txScope 一层事务，嵌套事务使用保存点
+type txScope struct {
+	tx     *gorm.DB
+	parent *txScope
+	lock   sync.Mutex
+	hooks  []func()
+}
+
+var (
+	defaultDBLock sync.RWMutex
+	defaultDB     *gorm.DB
+
+	connectionSwitcherLock sync.RWMutex
+	connectionSwitcher     func(ctx context.Context) *gorm.DB
+)
+
+// SetConnectionSwitcher 根据上下文切换默认连接，如按租户切换数据库或 pgsql 模式，返回 nil 时不切换
+// 仓库、Tx 和使用 db.WithContext(ctx) 的查询都会使用切换后的连接，命名连接不受影响
+// 返回 Error 不为空的 *gorm.DB 时不切换，语句和事务返回该错误，如租户成员检查失败
+func SetConnectionSwitcher(switcher func(ctx context.Context) *gorm.DB) {
+	connectionSwitcherLock.Lock()
+	connectionSwitcher = switcher
+	connectionSwitcherLock.Unlock()
+}
+
+// switchConnection db 为默认连接时返回上下文对应的连接
+func switchConnection(ctx context.Context, db *gorm.DB) *gorm.DB {
+	connectionSwitcherLock.RLock()
+	switcher := connectionSwitcher
+	connectionSwitcherLock.RUnlock()
+	if switcher == nil || ctx == nil {
+		return db
+	}
+
+	defaultDBLock.RLock()
+	isDefault := defaultDB != nil && defaultDB.Config.ConnPool == db.Config.ConnPool
+	defaultDBLock.RUnlock()
+	if !isDefault {
+		return db
+	}
+	if conn := switcher(ctx); conn != nil {
+		if conn.Error != nil {
+			failed := db.Session(&gorm.Session{NewDB: true})
+			_ = failed.AddError(conn.Error)
+			return failed
+		}
+		return conn
+	}
+	return db
+}
+
+// setDefaultDB 设置 Tx 使用的默认连接，由连接管理器在创建默认连接时调用
+func setDefaultDB(db *gorm.DB) {
+	defaultDBLock.Lock()
+	defaultDB = db
+	defaultDBLock.Unlock()
+}
+
+// Tx 在默认连接的事务中执行 fn，事务保存在 fn 的上下文中
+// 使用该上下文的仓库和查询(db.WithContext(ctx))自动加入事务，fn 返回错误时回滚
+//
+//	err := db.Tx(ctx, func(ctx context.Context) error {
+//		if err := orderRepo.WithContext(ctx).Create(order); err != nil {
+//			return err
+//		}
+//		db.AfterCommit(ctx, func() { bus.Publish("order_created", order) })
+//		return stockRepo.WithContext(ctx).UpdateFields(id, fields)
+//	})
+func Tx(ctx context.Context, fn func(ctx context.Context) error) error {
+	defaultDBLock.RLock()
+	db := defaultDB
+	defaultDBLock.RUnlock()
+	if db == nil {
+		return errors.New("默认数据库连接未初始化")
+	}
+	return TxOn(ctx, db, fn)
+}
+
+// TxOn 在指定连接的事务中执行 fn，上下文中已有同一连接的事务时，使用保存点实现嵌套事务
+func TxOn(ctx context.Context, db *gorm.DB, fn func(ctx context.Context) error) error {
+	db = switchConnection(ctx, db)
+	if db.Error != nil {
+		return db.Error
+	}
+	parent := scopeFromContext(ctx, db)
+	base := FromContext(ctx, db)
+
+	scope := &txScope{parent: parent}
+	err := base.Transaction(func(tx *gorm.DB) error {
+		scope.tx = tx
+		return fn(context.WithValue(ctx, TxContextKey, scope))
+	})
+	if err != nil {
+		return err
+	}
+
+	// 嵌套事务提交后，回调交给外层事务，外层事务提交后才执行
+	if parent != nil {
+		parent.addHooks(scope.hooks...)
+		return nil
+	}
+	for _, hook := range scope.hooks {
+		hook()
+	}
+	return nil
+}
+
+// AfterCommit 注册事务提交后执行的回调，事务回滚时不执行，不在事务中时立即执行
+func AfterCommit(ctx context.Context, fn func()) {
+	if ctx != nil {
+		if scope, ok := ctx.Value(TxContextKey).(*txScope); ok {
+			scope.addHooks(fn)
+			return
+		}
+	}
+	fn()
+}
+
+// FromContext 返回上下文中与 db 同一连接的事务，没有事务时返回 db.WithContext(ctx)
+// 手写查询使用该方法可以加入 Tx 开启的事务
+func FromContext(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	db = switchConnection(ctx, db)
+	if scope := scopeFromContext(ctx, db); scope != nil {
+		return scope.tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// InTx 判断上下文是否处于事务中
+func InTx(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	_, ok := ctx.Value(TxContextKey).(*txScope)
+	return ok
+}
+
+func scopeFromContext(ctx context.Context, db *gorm.DB) *txScope {
+	if ctx == nil {
+		return nil
+	}
+	scope, ok := ctx.Value(TxContextKey).(*txScope)
+	// 同一次 gorm.Open 创建的会话共享同一个连接池，据此判断是否为同一连接
+	if !ok || scope.tx == nil || scope.tx.Config.ConnPool != db.Config.ConnPool {
+		return nil
+	}
+	return scope
+}
+
+func (i *txScope) addHooks(hooks ...func()) {
+	i.lock.Lock()
+	i.hooks = append(i.hooks, hooks...)
+	i.lock.Unlock()
+}
+
+// TxPlugin 使用 db.WithContext(ctx) 的查询自动加入上下文中的事务，并按 SetConnectionSwitcher 切换连接
+type TxPlugin struct{}
+
+func (i *TxPlugin) Name() string {
+	return "owl:tx"
+}
+
+func (i *TxPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("*").Register("owl:tx", joinContextTx); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("*").Register("owl:tx", joinContextTx); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("*").Register("owl:tx", joinContextTx); err != nil {
+		return err
+	}
+	if err := cb.Delete().Before("*").Register("owl:tx", joinContextTx); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("*").Register("owl:tx", joinContextTx); err != nil {
+		return err
+	}
+	return cb.Raw().Before("*").Register("owl:tx", joinContextTx)
+}
+
+// joinContextTx 语句不在事务中且上下文中有同一连接的事务时，切换到事务连接
+func joinContextTx(db *gorm.DB) {
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok {
+		return
+	}
+	ctx := db.Statement.Context
+	conn := switchConnection(ctx, db)
+	if conn.Error != nil {
+		_ = db.AddError(conn.Error)
+		return
+	}
+	if scope := scopeFromContext(ctx, conn); scope != nil {
+		db.Statement.ConnPool = scope.tx.Statement.ConnPool
+		return
+	}
+	if conn != db {
+		db.Statement.ConnPool = conn.Config.ConnPool
+	}
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTx(t *testing.T) {
+	repo := newArticleRepo(t)
+	conn := repo.db
+	if err := conn.Use(&TxPlugin{}); err != nil {
+		t.Fatal(err)
+	}
+	ctx := repo.ctx
+
+	var committed []string
+	errRollback := errors.New("rollback")
+	err := TxOn(ctx, conn, func(ctx context.Context) error {
+		if err := repo.WithContext(ctx).Create(&repoArticle{Code: "outer"}); err != nil {
+			return err
+		}
+		AfterCommit(ctx, func() { committed = append(committed, "outer") })
+
+		// 手写查询通过上下文加入事务，可以读到未提交的数据
+		var count int64
+		if err := conn.WithContext(ctx).Model(&repoArticle{}).Where("code = ?", "outer").Count(&count).Error; err != nil {
+			return err
+		}
+		if count != 1 {
+			t.Errorf("query in tx count = %d", count)
+		}
+
+		// 嵌套事务回滚到保存点，回调不执行
+		err := TxOn(ctx, conn, func(ctx context.Context) error {
+			if err := repo.WithContext(ctx).Create(&repoArticle{Code: "inner"}); err != nil {
+				return err
+			}
+			AfterCommit(ctx, func() { committed = append(committed, "inner") })
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			t.Errorf("nested err = %v", err)
+		}
+
+		if len(committed) != 0 {
+			t.Errorf("hooks run before commit: %v", committed)
+		}
+		return repo.WithContext(ctx).Transaction(func(tx *BaseRepository[repoArticle]) error {
+			AfterCommit(ctx, func() { committed = append(committed, "repo") })
+			return tx.Create(&repoArticle{Code: "repo"})
+		})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, list, err := repo.Retrieve(1, 10, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || list[0].Code != "outer" || list[1].Code != "repo" {
+		t.Fatalf("list = %+v", list)
+	}
+	if len(committed) != 2 || committed[0] != "outer" || committed[1] != "repo" {
+		t.Fatalf("committed = %v", committed)
+	}
+
+	err = TxOn(ctx, conn, func(ctx context.Context) error {
+		if err := repo.WithContext(ctx).Create(&repoArticle{Code: "failed"}); err != nil {
+			return err
+		}
+		AfterCommit(ctx, func() { committed = append(committed, "failed") })
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("err = %v", err)
+	}
+	if c, _, _ := repo.Retrieve(1, 10, nil); c != 2 || len(committed) != 2 {
+		t.Fatalf("rollback count = %d, committed = %v", c, committed)
+	}
+}
@@ -0,0 +1,215 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Version 乐观锁版本号，模型中声明该类型的字段即启用乐观锁
+//
+//	type Article struct {
+//		db.BaseModel
+//		Title   string
+//		Version db.Version `json:"version"`
+//	}
+//
+// 更新时版本号不为 0 则只更新版本号一致的数据，并将版本号加 1；不一致时返回 *VersionConflictError
+// 版本号为 0 时不检查，版本号在数据库中加 1
+type Version uint64
+
+// ErrVersionConflict 数据已被修改，可以用 errors.Is 判断
+var ErrVersionConflict = errors.New("数据已被其他人修改，请刷新后重试")
+
+// VersionConflictError 乐观锁冲突，实现 HTTPStatus，router 的响应方法返回 409
+type VersionConflictError struct {
+	Table   string
+	Version Version
+}
+
+func (e *VersionConflictError) Error() string {
+	return ErrVersionConflict.Error()
+}
+
+func (e *VersionConflictError) Unwrap() error {
+	return ErrVersionConflict
+}
+
+func (e *VersionConflictError) HTTPStatus() int {
+	return http.StatusConflict
+}
+
+const (
+	versionCheckedKey   = "owl:version_checked"
+	versionIncrementKey = "owl:version_increment"
+)
+
+var (
+	versionType       = reflect.TypeOf(Version(0))
+	versionFieldCache sync.Map // reflect.Type -> *schema.Field
+)
+
+// OptimisticLockPlugin 乐观锁插件，处理 Version 类型的字段
+type OptimisticLockPlugin struct{}
+
+func (i *OptimisticLockPlugin) Name() string {
+	return "owl:optimistic_lock"
+}
+
+func (i *OptimisticLockPlugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	if err := cb.Create().Before("gorm:create").Register("owl:version", initVersion); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("owl:version_check", checkVersion); err != nil {
+		return err
+	}
+	// 在默认事务提交之前执行，递增版本号失败时回滚
+	return cb.Update().After("gorm:update").Before("gorm:commit_or_rollback_transaction").
+		Register("owl:version_result", versionResult)
+}
+
+// initVersion 创建时版本号为 0 则设为 1
+func initVersion(db *gorm.DB) {
+	field := versionField(db.Statement.Schema)
+	if field == nil || db.Error != nil {
+		return
+	}
+	ctx := db.Statement.Context
+	setIfZero := func(v reflect.Value) {
+		if _, zero := field.ValueOf(ctx, v); zero {
+			_ = field.Set(ctx, v, Version(1))
+		}
+	}
+	switch rv := reflect.Indirect(db.Statement.ReflectValue); rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for n := 0; n < rv.Len(); n++ {
+			setIfZero(reflect.Indirect(rv.Index(n)))
+		}
+	case reflect.Struct:
+		setIfZero(rv)
+	}
+}
+
+func checkVersion(db *gorm.DB) {
+	field := versionField(db.Statement.Schema)
+	if field == nil || db.Error != nil {
+		return
+	}
+	stmt := db.Statement
+	column := clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+	increment := clause.Expr{SQL: "? + 1", Vars: []any{clause.Column{Name: field.DBName}}}
+
+	var version Version
+	dest, isMap := stmt.Dest.(map[string]any)
+	if isMap {
+		for _, key := range []string{field.DBName, field.Name} {
+			if value, ok := dest[key]; ok {
+				version = toVersion(value)
+				delete(dest, key)
+			}
+		}
+	} else if rv := reflect.Indirect(stmt.ReflectValue); rv.Kind() == reflect.Struct {
+		value, _ := field.ValueOf(stmt.Context, rv)
+		version = toVersion(value)
+	}
+
+	if version == 0 {
+		if isMap {
+			dest[field.DBName] = increment
+			return
+		}
+		// 结构体无法写入表达式，更新后再递增
+		stmt.Omits = append(stmt.Omits, field.DBName)
+		stmt.Settings.Store(versionIncrementKey, true)
+		return
+	}
+
+	AndWhere(stmt, clause.Eq{Column: column, Value: version})
+	stmt.SetColumn(field.DBName, version+1, true)
+	stmt.Settings.Store(versionCheckedKey, version)
+}
+
+func versionResult(db *gorm.DB) {
+	stmt := db.Statement
+	checked, hasChecked := stmt.Settings.LoadAndDelete(versionCheckedKey)
+	_, hasIncrement := stmt.Settings.LoadAndDelete(versionIncrementKey)
+	field := versionField(stmt.Schema)
+	if field == nil || db.Error != nil || stmt.DryRun {
+		return
+	}
+
+	if hasChecked && db.RowsAffected == 0 {
+		version := checked.(Version)
+		// 恢复模型中的版本号，调用方可以据此重新加载
+		if rv := reflect.Indirect(stmt.ReflectValue); rv.Kind() == reflect.Struct {
+			_ = field.Set(stmt.Context, rv, version)
+		}
+		_ = db.AddError(&VersionConflictError{Table: stmt.Table, Version: version})
+		return
+	}
+
+	if hasIncrement && db.RowsAffected > 0 {
+		where, ok := stmt.Clauses["WHERE"]
+		if !ok {
+			return
+		}
+		tx := db.Session(&gorm.Session{NewDB: true, SkipHooks: true}).Table(stmt.Table)
+		tx.Statement.AddClause(where.Expression.(clause.Where))
+		err := tx.UpdateColumn(field.DBName, clause.Expr{SQL: "? + 1", Vars: []any{clause.Column{Name: field.DBName}}}).Error
+		if err != nil {
+			_ = db.AddError(fmt.Errorf("更新版本号失败: %w", err))
+		}
+	}
+}
+
+func versionField(s *schema.Schema) *schema.Field {
+	if s == nil {
+		return nil
+	}
+	if field, ok := versionFieldCache.Load(s.ModelType); ok {
+		return field.(*schema.Field)
+	}
+
+	var found *schema.Field
+	for _, field := range s.Fields {
+		if field.FieldType == versionType && field.DBName != "" {
+			found = field
+			break
+		}
+	}
+	versionFieldCache.Store(s.ModelType, found)
+	return found
+}
+
+func toVersion(value any) Version {
+	switch v := value.(type) {
+	case Version:
+		return v
+	case *Version:
+		if v != nil {
+			return *v
+		}
+	default:
+		rv := reflect.Indirect(reflect.ValueOf(value))
+		switch rv.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if rv.Int() > 0 {
+				return Version(rv.Int())
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			return Version(rv.Uint())
+		case reflect.Float32, reflect.Float64:
+			if rv.Float() > 0 {
+				return Version(rv.Float())
+			}
+		}
+	}
+	return 0
+}
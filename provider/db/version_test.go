@@ -0,0 +1,107 @@
+package db
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+type versionedPost struct {
+	BaseModel
+	Title   string
+	Content string
+	Version Version
+}
+
+func (i *versionedPost) HistoryIgnoreColumns() []string {
+	return []string{"content"}
+}
+
+func TestOptimisticLockAndHistory(t *testing.T) {
+	articles := newArticleRepo(t)
+	conn := articles.db
+	if err := conn.Use(&OptimisticLockPlugin{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Use(&HistoryPlugin{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := HistoryMigration("1").Up(conn); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.AutoMigrate(&versionedPost{}); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewBaseRepository[versionedPost](conn)
+	posts := repo.WithContext(articles.ctx)
+
+	post := &versionedPost{Title: "v1", Content: "c1"}
+	if err := posts.Create(post); err != nil {
+		t.Fatal(err)
+	}
+	if post.Version != 1 {
+		t.Fatalf("version after create = %d", post.Version)
+	}
+
+	// 两个管理员读取同一版本
+	first, _ := posts.Detail(post.ID)
+	second, _ := posts.Detail(post.ID)
+
+	first.Title, first.Content = "v2", "c2"
+	if err := posts.Update(first); err != nil {
+		t.Fatal(err)
+	}
+	if first.Version != 2 {
+		t.Fatalf("version after update = %d", first.Version)
+	}
+
+	second.Title = "stale"
+	err := posts.Update(second)
+	var conflict *VersionConflictError
+	if !errors.Is(err, ErrVersionConflict) || !errors.As(err, &conflict) || conflict.HTTPStatus() != http.StatusConflict {
+		t.Fatalf("err = %v", err)
+	}
+	if second.Version != 1 {
+		t.Fatalf("version after conflict = %d", second.Version)
+	}
+
+	// 不带版本号的更新不检查，但版本号递增
+	if err = posts.UpdateFields(post.ID, map[string]any{"title": "v3"}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := posts.Detail(post.ID)
+	if got.Title != "v3" || got.Version != 3 {
+		t.Fatalf("after map update = %s/%d", got.Title, got.Version)
+	}
+
+	got.Version = 0
+	got.Title = "v4"
+	if err = posts.Save(got); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ = posts.Detail(post.ID); got.Title != "v4" || got.Version != 4 {
+		t.Fatalf("after save without version = %s/%d", got.Title, got.Version)
+	}
+
+	var histories []ModelHistory
+	conn.Order("id").Find(&histories)
+	// 按条件更新(UpdateFields)同样记录历史
+	if len(histories) != 3 {
+		t.Fatalf("histories = %+v", histories)
+	}
+	if diff, _ := histories[1].Diff(); histories[1].RecordID != strconv.FormatUint(uint64(post.ID), 10) || diff["title"].Old != "v2" || diff["title"].New != "v3" {
+		t.Fatalf("map update history = %+v", histories[1])
+	}
+	h := histories[0]
+	diff, err := h.Diff()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Table != "versioned_posts" || h.OperatorID != "1001" || h.OperatorName != "admin" || len(diff) != 1 {
+		t.Fatalf("history = %+v", h)
+	}
+	if change := diff["title"]; change.Old != "v1" || change.New != "v2" {
+		t.Fatalf("title change = %+v", change)
+	}
+}
@@ -20,7 +20,7 @@ func (i *LogServiceProvider) Description() string {
 }
 
 type option struct {
-	Level      int    `json:"level"`
+	Level      int    `json:"level" validate:"min=-1,max=5"`
 	FileName   string `json:"file-name"`
 	MaxSize    int    `json:"max-size"`
 	MaxBackups int    `json:"max-backups"`
@@ -59,3 +59,9 @@ func (i *LogServiceProvider) Conf() map[string]string {
 		"log.yaml": logConf,
 	}
 }
+
+func (i *LogServiceProvider) ConfSchema() map[string]any {
+	return map[string]any{
+		"log.yaml": &option{},
+	}
+}
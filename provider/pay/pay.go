@@ -8,7 +8,7 @@ import (
 )
 
 type Options struct {
-	Default  string            `json:"default"`
+	Default  string            `json:"default" validate:"omitempty,oneof=alipay wechat card"`
 	Alipay   payc.AlipayConfig `json:"alipay"`
 	Wechat   payc.WechatConfig `json:"wechat"`
 	Card     payc.CardConfig   `json:"card"`
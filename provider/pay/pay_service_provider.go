@@ -2,12 +2,17 @@ package pay
 
 import (
 	_ "embed"
+	"errors"
+	"net/http"
 	"time"
 
 	"bit-labs.cn/owl"
 	"bit-labs.cn/owl/contract/foundation"
+	payc "bit-labs.cn/owl/contract/pay"
 	"bit-labs.cn/owl/provider/conf"
 	"bit-labs.cn/owl/provider/pay/impl"
+	"bit-labs.cn/owl/provider/router"
+	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
@@ -22,6 +27,8 @@ func (p *PayServiceProvider) Description() string {
 }
 
 func (p *PayServiceProvider) Register() {
+	router.RegisterErrorMapper(mapPaymentError)
+
 	p.app.Register(func(c *conf.Configure) *PaymentManager {
 		var opt Options
 		err := c.GetConfig("pay", &opt)
@@ -64,6 +71,23 @@ func (p *PayServiceProvider) Register() {
 	})
 }
 
+// mapPaymentError 支付错误不返回渠道的原始信息，渠道错误码放在 details 中
+func mapPaymentError(err error) *router.AppError {
+	var pe *payc.PaymentError
+	if !errors.As(err, &pe) {
+		return nil
+	}
+	status := http.StatusBadRequest
+	switch {
+	case pe.Code == "NotImplemented":
+		status = http.StatusNotImplemented
+	case pe.Retryable:
+		status = http.StatusServiceUnavailable
+	}
+	appErr := router.NewError(status, "payment_failed", "支付失败").WithCause(err)
+	return appErr.WithDetails(gin.H{"code": pe.Code, "retryable": pe.Retryable})
+}
+
 func (p *PayServiceProvider) Boot() {
 	_ = p.app.Invoke(func(c *conf.Configure, db *gorm.DB) {
 		var opt Options
@@ -85,3 +109,9 @@ func (p *PayServiceProvider) Conf() map[string]string {
 		"pay.yaml": payYaml,
 	}
 }
+
+func (p *PayServiceProvider) ConfSchema() map[string]any {
+	return map[string]any{
+		"pay.yaml": &Options{},
+	}
+}
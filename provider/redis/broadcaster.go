@@ -0,0 +1,52 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+
+	"bit-labs.cn/owl/provider/router"
+	"github.com/redis/go-redis/v9"
+)
+
+var _ router.Broadcaster = (*Broadcaster)(nil)
+
+// Broadcaster 通过 Redis 发布订阅转发实时消息，每个副本订阅同一频道，收到消息后投递到本副本的连接
+type Broadcaster struct {
+	client  redis.UniversalClient
+	channel string
+}
+
+func NewBroadcaster(client redis.UniversalClient, channel string) *Broadcaster {
+	return &Broadcaster{client: client, channel: channel}
+}
+
+func (b *Broadcaster) Publish(ctx context.Context, msg router.Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, b.channel, data).Err()
+}
+
+// Run 订阅频道并投递消息，连接断开时自动重新订阅，ctx 取消时退出
+func (b *Broadcaster) Run(ctx context.Context) {
+	sub := b.client.Subscribe(ctx, b.channel)
+	defer sub.Close()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m, ok := <-ch:
+			if !ok {
+				return
+			}
+			var msg router.Message
+			if err := json.Unmarshal([]byte(m.Payload), &msg); err != nil {
+				continue
+			}
+			router.Deliver(msg)
+		}
+	}
+}
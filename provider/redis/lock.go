@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	redsync "github.com/go-redsync/redsync/v4"
@@ -57,3 +58,12 @@ func (l *redisLocker) Unlock() {
 		l.mutex = nil
 	}
 }
+
+// Extend 延长锁的过期时间，长时间持有锁时需要定期调用
+func (l *redisLocker) Extend() error {
+	if l.mutex == nil {
+		return errors.New("未持有锁")
+	}
+	_, err := l.mutex.Extend()
+	return err
+}
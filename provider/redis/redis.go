@@ -10,13 +10,20 @@ import (
 
 // Options Redis 配置选项
 type Options struct {
-	Mode       string           `yaml:"mode"`       // single 或 cluster
-	Single     SingleConfig     `yaml:"single"`     // 单机配置
-	Cluster    ClusterConfig    `yaml:"cluster"`    // 集群配置
-	Pool       PoolConfig       `yaml:"pool"`       // 连接池配置
-	Timeout    TimeoutConfig    `yaml:"timeout"`    // 超时配置
-	Connection ConnectionConfig `yaml:"connection"` // 连接管理配置
-	Reconnect  ReconnectConfig  `yaml:"reconnect"`  // 自动重连配置
+	Mode       string           `yaml:"mode"`                       // single 或 cluster
+	Single     SingleConfig     `yaml:"single"`                     // 单机配置
+	Cluster    ClusterConfig    `yaml:"cluster"`                    // 集群配置
+	Pool       PoolConfig       `yaml:"pool"`                       // 连接池配置
+	Timeout    TimeoutConfig    `yaml:"timeout"`                    // 超时配置
+	Connection ConnectionConfig `yaml:"connection"`                 // 连接管理配置
+	Reconnect  ReconnectConfig  `yaml:"reconnect"`                  // 自动重连配置
+	Broadcast  BroadcastConfig  `yaml:"broadcast" json:"broadcast"` // 实时消息广播配置
+}
+
+// BroadcastConfig 实时消息广播配置，开启后 SSE、WebSocket 的消息通过 Redis 发布订阅推送到所有副本
+type BroadcastConfig struct {
+	Enabled bool   `yaml:"enabled" json:"enabled"`
+	Channel string `yaml:"channel" json:"channel"`
 }
 
 // SingleConfig 单机模式配置
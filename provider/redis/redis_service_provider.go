@@ -1,12 +1,14 @@
 package redis
 
 import (
+	"context"
 	_ "embed"
 
 	"bit-labs.cn/owl/provider/conf"
 
 	"bit-labs.cn/owl"
 	"bit-labs.cn/owl/contract/foundation"
+	"bit-labs.cn/owl/provider/router"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -34,7 +36,23 @@ func (r *RedisServiceProvider) Register() {
 }
 
 func (r *RedisServiceProvider) Boot() {
-	// Redis 服务启动时的初始化逻辑
+	err := r.app.Invoke(func(c *conf.Configure) error {
+		var opt Options
+		if err := c.GetConfig("redis", &opt); err != nil || !opt.Broadcast.Enabled {
+			return err
+		}
+		channel := opt.Broadcast.Channel
+		if channel == "" {
+			channel = "owl:broadcast"
+		}
+		// 多副本之间通过 Redis 转发 SSE、WebSocket 消息
+		return r.app.Invoke(func(client redis.UniversalClient) {
+			b := NewBroadcaster(client, channel)
+			go b.Run(context.Background())
+			router.SetBroadcaster(b)
+		})
+	})
+	owl.PanicIf(err)
 }
 
 //go:embed redis.yaml
@@ -0,0 +1,273 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+)
+
+// CrudRepository 通用增删改查使用的仓库，*db.BaseRepository 实现了该接口
+type CrudRepository[T any] interface {
+	Create(data *T) error
+	Update(data *T) error
+	Delete(ids ...any) error
+	Detail(id any) (*T, error)
+	RetrieveByFilter(page, pageSize int, filter any) (count int64, list []T, err error)
+}
+
+// ContextRepository 可以绑定请求上下文的仓库，请求中的事务、数据权限、租户等通过上下文传递
+type ContextRepository[R any] interface {
+	WithContext(ctx context.Context) R
+}
+
+var _ CrudHandler = (*Crud[struct{}, struct{}])(nil)
+
+// Crud 通用增删改查控制器，T 为模型，F 为查询条件结构体，标签规则见 db.ApplyFilter，分页参数 page、pageSize 单独绑定
+//
+//	crud := router.NewCrud[User, UserFilter](repo).
+//		CreateFields("name", "age").
+//		BeforeCreate(func(ctx *gin.Context, data *User) error { ... })
+//	builder.Crud("/users", crud)
+type Crud[T any, F any] struct {
+	repo func(ctx context.Context) CrudRepository[T]
+
+	createFields []string
+	updateFields []string
+
+	beforeCreate func(ctx *gin.Context, data *T) error
+	afterCreate  func(ctx *gin.Context, data *T)
+	beforeUpdate func(ctx *gin.Context, data *T) error
+	afterUpdate  func(ctx *gin.Context, data *T)
+	beforeDelete func(ctx *gin.Context, id string) error
+	afterDelete  func(ctx *gin.Context, id string)
+}
+
+// NewCrud 创建通用增删改查控制器，repo 通常为 *db.BaseRepository[T] 或嵌入它的仓库
+func NewCrud[T any, F any, R CrudRepository[T]](repo ContextRepository[R]) *Crud[T, F] {
+	return &Crud[T, F]{
+		repo: func(ctx context.Context) CrudRepository[T] {
+			return repo.WithContext(ctx)
+		},
+	}
+}
+
+// protectedFields 未设置允许提交的字段时忽略的字段，即 db.BaseModel 中由系统维护的列
+var protectedFields = map[string]bool{
+	"id": true, "createdAt": true, "updatedAt": true, "deletedAt": true,
+	"creatorID": true, "creatorName": true, "updaterID": true, "updaterName": true,
+}
+
+// CreateFields 创建时允许客户端提交的字段，使用 json 名称，未设置时允许除 db.BaseModel 之外的字段
+func (i *Crud[T, F]) CreateFields(fields ...string) *Crud[T, F] {
+	i.createFields = fields
+	return i
+}
+
+// UpdateFields 修改时允许客户端提交的字段，使用 json 名称，未设置时允许除 db.BaseModel 之外的字段
+// 模型使用乐观锁时需要包含版本号字段
+func (i *Crud[T, F]) UpdateFields(fields ...string) *Crud[T, F] {
+	i.updateFields = fields
+	return i
+}
+
+// BeforeCreate 创建之前执行，返回错误时中止创建
+func (i *Crud[T, F]) BeforeCreate(fn func(ctx *gin.Context, data *T) error) *Crud[T, F] {
+	i.beforeCreate = fn
+	return i
+}
+
+// AfterCreate 创建成功之后执行
+func (i *Crud[T, F]) AfterCreate(fn func(ctx *gin.Context, data *T)) *Crud[T, F] {
+	i.afterCreate = fn
+	return i
+}
+
+// BeforeUpdate 修改之前执行，返回错误时中止修改
+func (i *Crud[T, F]) BeforeUpdate(fn func(ctx *gin.Context, data *T) error) *Crud[T, F] {
+	i.beforeUpdate = fn
+	return i
+}
+
+// AfterUpdate 修改成功之后执行
+func (i *Crud[T, F]) AfterUpdate(fn func(ctx *gin.Context, data *T)) *Crud[T, F] {
+	i.afterUpdate = fn
+	return i
+}
+
+// BeforeDelete 删除之前执行，返回错误时中止删除
+func (i *Crud[T, F]) BeforeDelete(fn func(ctx *gin.Context, id string) error) *Crud[T, F] {
+	i.beforeDelete = fn
+	return i
+}
+
+// AfterDelete 删除成功之后执行
+func (i *Crud[T, F]) AfterDelete(fn func(ctx *gin.Context, id string)) *Crud[T, F] {
+	i.afterDelete = fn
+	return i
+}
+
+func (i *Crud[T, F]) Create(ctx *gin.Context) {
+	data, ok := i.bind(ctx, i.createFields)
+	if !ok {
+		return
+	}
+	if i.beforeCreate != nil {
+		if err := i.beforeCreate(ctx, data); err != nil {
+			Fail(ctx, err)
+			return
+		}
+	}
+	if err := i.repo(ctx).Create(data); err != nil {
+		Fail(ctx, err)
+		return
+	}
+	if i.afterCreate != nil {
+		i.afterCreate(ctx, data)
+	}
+	Success(ctx, data)
+}
+
+func (i *Crud[T, F]) Update(ctx *gin.Context) {
+	data, ok := i.bind(ctx, i.updateFields)
+	if !ok {
+		return
+	}
+	if err := setPrimaryKey(data, ctx.Param("id")); err != nil {
+		Fail(ctx, ErrBadRequest.WithCause(err))
+		return
+	}
+	repo := i.repo(ctx)
+	// 修改不存在的记录时不会返回错误，先查询一次，记录不存在时返回 404
+	if _, err := repo.Detail(ctx.Param("id")); err != nil {
+		Fail(ctx, err)
+		return
+	}
+	if i.beforeUpdate != nil {
+		if err := i.beforeUpdate(ctx, data); err != nil {
+			Fail(ctx, err)
+			return
+		}
+	}
+	if err := repo.Update(data); err != nil {
+		Fail(ctx, err)
+		return
+	}
+	if i.afterUpdate != nil {
+		i.afterUpdate(ctx, data)
+	}
+	Success(ctx, data)
+}
+
+func (i *Crud[T, F]) Delete(ctx *gin.Context) {
+	id := ctx.Param("id")
+	if i.beforeDelete != nil {
+		if err := i.beforeDelete(ctx, id); err != nil {
+			Fail(ctx, err)
+			return
+		}
+	}
+	if err := i.repo(ctx).Delete(id); err != nil {
+		Fail(ctx, err)
+		return
+	}
+	if i.afterDelete != nil {
+		i.afterDelete(ctx, id)
+	}
+	Success(ctx, nil)
+}
+
+func (i *Crud[T, F]) Retrieve(ctx *gin.Context) {
+	var filter F
+	if err := ctx.ShouldBindQuery(&filter); err != nil {
+		Fail(ctx, BindError(err))
+		return
+	}
+	var page PageReq
+	_ = ctx.ShouldBindQuery(&page)
+
+	count, list, err := i.repo(ctx).RetrieveByFilter(page.Page, page.PageSize, &filter)
+	if err != nil {
+		Fail(ctx, err)
+		return
+	}
+	PageSuccess(ctx, int(count), page.Page, page.PageSize, list)
+}
+
+func (i *Crud[T, F]) Detail(ctx *gin.Context) {
+	data, err := i.repo(ctx).Detail(ctx.Param("id"))
+	if err != nil {
+		Fail(ctx, err)
+		return
+	}
+	Success(ctx, data)
+}
+
+// bind 绑定并校验请求体，只保留允许提交的字段，fields 为空时忽略 protectedFields
+func (i *Crud[T, F]) bind(ctx *gin.Context, fields []string) (*T, bool) {
+	data := new(T)
+	if err := ctx.ShouldBindJSON(data); err != nil {
+		Fail(ctx, BindError(err))
+		return nil, false
+	}
+	allow := func(name string) bool { return !protectedFields[name] }
+	if len(fields) > 0 {
+		allow = func(name string) bool { return slices.Contains(fields, name) }
+	}
+	allowed := new(T)
+	copyFields(reflect.ValueOf(allowed).Elem(), reflect.ValueOf(data).Elem(), allow)
+	return allowed, true
+}
+
+// copyFields 按 json 名称复制允许提交的字段，嵌入的结构体递归处理
+func copyFields(dst, src reflect.Value, allow func(name string) bool) {
+	t := src.Type()
+	for n := 0; n < t.NumField(); n++ {
+		field := t.Field(n)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			copyFields(dst.Field(n), src.Field(n), allow)
+			continue
+		}
+		name := field.Name
+		if tag := strings.Split(field.Tag.Get("json"), ",")[0]; tag != "" && tag != "-" {
+			name = tag
+		}
+		if allow(name) {
+			dst.Field(n).Set(src.Field(n))
+		}
+	}
+}
+
+// setPrimaryKey 使用路径参数设置模型的 ID 字段
+func setPrimaryKey(data any, id string) error {
+	field := reflect.ValueOf(data).Elem().FieldByName("ID")
+	if !field.IsValid() || !field.CanSet() {
+		return errors.New("模型缺少 ID 字段")
+	}
+	switch field.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := cast.ToUint64E(id)
+		if err != nil || v == 0 {
+			return errors.New("ID 不合法")
+		}
+		field.SetUint(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := cast.ToInt64E(id)
+		if err != nil || v == 0 {
+			return errors.New("ID 不合法")
+		}
+		field.SetInt(v)
+	case reflect.String:
+		field.SetString(id)
+	default:
+		return errors.New("不支持的 ID 类型")
+	}
+	return nil
+}
@@ -0,0 +1,347 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	logContract "bit-labs.cn/owl/contract/log"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// 通用错误码，业务模块可以定义自己的错误码，如 "order.paid"
+const (
+	CodeOK              = "ok"
+	CodeBadRequest      = "bad_request"
+	CodeUnauthorized    = "unauthorized"
+	CodeForbidden       = "forbidden"
+	CodeNotFound        = "not_found"
+	CodeConflict        = "conflict"
+	CodeValidation      = "validation_failed"
+	CodeTooManyRequests = "too_many_requests"
+	CodeInternal        = "internal_error"
+	CodePayloadTooLarge = "payload_too_large"
+	CodeRequestTimeout  = "request_timeout"
+	CodeTimeout         = "timeout"
+)
+
+// AppError 应用错误，handler 中通过 ctx.Error(err) 或 Fail 返回，由 ErrorHandler 转换为统一的响应
+//
+//	var ErrOrderPaid = router.NewError(http.StatusConflict, "order.paid", "订单已支付").WithKey("order.paid")
+//	return ErrOrderPaid.WithDetails(gin.H{"orderNo": no})
+type AppError struct {
+	Status     int    // HTTP 状态码
+	Code       string // 业务错误码
+	Message    string // 默认提示信息，没有对应的翻译时使用
+	MessageKey string // 国际化消息键
+	Details    any    // 错误详情，如字段校验错误
+	Cause      error  // 原始错误，只记录日志，调试模式下返回给客户端
+}
+
+var (
+	ErrBadRequest      = NewError(http.StatusBadRequest, CodeBadRequest, "请求参数错误")
+	ErrUnauthorized    = NewError(http.StatusUnauthorized, CodeUnauthorized, "请先登录")
+	ErrForbidden       = NewError(http.StatusForbidden, CodeForbidden, "没有访问权限")
+	ErrNotFound        = NewError(http.StatusNotFound, CodeNotFound, "数据不存在")
+	ErrConflict        = NewError(http.StatusConflict, CodeConflict, "数据已被修改，请刷新后重试")
+	ErrValidation      = NewError(http.StatusBadRequest, CodeValidation, "参数校验失败")
+	ErrTooManyRequests = NewError(http.StatusTooManyRequests, CodeTooManyRequests, "请求过于频繁，请稍后再试")
+	ErrInternal        = NewError(http.StatusInternalServerError, CodeInternal, "服务器内部错误")
+	ErrPayloadTooLarge = NewError(http.StatusRequestEntityTooLarge, CodePayloadTooLarge, "请求内容过大")
+	ErrRequestTimeout  = NewError(http.StatusRequestTimeout, CodeRequestTimeout, "请求上传过慢，请检查网络后重试")
+	ErrTimeout         = NewError(http.StatusGatewayTimeout, CodeTimeout, "处理超时，请稍后重试")
+)
+
+// NewError 创建应用错误，消息键默认为 "error." + code
+func NewError(status int, code, message string) *AppError {
+	return &AppError{Status: status, Code: code, Message: message, MessageKey: "error." + code}
+}
+
+func (e *AppError) Error() string {
+	if e.Cause != nil {
+		return e.Message + ": " + e.Cause.Error()
+	}
+	return e.Message
+}
+
+func (e *AppError) Unwrap() error {
+	return e.Cause
+}
+
+// Is 错误码相同即认为是同一错误，WithCause 等返回的副本可以使用 errors.Is 判断
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.Code == e.Code
+}
+
+func (e *AppError) HTTPStatus() int {
+	return e.Status
+}
+
+// WithCause 返回携带原始错误的副本
+func (e *AppError) WithCause(err error) *AppError {
+	c := *e
+	c.Cause = err
+	return &c
+}
+
+// WithDetails 返回携带错误详情的副本
+func (e *AppError) WithDetails(details any) *AppError {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+// WithMessage 返回使用指定提示信息的副本
+func (e *AppError) WithMessage(format string, args ...any) *AppError {
+	c := *e
+	c.Message = fmt.Sprintf(format, args...)
+	c.MessageKey = ""
+	return &c
+}
+
+// WithKey 返回使用指定消息键的副本
+func (e *AppError) WithKey(key string) *AppError {
+	c := *e
+	c.MessageKey = key
+	return &c
+}
+
+// ErrorMapper 将其他包的错误转换为应用错误，不能转换时返回 nil
+type ErrorMapper func(err error) *AppError
+
+// MessageTranslator 根据请求的语言翻译消息键，没有对应的翻译时返回 fallback
+type MessageTranslator func(ctx *gin.Context, key string, fallback string) string
+
+// FieldErrorTranslator 根据请求的语言翻译字段校验错误
+type FieldErrorTranslator func(ctx *gin.Context, fe validator.FieldError) string
+
+var (
+	errorMappersLock sync.RWMutex
+	errorMappers     []ErrorMapper
+
+	translatorLock       sync.RWMutex
+	messageTranslator    MessageTranslator
+	fieldErrorTranslator FieldErrorTranslator
+)
+
+// RegisterErrorMapper 注册错误转换，如数据库模块将 gorm.ErrRecordNotFound 转换为 ErrNotFound
+func RegisterErrorMapper(mappers ...ErrorMapper) {
+	errorMappersLock.Lock()
+	errorMappers = append(errorMappers, mappers...)
+	errorMappersLock.Unlock()
+}
+
+// SetMessageTranslator 设置错误消息的翻译函数
+func SetMessageTranslator(translator MessageTranslator) {
+	translatorLock.Lock()
+	messageTranslator = translator
+	translatorLock.Unlock()
+}
+
+// SetFieldErrorTranslator 设置字段校验错误的翻译函数，由验证器模块设置
+func SetFieldErrorTranslator(translator FieldErrorTranslator) {
+	translatorLock.Lock()
+	fieldErrorTranslator = translator
+	translatorLock.Unlock()
+}
+
+// FieldErrors 返回字段校验错误，键为字段在请求中的路径，如 name、address.city、items[0].sku，值为按请求语言翻译后的错误信息
+// 请求体为数组时键以下标开头，如 [1].name，err 不是校验错误时返回 nil
+//
+//	if err := ctx.ShouldBindJSON(&req); err != nil {
+//		router.BadRequest(ctx, "参数错误", router.FieldErrors(ctx, err))
+//	}
+func FieldErrors(ctx *gin.Context, err error) FieldErrorMap {
+	fields, _ := fieldErrors(ctx, err)
+	return fields
+}
+
+// fieldErrors 返回字段校验错误和第一个错误信息
+func fieldErrors(ctx *gin.Context, err error) (FieldErrorMap, string) {
+	translatorLock.RLock()
+	translate := fieldErrorTranslator
+	translatorLock.RUnlock()
+
+	var fields FieldErrorMap
+	var first string
+	var collect func(err error, prefix string)
+	collect = func(err error, prefix string) {
+		// 数组请求体的校验错误，下标与请求体中的元素对应，校验通过的元素为 nil
+		var sliceErrs binding.SliceValidationError
+		if errors.As(err, &sliceErrs) {
+			for idx, item := range sliceErrs {
+				if item != nil {
+					collect(item, fmt.Sprintf("%s[%d]", prefix, idx))
+				}
+			}
+			return
+		}
+		var validationErrs validator.ValidationErrors
+		if !errors.As(err, &validationErrs) {
+			return
+		}
+		if fields == nil {
+			fields = make(FieldErrorMap, len(validationErrs))
+		}
+		for _, fe := range validationErrs {
+			key := fieldPath(fe)
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			msg := fe.Error()
+			if translate != nil {
+				msg = translate(ctx, fe)
+			}
+			fields[key] = msg
+			if first == "" {
+				first = msg
+			}
+		}
+	}
+	collect(err, "")
+	return fields, first
+}
+
+// fieldPath 字段的完整路径，去掉 Namespace 开头的结构体名称，嵌套和数组字段不会与同名字段冲突
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if idx := strings.Index(ns, "."); idx >= 0 {
+		return ns[idx+1:]
+	}
+	return fe.Field()
+}
+
+// AsAppError 将任意错误转换为应用错误，无法识别的错误为 ErrInternal
+func AsAppError(err error) *AppError {
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	errorMappersLock.RLock()
+	mappers := errorMappers
+	errorMappersLock.RUnlock()
+	for _, mapper := range mappers {
+		if appErr = mapper(err); appErr != nil {
+			return appErr
+		}
+	}
+
+	var validationErrs validator.ValidationErrors
+	var sliceErrs binding.SliceValidationError
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var statusErr StatusError
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxBytesErr):
+		return ErrPayloadTooLarge.WithCause(err)
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrTimeout.WithCause(err)
+	case errors.As(err, &validationErrs), errors.As(err, &sliceErrs):
+		// 详情在响应时按请求语言翻译
+		return ErrValidation.WithCause(err)
+	case errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return ErrBadRequest.WithCause(err)
+	case errors.As(err, &statusErr):
+		appErr = NewError(statusErr.HTTPStatus(), codeOfStatus(statusErr.HTTPStatus()), err.Error())
+		return appErr.WithCause(err)
+	}
+	return ErrInternal.WithCause(err)
+}
+
+// BindError 将请求绑定的错误转换为应用错误，字段校验失败时 details 为字段和错误信息
+func BindError(err error) *AppError {
+	if appErr := AsAppError(err); appErr.Status < http.StatusInternalServerError {
+		return appErr
+	}
+	return ErrBadRequest.WithCause(err)
+}
+
+func codeOfStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusTooManyRequests:
+		return CodeTooManyRequests
+	case http.StatusRequestEntityTooLarge:
+		return CodePayloadTooLarge
+	case http.StatusRequestTimeout:
+		return CodeRequestTimeout
+	case http.StatusGatewayTimeout:
+		return CodeTimeout
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
+
+// errorResp 将错误转换为响应，非调试模式下不返回原始错误信息，5xx 错误的 Details 由应用主动设置，仍然返回
+func errorResp(ctx *gin.Context, err error) (int, Resp) {
+	appErr := AsAppError(err)
+
+	msg := message(ctx, appErr.MessageKey, appErr.Message)
+
+	resp := Resp{Code: appErr.Code, Msg: msg, Details: appErr.Details}
+	if fields, first := fieldErrors(ctx, appErr); appErr.Details == nil && fields != nil {
+		resp.Details = fields
+		// 提示信息使用第一个字段的错误，方便前端直接展示
+		resp.Msg = first
+	}
+	if gin.IsDebugging() && appErr.Cause != nil {
+		resp.Error = appErr.Cause.Error()
+	}
+	return appErr.Status, resp
+}
+
+// Fail 返回错误响应，错误的转换规则见 AsAppError
+// 错误同时记录在 ctx.Errors 中，5xx 错误由 ErrorHandler 记录日志
+func Fail(ctx *gin.Context, err error) {
+	_ = ctx.Error(err)
+	status, resp := errorResp(ctx, err)
+	respondError(ctx, status, resp)
+}
+
+// ErrorHandler 将 handler 中通过 ctx.Error(err) 记录的错误转换为统一的响应，5xx 错误记录日志，包括 Fail 返回的错误
+//
+//	if err := svc.Pay(ctx, req); err != nil {
+//		_ = ctx.Error(err)
+//		return
+//	}
+func ErrorHandler(logger logContract.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 {
+			return
+		}
+		err := c.Errors.Last().Err
+		status, resp := errorResp(c, err)
+		if status >= http.StatusInternalServerError && logger != nil {
+			requestID, _ := c.Get("request_id")
+			logger.Error("HTTP错误", " requestId:", requestID, " method:", c.Request.Method, " path:", c.Request.URL.Path, " error:", err.Error())
+		}
+		// 已经通过 Fail 等方式响应时只记录日志
+		if c.Writer.Written() {
+			return
+		}
+		c.Abort()
+		respondError(c, status, resp)
+	}
+}
@@ -0,0 +1,182 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	limitLock sync.RWMutex
+	limitCfg  LimitConfig
+)
+
+// SetLimitConfig 设置请求限制，由路由服务根据 router.yaml 设置
+func SetLimitConfig(cfg LimitConfig) {
+	limitLock.Lock()
+	limitCfg = cfg
+	limitLock.Unlock()
+}
+
+func limitConfig() LimitConfig {
+	limitLock.RLock()
+	defer limitLock.RUnlock()
+	return limitCfg
+}
+
+// BodyLimit 限制请求体大小，超过时读取请求体返回 *http.MaxBytesError，Fail 转换为 413
+// 可以在全局、分组和路由上多次使用，以最后执行的为准，如上传接口放宽全局的限制
+func BodyLimit(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		b, created := guardBody(c)
+		b.limit = limit
+		if created {
+			b.serve(c)
+		} else {
+			c.Next()
+		}
+	}
+}
+
+// LimitBody 在 handler 中修改请求体的大小限制，需要在读取请求体之前调用
+func LimitBody(c *gin.Context, limit int64) {
+	b, _ := guardBody(c)
+	b.limit = limit
+}
+
+// SlowClient 读取请求体时两次收到数据的间隔超过 idle 时断开，返回 408，防止慢速客户端长时间占用连接
+// 超时时读取请求体返回包装了原始错误的 ErrRequestTimeout
+func SlowClient(idle time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		b, created := guardBody(c)
+		b.idle = idle
+		if created {
+			b.serve(c)
+		} else {
+			c.Next()
+		}
+	}
+}
+
+// Timeout 限制接口处理时间，超时后取消请求上下文，handler 没有输出时返回 504
+// handler 需要将 ctx.Request.Context() 传递给数据库查询等操作，超时才能及时结束
+func Timeout(d time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d <= 0 {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			c.Abort()
+			Fail(c, ErrTimeout)
+		}
+	}
+}
+
+// guardedBody 限制大小和读取间隔的请求体
+type guardedBody struct {
+	body     io.ReadCloser
+	c        *gin.Context
+	limit    int64
+	idle     time.Duration
+	read     int64
+	exceeded bool
+	timeout  bool
+	deadline bool
+	eof      bool
+}
+
+func guardBody(c *gin.Context) (*guardedBody, bool) {
+	if b, ok := c.Request.Body.(*guardedBody); ok {
+		return b, false
+	}
+	b := &guardedBody{body: c.Request.Body, c: c}
+	if b.body == nil {
+		b.body = http.NoBody
+	}
+	c.Request.Body = b
+	return b, true
+}
+
+func (b *guardedBody) Read(p []byte) (int, error) {
+	if b.limit > 0 {
+		if b.c.Request.ContentLength > b.limit {
+			return 0, b.tooLarge()
+		}
+		// 多读一个字节，用于判断是否超过限制
+		remaining := max(b.limit-b.read, 0)
+		if int64(len(p)) > remaining+1 {
+			p = p[:remaining+1]
+		}
+	}
+	if b.idle > 0 {
+		_ = http.NewResponseController(b.c.Writer).SetReadDeadline(time.Now().Add(b.idle))
+		b.deadline = true
+	}
+
+	n, err := b.body.Read(p)
+	b.read += int64(n)
+	if b.limit > 0 && b.read > b.limit {
+		n -= int(b.read - b.limit)
+		b.read = b.limit
+		return n, b.tooLarge()
+	}
+	switch {
+	case err == io.EOF:
+		b.eof = true
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		b.timeout = true
+		b.c.Header("Connection", "close")
+		// 与 handler 中数据库、Redis 等的读取超时区分，只有请求体读取超时返回 408
+		return n, ErrRequestTimeout.WithCause(err)
+	}
+	return n, err
+}
+
+func (b *guardedBody) Close() error {
+	return b.body.Close()
+}
+
+func (b *guardedBody) tooLarge() error {
+	b.exceeded = true
+	// 剩余的请求体不再读取，响应后关闭连接
+	b.c.Header("Connection", "close")
+	return &http.MaxBytesError{Limit: b.limit}
+}
+
+// serve 执行后续的 handler，handler 忽略了读取错误且没有输出时返回 413 或 408
+func (b *guardedBody) serve(c *gin.Context) {
+	c.Next()
+
+	if b.deadline {
+		if b.eof {
+			_ = http.NewResponseController(c.Writer).SetReadDeadline(time.Time{})
+		} else {
+			// 请求体没有读完时 net/http 会在响应前读取剩余的部分，保留读取期限并在响应后关闭连接
+			c.Header("Connection", "close")
+		}
+	}
+	if c.Writer.Written() {
+		return
+	}
+	switch {
+	case b.exceeded:
+		c.Abort()
+		Fail(c, &http.MaxBytesError{Limit: b.limit})
+	case b.timeout:
+		c.Abort()
+		Fail(c, ErrRequestTimeout)
+	}
+}
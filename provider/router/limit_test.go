@@ -0,0 +1,130 @@
+package router
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type uploadHandle struct{}
+
+func (uploadHandle) ModuleName() (string, string) { return "upload", "上传" }
+
+func (uploadHandle) Echo(ctx *gin.Context) {
+	data, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		Fail(ctx, err)
+		return
+	}
+	ctx.String(http.StatusOK, "%d", len(data))
+}
+
+func (uploadHandle) Slow(ctx *gin.Context) {
+	select {
+	case <-ctx.Request.Context().Done():
+		Fail(ctx, ctx.Request.Context().Err())
+	case <-time.After(200 * time.Millisecond):
+		ctx.String(http.StatusOK, "done")
+	}
+}
+
+func (uploadHandle) Ignore(ctx *gin.Context) {
+	<-ctx.Request.Context().Done()
+}
+
+func newLimitEngine() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	SetLimitConfig(LimitConfig{Timeout: 1})
+	defer SetLimitConfig(LimitConfig{})
+
+	engine := gin.New()
+	engine.Use(BodyLimit(8), SlowClient(100*time.Millisecond))
+	h := uploadHandle{}
+	b := NewRouteInfoBuilder("app", h, engine.Group("/limit"), MenuOption{})
+	b.Post("/echo", AccessPublic, h.Echo).Build()
+	b.Post("/large", AccessPublic, h.Echo).BodyLimit(16).Build()
+	b.Get("/slow", AccessPublic, h.Slow).Timeout(50 * time.Millisecond).Build()
+	b.Timeout(50*time.Millisecond).Get("/ignore", AccessPublic, h.Ignore).Build()
+	b.Get("/unlimited", AccessPublic, h.Slow).Timeout(0).Build()
+	return engine
+}
+
+func TestLimit(t *testing.T) {
+	engine := newLimitEngine()
+
+	cases := []struct {
+		method, target, body string
+		chunked              bool
+		status               int
+		code                 string
+	}{
+		{http.MethodPost, "/limit/echo", "12345678", false, http.StatusOK, ""},
+		{http.MethodPost, "/limit/echo", "123456789", false, http.StatusRequestEntityTooLarge, CodePayloadTooLarge},
+		{http.MethodPost, "/limit/echo", "123456789", true, http.StatusRequestEntityTooLarge, CodePayloadTooLarge},
+		{http.MethodPost, "/limit/large", "1234567890123456", false, http.StatusOK, ""},
+		{http.MethodPost, "/limit/large", "12345678901234567", true, http.StatusRequestEntityTooLarge, CodePayloadTooLarge},
+		{http.MethodGet, "/limit/slow", "", false, http.StatusGatewayTimeout, CodeTimeout},
+		{http.MethodGet, "/limit/ignore", "", false, http.StatusGatewayTimeout, CodeTimeout},
+		{http.MethodGet, "/limit/unlimited", "", false, http.StatusOK, ""},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(c.method, c.target, strings.NewReader(c.body))
+		if c.chunked {
+			req.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != c.status {
+			t.Errorf("%s %s: status = %d, want %d, body %s", c.method, c.target, w.Code, c.status, w.Body)
+			continue
+		}
+		if c.code == "" {
+			continue
+		}
+		var resp Resp
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Code != c.code {
+			t.Errorf("%s %s: body = %s, want code %s", c.method, c.target, w.Body, c.code)
+		}
+	}
+}
+
+func TestSlowClient(t *testing.T) {
+	srv := httptest.NewServer(newLimitEngine())
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	// 声明 8 字节的请求体，只发送 2 字节后停止
+	_, _ = fmt.Fprintf(conn, "POST /limit/echo HTTP/1.1\r\nHost: test\r\nContent-Length: 8\r\n\r\n12")
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestTimeout {
+		t.Fatalf("status = %d, want 408", resp.StatusCode)
+	}
+}
+
+func TestDeadlineErrorIsNotRequestTimeout(t *testing.T) {
+	// handler 中数据库、Redis 等的读取超时不是客户端上传过慢
+	err := &net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}
+	if appErr := AsAppError(fmt.Errorf("查询失败: %w", err)); appErr.Status != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", appErr.Status)
+	}
+}
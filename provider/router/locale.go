@@ -0,0 +1,66 @@
+package router
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LocaleContextKey 请求上下文中保存语言的键，中间件可以提前设置，如根据用户的语言偏好
+const LocaleContextKey = "locale"
+
+var (
+	localeLock    sync.RWMutex
+	defaultLocale = "zh"
+)
+
+// SetDefaultLocale 设置请求没有指定语言时使用的语言，由翻译服务根据 app.yaml 的 locale 设置
+func SetDefaultLocale(locale string) {
+	if locale = normalizeLocale(locale); locale == "" {
+		return
+	}
+	localeLock.Lock()
+	defaultLocale = locale
+	localeLock.Unlock()
+}
+
+// DefaultLocale 返回请求没有指定语言时使用的语言，默认为 zh
+func DefaultLocale() string {
+	localeLock.RLock()
+	defer localeLock.RUnlock()
+	return defaultLocale
+}
+
+// Locale 返回请求的语言，依次读取上下文、lang 查询参数、Accept-Language 请求头，如 zh、en，都没有时为 DefaultLocale
+func Locale(ctx *gin.Context) string {
+	if ctx == nil {
+		return DefaultLocale()
+	}
+	if locale := ctx.GetString(LocaleContextKey); locale != "" {
+		return locale
+	}
+	if ctx.Request != nil {
+		if locale := normalizeLocale(ctx.Query("lang")); locale != "" {
+			return locale
+		}
+		// Accept-Language: en-US,en;q=0.9,zh-CN;q=0.8 只取第一个
+		header := strings.Split(ctx.GetHeader("Accept-Language"), ",")[0]
+		if locale := normalizeLocale(strings.Split(header, ";")[0]); locale != "" {
+			return locale
+		}
+	}
+	return DefaultLocale()
+}
+
+// normalizeLocale 只保留主语言，如 zh-CN 返回 zh
+func normalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if idx := strings.IndexAny(locale, "-_"); idx > 0 {
+		locale = locale[:idx]
+	}
+	if locale == "*" {
+		return ""
+	}
+	return locale
+}
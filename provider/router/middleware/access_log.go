@@ -16,6 +16,12 @@ type AccessLogConfig struct {
 	SkipPaths []string `yaml:"skip-paths"`
 }
 
+// queryStats 数据库查询统计，由 db.QueryStatsMiddleware 以 db_query_stats 为键写入上下文
+type queryStats interface {
+	Count() int64
+	Duration() time.Duration
+}
+
 // AccessLog 访问日志中间件
 // 记录HTTP请求的访问日志，支持跳过指定路径
 func AccessLog(logger logContract.Logger, config AccessLogConfig) gin.HandlerFunc {
@@ -76,22 +82,7 @@ func AccessLog(logger logContract.Logger, config AccessLogConfig) gin.HandlerFun
 			requestPath = requestPath + "?" + rawQuery
 		}
 
-		if status >= 500 {
-			logger.Error(
-				"HTTP请求",
-				" requestId", requestID,
-				" status", status,
-				" method", method,
-				" path", requestPath,
-				" latency", latency.String(),
-				" clientIp", clientIP,
-				" userAgent", userAgent,
-			)
-			return
-		}
-
-		logger.Info(
-			"HTTP请求 ",
+		fields := []any{
 			" requestId", requestID,
 			" status", status,
 			" method", method,
@@ -99,6 +90,18 @@ func AccessLog(logger logContract.Logger, config AccessLogConfig) gin.HandlerFun
 			" latency", latency.String(),
 			" clientIp", clientIP,
 			" userAgent", userAgent,
-		)
+		}
+		if value, ok := c.Get("db_query_stats"); ok {
+			if stats, ok := value.(queryStats); ok {
+				fields = append(fields, " dbQueries", stats.Count(), " dbLatency", stats.Duration().String())
+			}
+		}
+
+		if status >= 500 {
+			logger.Error(append([]any{"HTTP请求"}, fields...)...)
+			return
+		}
+
+		logger.Info(append([]any{"HTTP请求 "}, fields...)...)
 	}
 }
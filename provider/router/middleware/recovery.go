@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
 	"runtime/debug"
 
@@ -8,7 +9,8 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func Recovery(logger logContract.Logger) gin.HandlerFunc {
+// Recovery 捕获 panic 并记录日志，respond 用于返回与其他接口一致的错误响应，为空时返回默认的 500 响应
+func Recovery(logger logContract.Logger, respond func(c *gin.Context, err error)) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		defer func() {
 			if recovered := recover(); recovered != nil {
@@ -21,10 +23,25 @@ func Recovery(logger logContract.Logger) gin.HandlerFunc {
 					" panic info:", recovered,
 					" stack", string(debug.Stack()),
 				)
-				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
-					"success": false,
-					"msg":     "服务器内部错误",
-				})
+
+				err, ok := recovered.(error)
+				if !ok {
+					err = fmt.Errorf("panic: %v", recovered)
+				}
+				if c.Writer.Written() {
+					c.Abort()
+					return
+				}
+				if respond == nil {
+					c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{
+						"code":    "internal_error",
+						"success": false,
+						"msg":     "服务器内部错误",
+					})
+					return
+				}
+				respond(c, err)
+				c.Abort()
 			}
 		}()
 		c.Next()
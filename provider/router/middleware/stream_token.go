@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StreamToken 浏览器的 EventSource、WebSocket 和 Socket.IO 长轮询不能设置请求头，将查询参数 access_token 转为 Authorization 请求头，供认证中间件使用
+// 转换后从查询参数中删除，避免令牌被记录到访问日志
+func StreamToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") != "" || !isStreamRequest(c) {
+			c.Next()
+			return
+		}
+		query := c.Request.URL.Query()
+		if token := query.Get("access_token"); token != "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+			query.Del("access_token")
+			c.Request.URL.RawQuery = query.Encode()
+		}
+		c.Next()
+	}
+}
+
+func isStreamRequest(c *gin.Context) bool {
+	return strings.EqualFold(c.GetHeader("Upgrade"), "websocket") ||
+		strings.Contains(c.GetHeader("Accept"), "text/event-stream") ||
+		c.Query("EIO") != "" // Socket.IO 的请求
+
+}
@@ -2,7 +2,7 @@ package router
 
 // RouterOptions 路由配置选项
 type RouterOptions struct {
-	Mode       string           `json:"mode"`
+	Mode       string           `json:"mode" validate:"omitempty,oneof=debug release test"`
 	Server     ServerConfig     `json:"server"`
 	Middleware MiddlewareConfig `json:"middleware"`
 	Cors       CorsConfig       `json:"cors"`
@@ -13,12 +13,15 @@ type RouterOptions struct {
 	Log        LogConfig        `json:"log"`
 	Health     HealthConfig     `json:"health"`
 	Metrics    MetricsConfig    `json:"metrics"`
+	Response   ResponseConfig   `json:"response"`
+	Realtime   RealtimeConfig   `json:"realtime"`
+	Limit      LimitConfig      `json:"limit"`
 }
 
 type TLSConfig struct {
 	Enabled           bool   `json:"enabled"`
-	CertFile          string `json:"cert-file"`
-	KeyFile           string `json:"key-file"`
+	CertFile          string `json:"cert-file" validate:"required_if=Enabled true"`
+	KeyFile           string `json:"key-file" validate:"required_if=Enabled true"`
 	ClientCAFile      string `json:"client-ca-file"`
 	RequireClientCert bool   `json:"require-client-cert"`
 	MinVersion        string `json:"min-version"`
@@ -26,13 +29,14 @@ type TLSConfig struct {
 
 // ServerConfig 服务器配置
 type ServerConfig struct {
-	Host           string    `json:"host"`
-	Port           int       `json:"port"`
-	ReadTimeout    int       `json:"read-timeout"`
-	WriteTimeout   int       `json:"write-timeout"`
-	IdleTimeout    int       `json:"idle-timeout"`
-	MaxHeaderBytes int       `json:"max-header-bytes"`
-	TLS            TLSConfig `json:"tls"`
+	Host              string    `json:"host"`
+	Port              int       `json:"port" validate:"min=1,max=65535"`
+	ReadTimeout       int       `json:"read-timeout" validate:"min=0"`
+	ReadHeaderTimeout int       `json:"read-header-timeout" validate:"min=0"`
+	WriteTimeout      int       `json:"write-timeout" validate:"min=0"`
+	IdleTimeout       int       `json:"idle-timeout" validate:"min=0"`
+	MaxHeaderBytes    int       `json:"max-header-bytes" validate:"min=0"`
+	TLS               TLSConfig `json:"tls"`
 }
 
 // MiddlewareConfig 中间件配置
@@ -56,9 +60,9 @@ type CorsConfig struct {
 
 // RateLimitConfig 限流配置
 type RateLimitConfig struct {
-	RequestsPerSecond int    `json:"requests-per-second"`
-	Burst             int    `json:"burst"`
-	KeyGenerator      string `json:"key-generator"`
+	RequestsPerSecond int    `json:"requests-per-second" validate:"min=0"`
+	Burst             int    `json:"burst" validate:"min=0"`
+	KeyGenerator      string `json:"key-generator" validate:"omitempty,oneof=ip user custom"`
 }
 
 // StaticConfig 静态文件配置
@@ -92,7 +96,7 @@ type SecurityConfig struct {
 // LogConfig 日志配置
 type LogConfig struct {
 	AccessLog       bool     `json:"access-log"`
-	AccessLogFormat string   `json:"access-log-format"`
+	AccessLogFormat string   `json:"access-log-format" validate:"omitempty,oneof=combined common short tiny"`
 	SkipPaths       []string `json:"skip-paths"`
 }
 
@@ -107,3 +111,27 @@ type MetricsConfig struct {
 	Enabled bool   `json:"enabled"`
 	Path    string `json:"path"`
 }
+
+// ResponseConfig 响应格式配置
+type ResponseConfig struct {
+	Envelope        string   `json:"envelope" validate:"omitempty,oneof=owl bare"`
+	ErrorFormat     string   `json:"error-format" validate:"omitempty,oneof=owl problem"`
+	Formats         []string `json:"formats" validate:"dive,oneof=json xml yaml msgpack protobuf"`
+	ProblemTypeBase string   `json:"problem-type-base" validate:"omitempty,url"`
+}
+
+// RealtimeConfig SSE 和 WebSocket 配置
+type RealtimeConfig struct {
+	Heartbeat      int      `json:"heartbeat" validate:"min=0"`     // 心跳间隔（秒）
+	WriteTimeout   int      `json:"write-timeout" validate:"min=0"` // 单条消息写入超时时间（秒）
+	SendBuffer     int      `json:"send-buffer" validate:"min=0"`   // 每个连接的发送缓冲区大小，写满时断开连接
+	MaxMessageSize int64    `json:"max-message-size" validate:"min=0"`
+	AllowedOrigins []string `json:"allowed-origins"`
+}
+
+// LimitConfig 请求限制配置，路由可以通过 RouteBuilder 的 BodyLimit、Timeout 单独指定
+type LimitConfig struct {
+	MaxBodySize     int64 `json:"max-body-size" validate:"min=0"`     // 请求体最大字节数，0 不限制
+	Timeout         int   `json:"timeout" validate:"min=0"`           // 接口处理超时时间（秒），0 不限制
+	BodyReadTimeout int   `json:"body-read-timeout" validate:"min=0"` // 读取请求体时两次收到数据的最长间隔（秒），0 不限制
+}
@@ -0,0 +1,506 @@
+package router
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/spf13/cast"
+)
+
+// 实时连接的传输方式
+const (
+	RealtimeSSE = "sse"
+	RealtimeWS  = "ws"
+)
+
+var (
+	ErrConnClosed   = errors.New("连接已关闭")
+	ErrSlowConsumer = errors.New("客户端接收过慢，连接已关闭")
+	ErrInvalidEvent = errors.New("事件名称不能包含换行")
+)
+
+// RealtimeHandler 实时连接建立前调用，返回错误时按 Fail 返回错误响应，不建立连接
+// 通常在其中校验权限、订阅主题，handler 返回后连接保持，直到客户端断开或调用 conn.Close
+// 需要定时推送时另起协程，并在 conn.Done() 关闭时退出
+//
+//	b.SSE("/orders/events", router.AccessAuthenticated, func(ctx *gin.Context, conn *router.Conn) error {
+//		conn.Subscribe("orders:" + conn.UserID())
+//		return nil
+//	}).Name("订单通知").Build()
+type RealtimeHandler func(ctx *gin.Context, conn *Conn) error
+
+// Message 推送给实时连接的消息，Topic 和 UserID 都为空时推送给所有连接
+type Message struct {
+	Topic  string          `json:"topic,omitempty"`
+	UserID string          `json:"userId,omitempty"`
+	Event  string          `json:"event"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// Broadcaster 将消息投递到所有副本的实时连接，默认只投递到当前副本，多副本部署时使用 Redis 发布订阅
+type Broadcaster interface {
+	Publish(ctx context.Context, msg Message) error
+}
+
+type localBroadcaster struct{}
+
+func (localBroadcaster) Publish(_ context.Context, msg Message) error {
+	Deliver(msg)
+	return nil
+}
+
+var (
+	broadcasterLock sync.RWMutex
+	broadcaster     Broadcaster = localBroadcaster{}
+
+	realtimeLock sync.RWMutex
+	realtimeCfg  RealtimeConfig
+
+	hub = &realtimeHub{
+		conns:  make(map[*Conn]struct{}),
+		topics: make(map[string]map[*Conn]struct{}),
+		users:  make(map[string]map[*Conn]struct{}),
+	}
+)
+
+// SetBroadcaster 设置消息的投递方式，由 Redis 模块在开启广播时设置
+func SetBroadcaster(b Broadcaster) {
+	broadcasterLock.Lock()
+	broadcaster = b
+	broadcasterLock.Unlock()
+}
+
+// SetRealtimeConfig 设置实时连接的心跳等参数，由路由服务根据 router.yaml 设置
+func SetRealtimeConfig(cfg RealtimeConfig) {
+	realtimeLock.Lock()
+	realtimeCfg = cfg
+	realtimeLock.Unlock()
+}
+
+func realtimeConfig() RealtimeConfig {
+	realtimeLock.RLock()
+	cfg := realtimeCfg
+	realtimeLock.RUnlock()
+	if cfg.Heartbeat <= 0 {
+		cfg.Heartbeat = 25
+	}
+	if cfg.WriteTimeout <= 0 {
+		cfg.WriteTimeout = 10
+	}
+	if cfg.SendBuffer <= 0 {
+		cfg.SendBuffer = 64
+	}
+	if cfg.MaxMessageSize <= 0 {
+		cfg.MaxMessageSize = 64 << 10
+	}
+	return cfg
+}
+
+// Broadcast 推送消息给订阅了 topic 的连接，包括其他副本上的连接，事件名称包含换行时返回 ErrInvalidEvent
+func Broadcast(ctx context.Context, topic, event string, data any) error {
+	return publish(ctx, Message{Topic: topic, Event: event}, data)
+}
+
+// SendToUser 推送消息给用户的所有连接，用户为连接建立时上下文中的 user_id
+func SendToUser(ctx context.Context, userID, event string, data any) error {
+	return publish(ctx, Message{UserID: userID, Event: event}, data)
+}
+
+func publish(ctx context.Context, msg Message, data any) error {
+	if !validEvent(msg.Event) {
+		return ErrInvalidEvent
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	msg.Data = raw
+
+	broadcasterLock.RLock()
+	b := broadcaster
+	broadcasterLock.RUnlock()
+	return b.Publish(ctx, msg)
+}
+
+// Deliver 将消息投递到当前副本的连接，返回收到消息的连接数，Broadcaster 收到其他副本的消息后调用
+// 事件名称包含换行的消息不投递
+func Deliver(msg Message) int {
+	if !validEvent(msg.Event) {
+		return 0
+	}
+	return hub.deliver(msg)
+}
+
+// validEvent 事件名称写入 SSE 的 event 行，包含换行时可以伪造其他字段和消息
+func validEvent(event string) bool {
+	return !strings.ContainsAny(event, "\r\n")
+}
+
+type realtimeHub struct {
+	lock   sync.RWMutex
+	conns  map[*Conn]struct{}
+	topics map[string]map[*Conn]struct{}
+	users  map[string]map[*Conn]struct{}
+}
+
+func (h *realtimeHub) add(c *Conn) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	c.registered = true
+	h.conns[c] = struct{}{}
+	if c.userID != "" {
+		addConn(h.users, c.userID, c)
+	}
+	for topic := range c.topics {
+		addConn(h.topics, topic, c)
+	}
+}
+
+func (h *realtimeHub) remove(c *Conn) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	c.registered = false
+	delete(h.conns, c)
+	removeConn(h.users, c.userID, c)
+	for topic := range c.topics {
+		removeConn(h.topics, topic, c)
+	}
+}
+
+func (h *realtimeHub) deliver(msg Message) int {
+	h.lock.RLock()
+	var targets []*Conn
+	switch {
+	case msg.Topic != "":
+		for c := range h.topics[msg.Topic] {
+			if msg.UserID == "" || c.userID == msg.UserID {
+				targets = append(targets, c)
+			}
+		}
+	case msg.UserID != "":
+		for c := range h.users[msg.UserID] {
+			targets = append(targets, c)
+		}
+	default:
+		for c := range h.conns {
+			targets = append(targets, c)
+		}
+	}
+	h.lock.RUnlock()
+
+	delivered := 0
+	for _, c := range targets {
+		if c.enqueue(msg) == nil {
+			delivered++
+		}
+	}
+	return delivered
+}
+
+func addConn(index map[string]map[*Conn]struct{}, key string, c *Conn) {
+	if index[key] == nil {
+		index[key] = make(map[*Conn]struct{})
+	}
+	index[key][c] = struct{}{}
+}
+
+func removeConn(index map[string]map[*Conn]struct{}, key string, c *Conn) {
+	delete(index[key], c)
+	if len(index[key]) == 0 {
+		delete(index, key)
+	}
+}
+
+// Conn 实时连接，SSE 和 WebSocket 共用
+type Conn struct {
+	id          string
+	userID      string
+	transport   string
+	lastEventID string
+	send        chan Message
+	done        chan struct{}
+	closeOnce   sync.Once
+	onMessage   func(event string, data json.RawMessage)
+
+	// 以下字段由 hub.lock 保护
+	registered bool
+	topics     map[string]struct{}
+}
+
+func newConn(ctx *gin.Context, transport string, buffer int) *Conn {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return &Conn{
+		id:          hex.EncodeToString(id),
+		userID:      cast.ToString(ctx.Value("user_id")),
+		transport:   transport,
+		lastEventID: ctx.GetHeader("Last-Event-ID"),
+		send:        make(chan Message, buffer),
+		done:        make(chan struct{}),
+		topics:      make(map[string]struct{}),
+	}
+}
+
+// ID 连接的唯一标识
+func (c *Conn) ID() string {
+	return c.id
+}
+
+// UserID 连接建立时上下文中的 user_id，由认证中间件设置，开放接口为空
+func (c *Conn) UserID() string {
+	return c.userID
+}
+
+// Transport 传输方式，sse 或 ws
+func (c *Conn) Transport() string {
+	return c.transport
+}
+
+// LastEventID SSE 断线重连时客户端携带的最后一条消息 ID，可用于补发消息
+func (c *Conn) LastEventID() string {
+	return c.lastEventID
+}
+
+// Subscribe 订阅主题，Broadcast 推送到主题的消息会发送到此连接
+func (c *Conn) Subscribe(topics ...string) {
+	hub.lock.Lock()
+	defer hub.lock.Unlock()
+	for _, topic := range topics {
+		c.topics[topic] = struct{}{}
+		if c.registered {
+			addConn(hub.topics, topic, c)
+		}
+	}
+}
+
+// Unsubscribe 取消订阅主题
+func (c *Conn) Unsubscribe(topics ...string) {
+	hub.lock.Lock()
+	defer hub.lock.Unlock()
+	for _, topic := range topics {
+		delete(c.topics, topic)
+		if c.registered {
+			removeConn(hub.topics, topic, c)
+		}
+	}
+}
+
+// OnMessage 设置 WebSocket 客户端消息的处理函数，消息格式为 {"event": "...", "data": ...}，SSE 连接不会调用
+func (c *Conn) OnMessage(fn func(event string, data json.RawMessage)) {
+	c.onMessage = fn
+}
+
+// Send 发送消息给此连接，发送缓冲区已满时认为客户端接收过慢，关闭连接，事件名称包含换行时返回 ErrInvalidEvent
+func (c *Conn) Send(event string, data any) error {
+	if !validEvent(event) {
+		return ErrInvalidEvent
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return c.enqueue(Message{Event: event, Data: raw})
+}
+
+func (c *Conn) enqueue(msg Message) error {
+	select {
+	case <-c.done:
+		return ErrConnClosed
+	default:
+	}
+	select {
+	case c.send <- msg:
+		return nil
+	default:
+		c.Close()
+		return ErrSlowConsumer
+	}
+}
+
+// Done 连接关闭时关闭
+func (c *Conn) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close 关闭连接
+func (c *Conn) Close() {
+	c.closeOnce.Do(func() { close(c.done) })
+}
+
+func (c *Conn) closed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// serveSSE 以 text/event-stream 输出消息，定时发送注释行作为心跳，避免代理断开空闲连接
+func serveSSE(handle RealtimeHandler) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		cfg := realtimeConfig()
+		conn := newConn(ctx, RealtimeSSE, cfg.SendBuffer)
+		if err := handle(ctx, conn); err != nil {
+			Fail(ctx, err)
+			return
+		}
+		if conn.closed() {
+			return
+		}
+
+		w := ctx.Writer
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		// 长连接不受服务器写超时限制，每次写入单独设置超时
+		rc := http.NewResponseController(w)
+		_ = rc.SetWriteDeadline(time.Time{})
+		w.Flush()
+
+		hub.add(conn)
+		defer hub.remove(conn)
+		defer conn.Close()
+
+		heartbeat := time.NewTicker(time.Duration(cfg.Heartbeat) * time.Second)
+		defer heartbeat.Stop()
+		writeTimeout := time.Duration(cfg.WriteTimeout) * time.Second
+
+		var seq uint64
+		for {
+			var frame string
+			select {
+			case <-ctx.Request.Context().Done():
+				return
+			case <-conn.done:
+				return
+			case <-heartbeat.C:
+				frame = ": ping\n\n"
+			case msg := <-conn.send:
+				seq++
+				frame = fmt.Sprintf("id: %d\nevent: %s\ndata: %s\n\n", seq, msg.Event, sseData(msg.Data))
+			}
+			_ = rc.SetWriteDeadline(time.Now().Add(writeTimeout))
+			if _, err := w.WriteString(frame); err != nil {
+				return
+			}
+			w.Flush()
+		}
+	}
+}
+
+// sseData 多行数据每行都需要 data: 前缀，JSON 编码后通常只有一行
+func sseData(data json.RawMessage) string {
+	if len(data) == 0 {
+		return "null"
+	}
+	return strings.ReplaceAll(string(data), "\n", "\ndata: ")
+}
+
+// serveWS 升级为 WebSocket，服务端定时发送 ping，超过两个心跳周期没有收到任何消息时断开
+func serveWS(handle RealtimeHandler) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		cfg := realtimeConfig()
+		conn := newConn(ctx, RealtimeWS, cfg.SendBuffer)
+		if err := handle(ctx, conn); err != nil {
+			Fail(ctx, err)
+			return
+		}
+		if conn.closed() {
+			return
+		}
+
+		upgrader := websocket.Upgrader{CheckOrigin: CheckOrigin(cfg.AllowedOrigins)}
+		ws, err := upgrader.Upgrade(ctx.Writer, ctx.Request, nil)
+		if err != nil {
+			// Upgrade 已返回错误响应
+			return
+		}
+		defer ws.Close()
+
+		hub.add(conn)
+		defer hub.remove(conn)
+		defer conn.Close()
+
+		heartbeat := time.Duration(cfg.Heartbeat) * time.Second
+		writeTimeout := time.Duration(cfg.WriteTimeout) * time.Second
+
+		readDone := make(chan struct{})
+		go func() {
+			defer close(readDone)
+			defer conn.Close()
+			ws.SetReadLimit(cfg.MaxMessageSize)
+			_ = ws.SetReadDeadline(time.Now().Add(2 * heartbeat))
+			ws.SetPongHandler(func(string) error {
+				return ws.SetReadDeadline(time.Now().Add(2 * heartbeat))
+			})
+			for {
+				_, data, err := ws.ReadMessage()
+				if err != nil {
+					return
+				}
+				_ = ws.SetReadDeadline(time.Now().Add(2 * heartbeat))
+				var msg Message
+				if json.Unmarshal(data, &msg) != nil || conn.onMessage == nil {
+					continue
+				}
+				conn.onMessage(msg.Event, msg.Data)
+			}
+		}()
+
+		ticker := time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-conn.done:
+				_ = ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(writeTimeout))
+				_ = ws.Close()
+				<-readDone
+				return
+			case <-ticker.C:
+				if err := ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+					conn.Close()
+				}
+			case msg := <-conn.send:
+				_ = ws.SetWriteDeadline(time.Now().Add(writeTimeout))
+				if err := ws.WriteJSON(Message{Event: msg.Event, Data: msg.Data}); err != nil {
+					conn.Close()
+				}
+			}
+		}
+	}
+}
+
+// CheckOrigin 检查 WebSocket 等连接的来源，未配置时只允许同源，配置 * 时允许所有来源
+// 没有 Origin 请求头的非浏览器客户端不检查
+func CheckOrigin(allowed []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		if len(allowed) == 0 {
+			u, err := url.Parse(origin)
+			return err == nil && strings.EqualFold(u.Host, r.Host)
+		}
+		for _, o := range allowed {
+			if o == "*" || strings.EqualFold(o, origin) {
+				return true
+			}
+		}
+		return false
+	}
+}
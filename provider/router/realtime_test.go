@@ -0,0 +1,189 @@
+package router
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+type noticeHandle struct{}
+
+func (noticeHandle) ModuleName() (string, string) { return "notice", "通知" }
+
+func (noticeHandle) Events(ctx *gin.Context, conn *Conn) error {
+	if ctx.Query("deny") != "" {
+		return ErrForbidden
+	}
+	conn.Subscribe("notice")
+	return nil
+}
+
+func (noticeHandle) Chat(ctx *gin.Context, conn *Conn) error {
+	conn.OnMessage(func(event string, data json.RawMessage) {
+		var text string
+		_ = json.Unmarshal(data, &text)
+		_ = conn.Send(event, "echo: "+text)
+	})
+	return nil
+}
+
+func newRealtimeServer(t *testing.T) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	group := engine.Group("/api", func(c *gin.Context) {
+		c.Set("user_id", c.GetHeader("X-User"))
+	})
+	h := noticeHandle{}
+	b := NewRouteInfoBuilder("app", h, group, MenuOption{})
+	b.SSE("/events", AccessAuthenticated, h.Events).Name("通知事件").Build()
+	b.WS("/chat", AccessAuthenticated, h.Chat).Name("聊天").Build()
+
+	srv := httptest.NewServer(engine)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// waitConns 等待连接注册到 hub，连接建立在 handler 返回之后
+func waitConns(t *testing.T, n int) {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		hub.lock.RLock()
+		count := len(hub.conns)
+		hub.lock.RUnlock()
+		if count == n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("等待 %d 个连接超时", n)
+}
+
+func TestSSE(t *testing.T) {
+	srv := newRealtimeServer(t)
+
+	resp, err := http.Get(srv.URL + "/api/events?deny=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("deny status = %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/api/events", nil)
+	req.Header.Set("X-User", "u1")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %s", ct)
+	}
+	waitConns(t, 1)
+
+	if err = Broadcast(context.Background(), "notice", "created", gin.H{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err = SendToUser(context.Background(), "u1", "private", "hi"); err != nil {
+		t.Fatal(err)
+	}
+	if n := Deliver(Message{UserID: "u2", Event: "other"}); n != 0 {
+		t.Fatalf("delivered to other user: %d", n)
+	}
+	// 事件名称包含换行时可以伪造 SSE 的 data 行，直接拒绝
+	if err = Broadcast(context.Background(), "notice", "x\ndata: forged", nil); !errors.Is(err, ErrInvalidEvent) {
+		t.Fatalf("broadcast err = %v", err)
+	}
+	if err = SendToUser(context.Background(), "u1", "x\r\n", nil); !errors.Is(err, ErrInvalidEvent) {
+		t.Fatalf("send to user err = %v", err)
+	}
+	if n := Deliver(Message{UserID: "u1", Event: "x\nid: 9"}); n != 0 {
+		t.Fatalf("delivered invalid event: %d", n)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	var frames []string
+	var frame strings.Builder
+	for len(frames) < 2 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if line == "\n" {
+			frames = append(frames, frame.String())
+			frame.Reset()
+			continue
+		}
+		frame.WriteString(line)
+	}
+	if frames[0] != "id: 1\nevent: created\ndata: {\"id\":1}\n" || frames[1] != "id: 2\nevent: private\ndata: \"hi\"\n" {
+		t.Fatalf("frames = %q", frames)
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	srv := newRealtimeServer(t)
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/api/chat"
+	ws, _, err := websocket.DefaultDialer.Dial(url, http.Header{"X-User": {"u2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	waitConns(t, 1)
+
+	if err = ws.WriteJSON(gin.H{"event": "say", "data": "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	var msg Message
+	if err = ws.ReadJSON(&msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Event != "say" || string(msg.Data) != `"echo: hello"` {
+		t.Fatalf("echo = %+v", msg)
+	}
+
+	if err = SendToUser(context.Background(), "u2", "notice", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err = ws.ReadJSON(&msg); err != nil || msg.Event != "notice" {
+		t.Fatalf("notice = %+v, err = %v", msg, err)
+	}
+
+	_ = ws.Close()
+	waitConns(t, 0)
+}
+
+func TestCheckOrigin(t *testing.T) {
+	cases := []struct {
+		allowed []string
+		origin  string
+		want    bool
+	}{
+		{nil, "", true},
+		{nil, "http://example.com", true},
+		{nil, "https://other.com", false},
+		{[]string{"https://a.example.com"}, "https://a.example.com", true},
+		{[]string{"https://a.example.com"}, "https://b.example.com", false},
+		{[]string{"*"}, "https://other.com", true},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, "http://example.com/ws", nil)
+		if c.origin != "" {
+			r.Header.Set("Origin", c.origin)
+		}
+		if got := CheckOrigin(c.allowed)(r); got != c.want {
+			t.Errorf("allowed %v origin %q = %v, want %v", c.allowed, c.origin, got, c.want)
+		}
+	}
+}
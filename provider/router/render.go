@@ -0,0 +1,281 @@
+package router
+
+import (
+	"encoding/xml"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/gin-gonic/gin/render"
+	"google.golang.org/protobuf/proto"
+)
+
+// 响应包装风格
+const (
+	EnvelopeOwl  = "owl"  // 统一包装为 {code, msg, success, data}
+	EnvelopeBare = "bare" // 直接返回数据，分页信息放在响应头中
+)
+
+// 错误响应格式
+const (
+	ErrorFormatOwl     = "owl"     // 与成功响应相同的包装
+	ErrorFormatProblem = "problem" // RFC 7807 application/problem+json
+)
+
+// 内容协商支持的格式
+const (
+	FormatJSON     = "json"
+	FormatXML      = "xml"
+	FormatYAML     = "yaml"
+	FormatMsgPack  = "msgpack"
+	FormatProtoBuf = "protobuf"
+	FormatCSV      = "csv"    // 只用于 Export
+	FormatNDJSON   = "ndjson" // 只用于 Export
+)
+
+const (
+	MIMEProblemJSON = "application/problem+json"
+	MIMEMsgPack     = "application/msgpack"
+	MIMEXMsgPack    = "application/x-msgpack"
+	MIMECSV         = "text/csv"
+	MIMENDJSON      = "application/x-ndjson"
+
+	// EnvelopeContextKey 当前请求使用的包装风格，由 Envelope 中间件设置
+	EnvelopeContextKey = "envelope"
+)
+
+// bare 风格下分页信息的响应头
+const (
+	HeaderTotalCount  = "X-Total-Count"
+	HeaderCurrentPage = "X-Current-Page"
+	HeaderPageSize    = "X-Page-Size"
+	HeaderNextCursor  = "X-Next-Cursor"
+)
+
+var formatMIMEs = map[string][]string{
+	FormatJSON:     {binding.MIMEJSON},
+	FormatXML:      {binding.MIMEXML, binding.MIMEXML2},
+	FormatYAML:     {binding.MIMEYAML, binding.MIMEYAML2},
+	FormatMsgPack:  {MIMEMsgPack, MIMEXMsgPack},
+	FormatProtoBuf: {binding.MIMEPROTOBUF},
+	FormatCSV:      {MIMECSV},
+	FormatNDJSON:   {MIMENDJSON, "application/jsonl"},
+}
+
+// Problem RFC 7807 错误响应
+type Problem struct {
+	Type      string `json:"type" xml:"type" yaml:"type"`
+	Title     string `json:"title" xml:"title" yaml:"title"`
+	Status    int    `json:"status" xml:"status" yaml:"status"`
+	Detail    string `json:"detail,omitempty" xml:"detail,omitempty" yaml:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty" xml:"instance,omitempty" yaml:"instance,omitempty"`
+	Code      string `json:"code" xml:"code" yaml:"code"`
+	Errors    any    `json:"errors,omitempty" xml:"errors,omitempty" yaml:"errors,omitempty"` // 错误详情，如字段校验错误
+	RequestID string `json:"requestId,omitempty" xml:"requestId,omitempty" yaml:"requestId,omitempty"`
+	Error     string `json:"error,omitempty" xml:"error,omitempty" yaml:"error,omitempty"` // 原始错误信息，仅调试模式返回
+}
+
+var (
+	responseLock sync.RWMutex
+	responseCfg  = ResponseConfig{Envelope: EnvelopeOwl, ErrorFormat: ErrorFormatOwl}
+)
+
+// SetResponseConfig 设置响应的包装风格和内容协商，由路由服务根据 router.yaml 设置
+func SetResponseConfig(cfg ResponseConfig) {
+	if cfg.Envelope == "" {
+		cfg.Envelope = EnvelopeOwl
+	}
+	if cfg.ErrorFormat == "" {
+		cfg.ErrorFormat = ErrorFormatOwl
+	}
+	responseLock.Lock()
+	responseCfg = cfg
+	responseLock.Unlock()
+}
+
+func responseConfig() ResponseConfig {
+	responseLock.RLock()
+	defer responseLock.RUnlock()
+	return responseCfg
+}
+
+// Envelope 为路由组指定包装风格，如对外开放的接口直接返回数据
+//
+//	api := engine.Group("/open", router.Envelope(router.EnvelopeBare))
+func Envelope(style string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(EnvelopeContextKey, style)
+		c.Next()
+	}
+}
+
+func envelopeOf(ctx *gin.Context) string {
+	if style := ctx.GetString(EnvelopeContextKey); style != "" {
+		return style
+	}
+	return responseConfig().Envelope
+}
+
+// respond 按包装风格输出成功响应，bare 风格只输出 data，headers 为 bare 风格下的附加响应头
+func respond(ctx *gin.Context, status int, resp any, data any, headers map[string]string) {
+	if envelopeOf(ctx) != EnvelopeBare {
+		negotiate(ctx, status, resp)
+		return
+	}
+	for k, v := range headers {
+		ctx.Header(k, v)
+	}
+	negotiate(ctx, status, data)
+}
+
+// respondError 按错误格式输出错误响应，客户端接受 application/problem+json 时总是使用 RFC 7807 格式
+func respondError(ctx *gin.Context, status int, resp Resp) {
+	cfg := responseConfig()
+	if cfg.ErrorFormat != ErrorFormatProblem && !acceptsProblem(ctx) {
+		negotiate(ctx, status, resp)
+		return
+	}
+
+	problem := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Detail:   resp.Msg,
+		Instance: ctx.Request.URL.Path,
+		Code:     resp.Code,
+		Errors:   resp.Details,
+		Error:    resp.Error,
+	}
+	if cfg.ProblemTypeBase != "" {
+		problem.Type = strings.TrimSuffix(cfg.ProblemTypeBase, "/") + "/" + resp.Code
+	}
+	if requestID, ok := ctx.Get("request_id"); ok {
+		problem.RequestID, _ = requestID.(string)
+	}
+
+	format := negotiateFormat(ctx, problem)
+	if format == FormatJSON {
+		ctx.Header("Content-Type", MIMEProblemJSON)
+	}
+	renderFormat(ctx, status, format, problem)
+}
+
+func acceptsProblem(ctx *gin.Context) bool {
+	return strings.Contains(ctx.GetHeader("Accept"), MIMEProblemJSON)
+}
+
+// negotiate 根据 Accept 选择配置中允许的格式输出，没有匹配的格式时使用 JSON
+func negotiate(ctx *gin.Context, status int, body any) {
+	renderFormat(ctx, status, negotiateFormat(ctx, body), body)
+}
+
+func negotiateFormat(ctx *gin.Context, body any) string {
+	formats := responseConfig().Formats
+	if len(formats) <= 1 {
+		return FormatJSON
+	}
+
+	var offered []string
+	for _, format := range formats {
+		// protobuf 只能输出 proto.Message，通常为 bare 风格下的数据
+		if _, ok := body.(proto.Message); format == FormatProtoBuf && !ok {
+			continue
+		}
+		offered = append(offered, format)
+	}
+	return NegotiateFormat(ctx.GetHeader("Accept"), offered...)
+}
+
+func renderFormat(ctx *gin.Context, status int, format string, body any) {
+	switch format {
+	case FormatXML:
+		// encoding/xml 不支持 map 等类型，无法输出时使用 JSON，避免返回空的响应
+		data, err := xml.Marshal(body)
+		if err != nil {
+			ctx.JSON(status, body)
+			return
+		}
+		ctx.Data(status, binding.MIMEXML+"; charset=utf-8", data)
+	case FormatYAML:
+		ctx.YAML(status, body)
+	case FormatMsgPack:
+		ctx.Render(status, render.MsgPack{Data: body})
+	case FormatProtoBuf:
+		ctx.ProtoBuf(status, body)
+	default:
+		ctx.JSON(status, body)
+	}
+}
+
+// FieldErrorMap 字段校验错误，键为字段名，值为错误信息，XML 格式输出为 <field name="字段">错误信息</field>
+type FieldErrorMap map[string]string
+
+func (m FieldErrorMap) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	fields := make([]string, 0, len(m))
+	for field := range m {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, field := range fields {
+		el := xml.StartElement{Name: xml.Name{Local: "field"}, Attr: []xml.Attr{{Name: xml.Name{Local: "name"}, Value: field}}}
+		if err := e.EncodeElement(m[field], el); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// NegotiateFormat 按 Accept 中的权重从 offered 中选择格式，没有匹配时返回第一个格式
+// 与 gin 的 NegotiateFormat 不同，会按 q 参数排序后再匹配
+func NegotiateFormat(accept string, offered ...string) string {
+	if len(offered) == 0 {
+		return FormatJSON
+	}
+	if accept == "" {
+		return offered[0]
+	}
+
+	type mediaRange struct {
+		mime string
+		q    float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		r := mediaRange{mime: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		for _, p := range params[1:] {
+			if k, v, ok := strings.Cut(strings.TrimSpace(p), "="); ok && k == "q" {
+				r.q, _ = strconv.ParseFloat(v, 64)
+			}
+		}
+		if r.mime != "" && r.q > 0 {
+			ranges = append(ranges, r)
+		}
+	}
+	sort.SliceStable(ranges, func(a, b int) bool { return ranges[a].q > ranges[b].q })
+
+	for _, r := range ranges {
+		if r.mime == "*/*" || r.mime == "application/*" {
+			return offered[0]
+		}
+		for _, format := range offered {
+			for _, mime := range formatMIMEs[format] {
+				if r.mime == mime {
+					return format
+				}
+			}
+		}
+		if r.mime == MIMEProblemJSON {
+			return FormatJSON
+		}
+	}
+	return offered[0]
+}
@@ -0,0 +1,243 @@
+package router
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	logContract "bit-labs.cn/owl/contract/log"
+	"github.com/gin-gonic/gin"
+)
+
+func serve(engine *gin.Engine, path, accept string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, req)
+	return w
+}
+
+func TestNegotiateFormat(t *testing.T) {
+	offered := []string{FormatJSON, FormatXML, FormatYAML}
+	cases := map[string]string{
+		"":                FormatJSON,
+		"application/xml": FormatXML,
+		"text/html, application/yaml;q=0.5, application/json;q=0.8": FormatJSON,
+		"application/x-yaml, */*;q=0.1":                             FormatYAML,
+		"image/png":                                                 FormatJSON,
+		"application/problem+json":                                  FormatJSON,
+	}
+	for accept, want := range cases {
+		if got := NegotiateFormat(accept, offered...); got != want {
+			t.Errorf("NegotiateFormat(%q) = %s, want %s", accept, got, want)
+		}
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetResponseConfig(ResponseConfig{Formats: []string{FormatJSON, FormatXML}, ProblemTypeBase: "https://example.com/errors"})
+	defer SetResponseConfig(ResponseConfig{})
+
+	engine := gin.New()
+	engine.Use(ErrorHandler(nil))
+	engine.GET("/page", func(c *gin.Context) { PageSuccess(c, 12, 2, 5, []string{"a", "b"}) })
+	engine.GET("/xml", func(c *gin.Context) { Success(c, gin.H{"name": "owl"}) })
+	engine.GET("/error", func(c *gin.Context) { _ = c.Error(ErrNotFound) })
+	engine.GET("/validate", func(c *gin.Context) {
+		var req struct {
+			Name string `form:"name" binding:"required"`
+		}
+		_ = c.Error(c.ShouldBindQuery(&req))
+	})
+	engine.GET("/bad", func(c *gin.Context) { BadRequest(c, "bad", map[string]string{"phone": "格式错误"}) })
+	engine.GET("/rows", func(c *gin.Context) { PageSuccess(c, 1, 1, 10, []map[string]any{{"name": "owl"}}) })
+	bare := engine.Group("/open", Envelope(EnvelopeBare))
+	bare.GET("/page", func(c *gin.Context) { PageSuccess(c, 12, 2, 5, []string{"a", "b"}) })
+	engine.GET("/nocount", func(c *gin.Context) { PageSuccess(c, -1, 2, 5, []string{"a", "b"}) })
+	bare.GET("/nocount", func(c *gin.Context) { PageSuccess(c, -1, 2, 5, []string{"a", "b"}) })
+
+	w := serve(engine, "/page", "")
+	var page PageResp
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil || page.Total != 12 || page.Code != CodeOK {
+		t.Fatalf("owl page = %s, err = %v", w.Body.String(), err)
+	}
+
+	w = serve(engine, "/open/page", "")
+	if strings.TrimSpace(w.Body.String()) != `["a","b"]` || w.Header().Get(HeaderTotalCount) != "12" || w.Header().Get(HeaderCurrentPage) != "2" {
+		t.Fatalf("bare page = %s, headers = %v", w.Body.String(), w.Header())
+	}
+
+	// 未查询总数时不返回 total 和 X-Total-Count
+	w = serve(engine, "/nocount", "")
+	if strings.Contains(w.Body.String(), "total") || !strings.Contains(w.Body.String(), `"currentPage":2`) {
+		t.Fatalf("page without total = %s", w.Body.String())
+	}
+	w = serve(engine, "/open/nocount", "")
+	if _, ok := w.Header()[HeaderTotalCount]; ok || w.Header().Get(HeaderPageSize) != "5" {
+		t.Fatalf("bare page without total headers = %v", w.Header())
+	}
+
+	w = serve(engine, "/xml", "application/xml")
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "application/xml") || !strings.Contains(w.Body.String(), "<name>owl</name>") {
+		t.Fatalf("xml = %s", w.Body.String())
+	}
+
+	w = serve(engine, "/validate", "application/xml")
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `<field name="Name">`) {
+		t.Fatalf("xml validation = %d %s", w.Code, w.Body.String())
+	}
+	w = serve(engine, "/bad", "application/xml")
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `<field name="phone">格式错误</field>`) {
+		t.Fatalf("xml bad request = %d %s", w.Code, w.Body.String())
+	}
+	// encoding/xml 无法输出 map，使用 JSON
+	w = serve(engine, "/rows", "application/xml")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"list":[{"name":"owl"}]`) {
+		t.Fatalf("xml map rows = %d %s", w.Code, w.Body.String())
+	}
+
+	w = serve(engine, "/error", "application/problem+json")
+	var problem Problem
+	if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusNotFound || w.Header().Get("Content-Type") != MIMEProblemJSON ||
+		problem.Type != "https://example.com/errors/not_found" || problem.Status != http.StatusNotFound || problem.Instance != "/error" {
+		t.Fatalf("problem = %d %s %+v", w.Code, w.Header().Get("Content-Type"), problem)
+	}
+}
+
+func TestCSVValueFormula(t *testing.T) {
+	cases := map[any]string{
+		"=HYPERLINK(1)": "'=HYPERLINK(1)",
+		"+1":            "'+1",
+		"-1+2":          "'-1+2",
+		"@SUM(A1)":      "'@SUM(A1)",
+		"\tx":           "'\tx",
+		"\rx":           "'\rx",
+		"a=1":           "a=1",
+		-1:              "-1",
+	}
+	for v, want := range cases {
+		if got := csvValue(reflect.ValueOf(v)); got != want {
+			t.Errorf("csvValue(%q) = %q, want %q", v, got, want)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	type base struct {
+		ID        uint      `json:"id"`
+		CreatedAt time.Time `json:"createdAt"`
+	}
+	type user struct {
+		base
+		Name     string   `json:"name" csv:"姓名"`
+		Tags     []string `json:"tags"`
+		Password string   `json:"-"`
+	}
+	created := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	users := []user{
+		{base: base{ID: 1, CreatedAt: created}, Name: "张三", Tags: []string{"a"}},
+		{base: base{ID: 2}, Name: "Li, Si"},
+	}
+
+	engine := gin.New()
+	engine.GET("/export", func(c *gin.Context) {
+		Export(c, "用户", func(write func(items ...user) error) error {
+			for _, u := range users {
+				if err := write(u); err != nil {
+					return err
+				}
+			}
+			if c.Query("fail") != "" {
+				return errors.New("query failed")
+			}
+			return nil
+		})
+	})
+	engine.GET("/empty", func(c *gin.Context) {
+		Export(c, "", func(write func(items ...user) error) error { return nil })
+	})
+	engine.GET("/early", func(c *gin.Context) {
+		Export(c, "", func(write func(items ...user) error) error { return ErrForbidden })
+	})
+
+	w := serve(engine, "/export", "")
+	want := "\xEF\xBB\xBFid,createdAt,姓名,tags\n1,2024-05-01 08:00:00,张三,\"[\"\"a\"\"]\"\n2,,\"Li, Si\",null\n"
+	if w.Body.String() != want {
+		t.Errorf("csv = %q, want %q", w.Body.String(), want)
+	}
+	if !strings.Contains(w.Header().Get("Content-Disposition"), "filename*=UTF-8''%E7%94%A8%E6%88%B7.csv") {
+		t.Errorf("Content-Disposition = %s", w.Header().Get("Content-Disposition"))
+	}
+
+	w = serve(engine, "/export?format=ndjson&fail=1", "")
+	lines := strings.Split(strings.TrimSpace(w.Body.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[2], `"error"`) {
+		t.Errorf("ndjson = %q", w.Body.String())
+	}
+
+	w = serve(engine, "/empty", "application/json")
+	if w.Body.String() != "[]" {
+		t.Errorf("empty json = %q", w.Body.String())
+	}
+
+	w = serve(engine, "/early", "application/json")
+	if w.Code != http.StatusForbidden {
+		t.Errorf("early error status = %d", w.Code)
+	}
+}
+
+type errorLogger struct {
+	logContract.Logger
+	errors []string
+}
+
+func (i *errorLogger) Error(content ...interface{}) {
+	i.errors = append(i.errors, fmt.Sprint(content...))
+}
+
+func TestFailLogsServerErrors(t *testing.T) {
+	gin.SetMode(gin.ReleaseMode)
+	defer gin.SetMode(gin.TestMode)
+
+	logger := &errorLogger{}
+	engine := gin.New()
+	engine.Use(ErrorHandler(logger))
+	engine.GET("/internal", func(c *gin.Context) { InternalError(c, errors.New("connection refused")) })
+	engine.GET("/unavailable", func(c *gin.Context) {
+		Fail(c, NewError(http.StatusServiceUnavailable, CodeInternal, "支付渠道暂不可用").WithDetails(gin.H{"retryable": true}))
+	})
+	engine.GET("/missing", func(c *gin.Context) { Fail(c, ErrNotFound) })
+
+	// 客户端看不到原始错误，但日志中记录
+	w := serve(engine, "/internal", "")
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "connection refused") {
+		t.Fatalf("internal = %d %s", w.Code, w.Body.String())
+	}
+	if len(logger.errors) != 1 || !strings.Contains(logger.errors[0], "connection refused") {
+		t.Fatalf("logs = %v", logger.errors)
+	}
+
+	// 应用主动设置的 Details 保留
+	w = serve(engine, "/unavailable", "")
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), `"retryable":true`) {
+		t.Fatalf("unavailable = %d %s", w.Code, w.Body.String())
+	}
+
+	serve(engine, "/missing", "")
+	if len(logger.errors) != 2 {
+		t.Fatalf("4xx should not be logged, logs = %v", logger.errors)
+	}
+}
@@ -2,6 +2,7 @@ package router
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -11,6 +12,8 @@ type Resp struct {
 	Msg     string `json:"msg"`
 	Success bool   `json:"success"`
 	Data    any    `json:"data,omitempty"`
+	Details any    `json:"details,omitempty"` // 错误详情，如字段校验错误
+	Error   string `json:"error,omitempty"`   // 原始错误信息，仅调试模式返回
 }
 
 type PageResp struct {
@@ -21,35 +24,120 @@ type PageResp struct {
 }
 
 type PageReq struct {
-	Page     int `json:"page"`
-	PageSize int `json:"pageSize"`
+	Page     int `json:"page" form:"page"`
+	PageSize int `json:"pageSize" form:"pageSize"`
 }
 
+// PageWithoutTotalResp 不包含总数的分页响应，用于不查询总数的列表
+type PageWithoutTotalResp struct {
+	Resp
+	CurrentPage int `json:"currentPage"`
+	PageSize    int `json:"pageSize"`
+}
+
+// CursorResp 游标分页响应，nextCursor 为空时没有下一页
+type CursorResp struct {
+	Resp
+	NextCursor string `json:"nextCursor"`
+	HasMore    bool   `json:"hasMore"`
+	PageSize   int    `json:"pageSize"`
+}
+
+// CursorReq 游标分页请求，cursor 为上一页响应中的 nextCursor，第一页不传
+type CursorReq struct {
+	Cursor   string `json:"cursor" form:"cursor"`
+	PageSize int    `json:"pageSize" form:"pageSize"`
+}
+
+// message 按请求的语言翻译提示信息，msg 可以是消息键，没有对应的翻译时原样返回
+func message(ctx *gin.Context, key, fallback string) string {
+	translatorLock.RLock()
+	translate := messageTranslator
+	translatorLock.RUnlock()
+	if translate == nil || key == "" {
+		return fallback
+	}
+	return translate(ctx, key, fallback)
+}
+
+// Success 返回成功响应，包装风格和输出格式见 router.yaml 的 response 配置
 func Success(ctx *gin.Context, data any) {
-	ctx.JSON(http.StatusOK, Resp{Success: true, Msg: "操作成功", Data: data})
+	respond(ctx, http.StatusOK, Resp{Code: CodeOK, Success: true, Msg: message(ctx, "response.success", "操作成功"), Data: data}, data, nil)
 }
 
 func SuccessWithMsg(ctx *gin.Context, msg string, data any) {
-	ctx.JSON(http.StatusOK, Resp{Success: true, Msg: msg, Data: data})
+	respond(ctx, http.StatusOK, Resp{Code: CodeOK, Success: true, Msg: message(ctx, msg, msg), Data: data}, data, nil)
 }
 
-func BadRequest(ctx *gin.Context, msg string) {
-	ctx.JSON(http.StatusBadRequest, Resp{Success: false, Msg: msg})
+// BadRequest 返回 400，fields 为字段级错误，如 FieldErrors 的返回值
+func BadRequest(ctx *gin.Context, msg string, fields ...map[string]string) {
+	resp := Resp{Code: CodeBadRequest, Success: false, Msg: message(ctx, msg, msg)}
+	if len(fields) > 0 && fields[0] != nil {
+		resp.Code = CodeValidation
+		resp.Details = FieldErrorMap(fields[0])
+	}
+	respondError(ctx, http.StatusBadRequest, resp)
 }
 
 func Forbidden(ctx *gin.Context, msg string) {
-	ctx.JSON(http.StatusForbidden, Resp{Success: false, Msg: msg})
+	respondError(ctx, http.StatusForbidden, Resp{Code: CodeForbidden, Success: false, Msg: message(ctx, msg, msg)})
 }
 
+func Conflict(ctx *gin.Context, msg string) {
+	respondError(ctx, http.StatusConflict, Resp{Code: CodeConflict, Success: false, Msg: message(ctx, msg, msg)})
+}
+
+// StatusError 携带 HTTP 状态码的错误，如乐观锁冲突返回 409
+type StatusError interface {
+	error
+	HTTPStatus() int
+}
+
+// InternalError 返回错误响应，与 Fail 相同，非调试模式下不返回 5xx 错误的原始信息
 func InternalError(ctx *gin.Context, err error) {
-	ctx.JSON(http.StatusInternalServerError, Resp{Success: false, Msg: err.Error()})
+	Fail(ctx, err)
 }
 
+// PageSuccess 返回分页响应，bare 风格下只返回列表，分页信息放在 X-Total-Count 等响应头中
+// total 小于 0 表示没有查询总数，如 db.BaseRepository 的 WithoutCount，此时按 PageSuccessWithoutTotal 返回
 func PageSuccess(ctx *gin.Context, total int, currentPage int, pageSize int, data any) {
-	ctx.JSON(http.StatusOK, PageResp{
-		Resp:        Resp{Success: true, Msg: "操作成功", Data: gin.H{"list": data}},
+	if total < 0 {
+		PageSuccessWithoutTotal(ctx, currentPage, pageSize, data)
+		return
+	}
+	respond(ctx, http.StatusOK, PageResp{
+		Resp:        Resp{Code: CodeOK, Success: true, Msg: message(ctx, "response.success", "操作成功"), Data: gin.H{"list": data}},
 		Total:       total,
 		CurrentPage: currentPage,
 		PageSize:    pageSize,
+	}, data, map[string]string{
+		HeaderTotalCount:  strconv.Itoa(total),
+		HeaderCurrentPage: strconv.Itoa(currentPage),
+		HeaderPageSize:    strconv.Itoa(pageSize),
+	})
+}
+
+// PageSuccessWithoutTotal 返回不包含总数的分页响应，bare 风格下不设置 X-Total-Count 响应头
+func PageSuccessWithoutTotal(ctx *gin.Context, currentPage int, pageSize int, data any) {
+	respond(ctx, http.StatusOK, PageWithoutTotalResp{
+		Resp:        Resp{Code: CodeOK, Success: true, Msg: message(ctx, "response.success", "操作成功"), Data: gin.H{"list": data}},
+		CurrentPage: currentPage,
+		PageSize:    pageSize,
+	}, data, map[string]string{
+		HeaderCurrentPage: strconv.Itoa(currentPage),
+		HeaderPageSize:    strconv.Itoa(pageSize),
+	})
+}
+
+// CursorSuccess 返回游标分页响应，bare 风格下只返回列表，下一页游标放在 X-Next-Cursor 响应头中
+func CursorSuccess(ctx *gin.Context, nextCursor string, pageSize int, data any) {
+	respond(ctx, http.StatusOK, CursorResp{
+		Resp:       Resp{Code: CodeOK, Success: true, Msg: message(ctx, "response.success", "操作成功"), Data: gin.H{"list": data}},
+		NextCursor: nextCursor,
+		HasMore:    nextCursor != "",
+		PageSize:   pageSize,
+	}, data, map[string]string{
+		HeaderNextCursor: nextCursor,
+		HeaderPageSize:   strconv.Itoa(pageSize),
 	})
 }
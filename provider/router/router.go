@@ -6,6 +6,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,17 +20,30 @@ const (
 	AccessSuperAdmin    AccessLevel = "仅超管"
 )
 
+// AccessLevelContextKey 请求上下文中当前路由访问级别的键，数据权限等功能据此判断是否为超管接口
+const AccessLevelContextKey = "access_level"
+
+// MethodAny 通过 RouterInfoBuilder.Any 注册的路由的请求方式
+const MethodAny = "ANY"
+
+// engineLock 保护路由注册，gin 的路由树不支持并发写入
+var engineLock sync.Mutex
+
 type RouterInfo struct {
-	Group            string          `json:"group"`            // 接口分组
-	Method           string          `json:"method"`           // 请求方式
-	Path             string          `json:"path"`             // 路由路径
-	PathWithoutGroup string          `json:"pathWithoutGroup"` // 路由路径（不包含分组）
-	Name             string          `json:"name"`             // 路由名称
-	Module           string          `json:"module"`           // 模块名称
-	Permission       string          `json:"permission"`       // 权限标识
-	Description      string          `json:"description"`      // 描述
-	AccessLevel      AccessLevel     `json:"accessLevel"`      // 访问级别
+	Group            string          `json:"group"`               // 接口分组
+	Method           string          `json:"method"`              // 请求方式
+	Path             string          `json:"path"`                // 路由路径
+	PathWithoutGroup string          `json:"pathWithoutGroup"`    // 路由路径（不包含分组）
+	Name             string          `json:"name"`                // 路由名称
+	Module           string          `json:"module"`              // 模块名称
+	Permission       string          `json:"permission"`          // 权限标识
+	Description      string          `json:"description"`         // 描述
+	AccessLevel      AccessLevel     `json:"accessLevel"`         // 访问级别
+	RouteName        string          `json:"routeName,omitempty"` // 路由名称，用于 URL 生成
+	Version          string          `json:"version,omitempty"`   // API 版本
+	Realtime         string          `json:"realtime,omitempty"`  // 实时接口的传输方式，sse 或 ws
 	handle           gin.HandlerFunc // 处理函数
+	origin           any             // 用于生成权限标识的处理函数，为空时使用 handle
 	operateLog       bool            // 是否记录操作日志
 }
 
@@ -59,22 +73,44 @@ func GetAllRoutes() []RouterInfo {
 	return result
 }
 
+// 全局中间件注册表，服务提供者在 Register 中注册，路由引擎创建时挂载在内置中间件之后
+var (
+	middlewaresLock    sync.RWMutex
+	registeredHandlers []gin.HandlerFunc
+)
+
+// RegisterMiddleware 注册全局中间件，需在路由引擎创建之前调用
+func RegisterMiddleware(handlers ...gin.HandlerFunc) {
+	middlewaresLock.Lock()
+	registeredHandlers = append(registeredHandlers, handlers...)
+	middlewaresLock.Unlock()
+}
+
+func registeredMiddlewares() []gin.HandlerFunc {
+	middlewaresLock.RLock()
+	defer middlewaresLock.RUnlock()
+	return append([]gin.HandlerFunc{}, registeredHandlers...)
+}
+
 type Dep struct {
 	Handler Handler
 	Method  gin.HandlerFunc
 }
-type RouterInfoBuilder struct {
-	menu     *Menu
-	moduleEn string // 模块英文名称
-	moduleZh string // 模块中文名称
-	appName  string
-	router   *gin.RouterGroup
-	handler  Handler // 定义 handler，可以获取 handler 的 moduleName
-	lock     sync.RWMutex
 
-	currentRouter            *RouterInfo
-	currentRouterMiddlewares []gin.HandlerFunc
-	deps                     []Dep
+// RouterInfoBuilder 模块的路由构建器，Get、Post 等方法返回单个路由的 RouteBuilder，调用 Build 后生效
+// Group、Version、WithAccess、Timeout 返回共享菜单的副本，原构建器不受影响
+type RouterInfoBuilder struct {
+	menu        *Menu
+	menuLock    *sync.Mutex // 分组共享菜单
+	moduleEn    string      // 模块英文名称
+	moduleZh    string      // 模块中文名称
+	appName     string
+	router      *gin.RouterGroup
+	handler     Handler        // 定义 handler，可以获取 handler 的 moduleName
+	accessLevel AccessLevel    // 路由未指定访问级别时使用
+	version     string         // API 版本
+	timeout     *time.Duration // 路由未指定处理超时时间时使用
+	middlewares []gin.HandlerFunc
 }
 
 type MenuOption struct {
@@ -91,6 +127,7 @@ func NewRouteInfoBuilder(appName string, handle Handler, router *gin.RouterGroup
 		router:   router,
 		handler:  handle,
 		appName:  appName,
+		menuLock: &sync.Mutex{},
 		menu: &Menu{
 			Name: meta.ComponentName,
 			Path: meta.Path,
@@ -105,109 +142,279 @@ func NewRouteInfoBuilder(appName string, handle Handler, router *gin.RouterGroup
 	}
 }
 
-func (i *RouterInfoBuilder) add(method, path string, accessLevel AccessLevel, handle gin.HandlerFunc) *RouterInfoBuilder {
-	i.lock.Lock()
-	i.currentRouter = &RouterInfo{
-		Group:            i.router.BasePath(),
-		Method:           method,
-		Path:             i.router.BasePath() + path,
-		PathWithoutGroup: path,
-		Module:           i.moduleZh,
-		AccessLevel:      accessLevel,
-		handle:           handle,
-		operateLog:       method != http.MethodGet,
-	}
+// Group 返回子分组的构建器，handlers 为分组共享的中间件，路由的权限、菜单仍属于当前模块
+//
+//	admin := b.Group("/admin", auditLog).WithAccess(router.AccessSuperAdmin)
+//	admin.Post("/reset", "", h.Reset).Name("重置").Build()
+func (i *RouterInfoBuilder) Group(path string, handlers ...gin.HandlerFunc) *RouterInfoBuilder {
+	c := *i
+	c.router = i.router.Group(path, handlers...)
+	return &c
+}
 
+// WithAccess 返回指定默认访问级别的副本，路由的访问级别为空时使用
+func (i *RouterInfoBuilder) WithAccess(accessLevel AccessLevel) *RouterInfoBuilder {
+	c := *i
+	c.accessLevel = accessLevel
+	return &c
+}
+
+// Timeout 返回指定处理超时时间的副本，覆盖 router.yaml 中的 limit.timeout，0 不限制
+func (i *RouterInfoBuilder) Timeout(d time.Duration) *RouterInfoBuilder {
+	c := *i
+	c.timeout = &d
+	return &c
+}
+
+// Use 为当前构建器添加中间件，作用于之后构建的路由，以及之后创建的 Group、Version 等副本
+func (i *RouterInfoBuilder) Use(handle ...gin.HandlerFunc) *RouterInfoBuilder {
+	// 重新分配，避免与已创建的副本共用底层数组
+	i.middlewares = append(append([]gin.HandlerFunc{}, i.middlewares...), handle...)
 	return i
 }
 
+// Version 返回指定 API 版本的构建器，路由注册在 /{version} 下，如 /api/v1/users
+// 请求头 X-API-Version 或 Accept-Version 指定版本时，不带版本的路径同样可以访问，见 VersionHandler
+func (i *RouterInfoBuilder) Version(version string) *RouterInfoBuilder {
+	c := *i
+	c.version = version
+	c.router = i.router.Group("/" + version)
+	registerVersionBase(i.router.BasePath(), version)
+	return &c
+}
+
+func (i *RouterInfoBuilder) add(method, path string, accessLevel AccessLevel, handle gin.HandlerFunc) *RouteBuilder {
+	if accessLevel == "" {
+		accessLevel = i.accessLevel
+	}
+	readOnly := method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+	return &RouteBuilder{
+		parent: i,
+		info: RouterInfo{
+			Group:            i.router.BasePath(),
+			Method:           method,
+			Path:             joinPaths(i.router.BasePath(), path),
+			PathWithoutGroup: path,
+			Module:           i.moduleZh,
+			AccessLevel:      accessLevel,
+			Version:          i.version,
+			handle:           handle,
+			operateLog:       !readOnly,
+		},
+		middlewares: append([]gin.HandlerFunc{}, i.middlewares...),
+	}
+}
+
+func (i *RouteBuilder) handleTimeout() time.Duration {
+	switch {
+	case i.timeout != nil:
+		return *i.timeout
+	case i.parent.timeout != nil:
+		return *i.parent.timeout
+	}
+	return time.Duration(limitConfig().Timeout) * time.Second
+}
+
 func (i *RouterInfoBuilder) getPermissionStr(funcName string) string {
 	return i.appName + ":" + i.moduleEn + ":" + funcName
 }
 
-// Build 构建路由,以及菜单
-func (i *RouterInfoBuilder) Build() {
-	defer i.lock.Unlock()
+func (i *RouterInfoBuilder) Post(path string, accessLevel AccessLevel, handle gin.HandlerFunc) *RouteBuilder {
+	return i.add(http.MethodPost, path, accessLevel, handle)
+}
+func (i *RouterInfoBuilder) Put(path string, accessLevel AccessLevel, handle gin.HandlerFunc) *RouteBuilder {
+	return i.add(http.MethodPut, path, accessLevel, handle)
+}
+func (i *RouterInfoBuilder) Delete(path string, accessLevel AccessLevel, handle gin.HandlerFunc) *RouteBuilder {
+	return i.add(http.MethodDelete, path, accessLevel, handle)
+}
+func (i *RouterInfoBuilder) Get(path string, accessLevel AccessLevel, handle gin.HandlerFunc) *RouteBuilder {
+	return i.add(http.MethodGet, path, accessLevel, handle)
+}
+func (i *RouterInfoBuilder) Patch(path string, accessLevel AccessLevel, handle gin.HandlerFunc) *RouteBuilder {
+	return i.add(http.MethodPatch, path, accessLevel, handle)
+}
+func (i *RouterInfoBuilder) Head(path string, accessLevel AccessLevel, handle gin.HandlerFunc) *RouteBuilder {
+	return i.add(http.MethodHead, path, accessLevel, handle)
+}
+func (i *RouterInfoBuilder) Options(path string, accessLevel AccessLevel, handle gin.HandlerFunc) *RouteBuilder {
+	return i.add(http.MethodOptions, path, accessLevel, handle)
+}
 
-	// 设置路由授权标识，前端可用于控制按钮的显示
-	handleName := nameOfFunction(i.currentRouter.handle)
-	i.currentRouter.Permission = i.getPermissionStr(handleName)
+// Any 注册所有请求方式，路由信息中的请求方式为 ANY
+func (i *RouterInfoBuilder) Any(path string, accessLevel AccessLevel, handle gin.HandlerFunc) *RouteBuilder {
+	return i.add(MethodAny, path, accessLevel, handle)
+}
 
-	var permissions []string
-	permissions = append(permissions, i.currentRouter.Permission)
+// SSE 注册 Server-Sent Events 接口，权限、菜单与普通接口相同，handler 的说明见 RealtimeHandler
+func (i *RouterInfoBuilder) SSE(path string, accessLevel AccessLevel, handle RealtimeHandler) *RouteBuilder {
+	return i.realtime(RealtimeSSE, path, accessLevel, handle)
+}
 
-	// 设置接口依赖，完成一个动作可能会需要很多个接口配合
-	for _, dep := range i.deps {
-		funcName := nameOfFunction(dep.Method)
-		en, _ := dep.Handler.ModuleName()
-		permission := i.appName + ":" + en + ":" + funcName
-		permissions = append(permissions, permission)
-	}
-	i.deps = nil // 清理依赖
+// WS 注册 WebSocket 接口，权限、菜单与普通接口相同，客户端消息通过 conn.OnMessage 处理
+func (i *RouterInfoBuilder) WS(path string, accessLevel AccessLevel, handle RealtimeHandler) *RouteBuilder {
+	return i.realtime(RealtimeWS, path, accessLevel, handle)
+}
 
-	// 构建菜单（按钮）
-	if i.currentRouter.AccessLevel == AccessAuthorized {
-		i.menu.Children = append(i.menu.Children, &Menu{
-			Name: handleName,
-			Meta: Meta{
-				Title: i.currentRouter.Name,
-			},
-			MenuType:             MenuTypeBtn,
-			DependentsPermission: permissions,
-		})
+func (i *RouterInfoBuilder) realtime(transport, path string, accessLevel AccessLevel, handle RealtimeHandler) *RouteBuilder {
+	serve := serveSSE(handle)
+	if transport == RealtimeWS {
+		serve = serveWS(handle)
 	}
-	// 添加路由
-	handlers := append(i.currentRouterMiddlewares, i.currentRouter.handle)
-	i.router.Handle(i.currentRouter.Method, i.currentRouter.PathWithoutGroup, handlers...)
-	i.currentRouterMiddlewares = nil
+	r := i.add(http.MethodGet, path, accessLevel, serve)
+	r.info.Realtime = transport
+	r.info.origin = handle
+	return r
+}
 
-	// 保存路由到全局注册表
-	RegisterRoute(i.currentRouter)
+// Crud 注册增删改查的五个接口，接口需要授权，同时生成对应的菜单按钮，修改接口依赖详情接口
+//
+//	GET path 列表，GET path/:id 详情，POST path 创建，PUT path/:id 修改，DELETE path/:id 删除
+func (i *RouterInfoBuilder) Crud(path string, handler CrudHandler) *RouterInfoBuilder {
+	path = strings.TrimSuffix(path, "/")
+	i.Get(path, AccessAuthorized, handler.Retrieve).Name(i.moduleZh + "列表").Build()
+	i.Get(path+"/:id", AccessAuthorized, handler.Detail).Name(i.moduleZh + "详情").Build()
+	i.Post(path, AccessAuthorized, handler.Create).Name("创建" + i.moduleZh).Build()
+	i.Put(path+"/:id", AccessAuthorized, handler.Update).Name("修改" + i.moduleZh).
+		Deps(Dep{Handler: i.handler, Method: handler.Detail}).Build()
+	i.Delete(path+"/:id", AccessAuthorized, handler.Delete).Name("删除" + i.moduleZh).Build()
+	return i
+}
+
+// RouteBuilder 单个路由的构建器，调用 Build 后注册到路由引擎，未调用 Build 的路由不会生效
+type RouteBuilder struct {
+	parent      *RouterInfoBuilder
+	info        RouterInfo
+	middlewares []gin.HandlerFunc
+	deps        []Dep
+	timeout     *time.Duration
+	bodyLimit   *int64
+}
+
+func (i *RouteBuilder) Description(d string) *RouteBuilder {
+	i.info.Description = d
+	return i
 }
 
-func (i *RouterInfoBuilder) Description(d string) *RouterInfoBuilder {
-	i.currentRouter.Description = d
+func (i *RouteBuilder) Name(n string) *RouteBuilder {
+	i.info.Name = n
 	return i
 }
 
-func (i *RouterInfoBuilder) Name(n string) *RouterInfoBuilder {
-	i.currentRouter.Name = n
+// As 设置路由名称，用于 URL 生成，名称全局唯一，如 user.detail
+func (i *RouteBuilder) As(routeName string) *RouteBuilder {
+	i.info.RouteName = routeName
 	return i
 }
 
-func (i *RouterInfoBuilder) WithoutOperateLog() *RouterInfoBuilder {
-	i.currentRouter.operateLog = false
+func (i *RouteBuilder) WithoutOperateLog() *RouteBuilder {
+	i.info.operateLog = false
 	return i
 }
 
-func (i *RouterInfoBuilder) WithOperateLog() *RouterInfoBuilder {
-	i.currentRouter.operateLog = true
+func (i *RouteBuilder) WithOperateLog() *RouteBuilder {
+	i.info.operateLog = true
 	return i
 }
 
 // Deps 此接口依赖的其他接口，比如说修改用户，则需要 `获取用户详情` `更新用户`  两个接口
-func (i *RouterInfoBuilder) Deps(dep ...Dep) *RouterInfoBuilder {
+func (i *RouteBuilder) Deps(dep ...Dep) *RouteBuilder {
 	i.deps = append(i.deps, dep...)
 	return i
 }
-func (i *RouterInfoBuilder) Post(path string, accessLevel AccessLevel, handle gin.HandlerFunc) *RouterInfoBuilder {
-	return i.add(http.MethodPost, path, accessLevel, handle)
-}
-func (i *RouterInfoBuilder) Put(path string, accessLevel AccessLevel, handle gin.HandlerFunc) *RouterInfoBuilder {
-	return i.add(http.MethodPut, path, accessLevel, handle)
-}
-func (i *RouterInfoBuilder) Delete(path string, accessLevel AccessLevel, handle gin.HandlerFunc) *RouterInfoBuilder {
-	return i.add(http.MethodDelete, path, accessLevel, handle)
+
+// Use 此路由的中间件，在分组中间件之后执行
+func (i *RouteBuilder) Use(handle ...gin.HandlerFunc) *RouteBuilder {
+	i.middlewares = append(i.middlewares, handle...)
+	return i
 }
-func (i *RouterInfoBuilder) Get(path string, accessLevel AccessLevel, handle gin.HandlerFunc) *RouterInfoBuilder {
-	return i.add(http.MethodGet, path, accessLevel, handle)
+
+// Timeout 此路由的处理超时时间，覆盖分组和 router.yaml 中的设置，0 不限制，如导出接口
+func (i *RouteBuilder) Timeout(d time.Duration) *RouteBuilder {
+	i.timeout = &d
+	return i
 }
 
-func (i *RouterInfoBuilder) Use(handle ...gin.HandlerFunc) *RouterInfoBuilder {
-	i.currentRouterMiddlewares = append(i.currentRouterMiddlewares, handle...)
+// BodyLimit 此路由的请求体最大字节数，覆盖 router.yaml 中的 limit.max-body-size，0 不限制
+func (i *RouteBuilder) BodyLimit(limit int64) *RouteBuilder {
+	i.bodyLimit = &limit
 	return i
 }
 
+// Build 构建路由,以及菜单
+func (i *RouteBuilder) Build() {
+	b := i.parent
+	info := i.info
+
+	// 设置路由授权标识，前端可用于控制按钮的显示
+	var handleName string
+	if info.origin != nil {
+		handleName = nameOfFunction(info.origin)
+	} else {
+		handleName = nameOfFunction(info.handle)
+	}
+	info.Permission = b.getPermissionStr(handleName)
+
+	var permissions []string
+	permissions = append(permissions, info.Permission)
+
+	// 设置接口依赖，完成一个动作可能会需要很多个接口配合
+	for _, dep := range i.deps {
+		funcName := nameOfFunction(dep.Method)
+		en, _ := dep.Handler.ModuleName()
+		permission := b.appName + ":" + en + ":" + funcName
+		permissions = append(permissions, permission)
+	}
+
+	// 构建菜单（按钮）
+	if info.AccessLevel == AccessAuthorized {
+		b.menuLock.Lock()
+		b.menu.Children = append(b.menu.Children, &Menu{
+			Name: handleName,
+			Meta: Meta{
+				Title: info.Name,
+			},
+			MenuType:             MenuTypeBtn,
+			DependentsPermission: permissions,
+		})
+		b.menuLock.Unlock()
+	}
+
+	if info.RouteName != "" {
+		registerNamedRoute(info.RouteName, info.Path)
+	}
+
+	// 添加路由
+	accessLevel, version := info.AccessLevel, info.Version
+	handlers := []gin.HandlerFunc{func(ctx *gin.Context) {
+		ctx.Set(AccessLevelContextKey, accessLevel)
+		if version != "" {
+			ctx.Set(VersionContextKey, version)
+		}
+	}}
+	if i.bodyLimit != nil {
+		handlers = append(handlers, BodyLimit(*i.bodyLimit))
+	}
+	// SSE、WebSocket 为长连接，不限制处理时间
+	if timeout := i.handleTimeout(); timeout > 0 && info.Realtime == "" {
+		handlers = append(handlers, Timeout(timeout))
+	}
+	handlers = append(handlers, i.middlewares...)
+	handlers = append(handlers, info.handle)
+
+	// gin 的路由树不支持并发写入
+	engineLock.Lock()
+	if info.Method == MethodAny {
+		b.router.Any(info.PathWithoutGroup, handlers...)
+	} else {
+		b.router.Handle(info.Method, info.PathWithoutGroup, handlers...)
+	}
+	engineLock.Unlock()
+
+	// 保存路由到全局注册表
+	RegisterRoute(&info)
+}
+
 func (i *RouterInfoBuilder) GetMenu() *Menu {
 	return i.menu
 }
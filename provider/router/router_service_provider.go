@@ -71,12 +71,13 @@ func (i *RouterServiceProvider) Run() {
 	}
 
 	i.srv = &http.Server{
-		Addr:           addr,
-		Handler:        i.engine,
-		ReadTimeout:    time.Duration(i.serverCfg.ReadTimeout) * time.Second,
-		WriteTimeout:   time.Duration(i.serverCfg.WriteTimeout) * time.Second,
-		IdleTimeout:    time.Duration(i.serverCfg.IdleTimeout) * time.Second,
-		MaxHeaderBytes: i.serverCfg.MaxHeaderBytes,
+		Addr:              addr,
+		Handler:           VersionHandler(i.engine),
+		ReadTimeout:       time.Duration(i.serverCfg.ReadTimeout) * time.Second,
+		ReadHeaderTimeout: time.Duration(i.serverCfg.ReadHeaderTimeout) * time.Second,
+		WriteTimeout:      time.Duration(i.serverCfg.WriteTimeout) * time.Second,
+		IdleTimeout:       time.Duration(i.serverCfg.IdleTimeout) * time.Second,
+		MaxHeaderBytes:    i.serverCfg.MaxHeaderBytes,
 	}
 	i.logger.Info("启动 HTTP 服务器", "监听", addr)
 	if err := i.srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -95,13 +96,14 @@ func (i *RouterServiceProvider) serveTLS(addr string) {
 	}
 
 	i.srv = &http.Server{
-		Addr:           addr,
-		Handler:        i.engine,
-		ReadTimeout:    time.Duration(i.serverCfg.ReadTimeout) * time.Second,
-		WriteTimeout:   time.Duration(i.serverCfg.WriteTimeout) * time.Second,
-		IdleTimeout:    time.Duration(i.serverCfg.IdleTimeout) * time.Second,
-		MaxHeaderBytes: i.serverCfg.MaxHeaderBytes,
-		TLSConfig:      tlsCfg,
+		Addr:              addr,
+		Handler:           VersionHandler(i.engine),
+		ReadTimeout:       time.Duration(i.serverCfg.ReadTimeout) * time.Second,
+		ReadHeaderTimeout: time.Duration(i.serverCfg.ReadHeaderTimeout) * time.Second,
+		WriteTimeout:      time.Duration(i.serverCfg.WriteTimeout) * time.Second,
+		IdleTimeout:       time.Duration(i.serverCfg.IdleTimeout) * time.Second,
+		MaxHeaderBytes:    i.serverCfg.MaxHeaderBytes,
+		TLSConfig:         tlsCfg,
 	}
 
 	i.logger.Info("启动 HTTPS 服务器", "addr", addr)
@@ -182,6 +184,9 @@ func (i *RouterServiceProvider) BuildEngine() *gin.Engine {
 
 	// 创建 Gin 引擎
 	i.engine = gin.New()
+	SetResponseConfig(i.opt.Response)
+	SetRealtimeConfig(i.opt.Realtime)
+	SetLimitConfig(i.opt.Limit)
 
 	i.setupMiddleware()
 	i.setupStatic()
@@ -196,7 +201,11 @@ func (i *RouterServiceProvider) BuildEngine() *gin.Engine {
 
 func (i *RouterServiceProvider) setupMiddleware() {
 	i.engine.Use(middleware.RequestID())
-	i.engine.Use(middleware.Recovery(i.logger))
+	i.engine.Use(middleware.StreamToken())
+	i.engine.Use(middleware.Recovery(i.logger, Fail))
+	i.engine.Use(ErrorHandler(i.logger))
+	i.engine.Use(BodyLimit(i.opt.Limit.MaxBodySize))
+	i.engine.Use(SlowClient(time.Duration(i.opt.Limit.BodyReadTimeout) * time.Second))
 
 	if i.opt.Middleware.Logger {
 		i.engine.Use(middleware.AccessLog(i.logger, middleware.AccessLogConfig{
@@ -235,6 +244,9 @@ func (i *RouterServiceProvider) setupMiddleware() {
 		HstsMaxAge:         i.opt.Security.HstsMaxAge,
 	}
 	i.engine.Use(middleware.Security(securityConfig))
+
+	// 其他服务提供者注册的全局中间件
+	i.engine.Use(registeredMiddlewares()...)
 }
 
 func (i *RouterServiceProvider) setupStatic() {
@@ -288,3 +300,9 @@ func (i *RouterServiceProvider) Conf() map[string]string {
 		"router.yaml": routerYaml,
 	}
 }
+
+func (i *RouterServiceProvider) ConfSchema() map[string]any {
+	return map[string]any{
+		"router.yaml": &RouterOptions{},
+	}
+}
@@ -0,0 +1,128 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type articleHandle struct{}
+
+func (articleHandle) ModuleName() (string, string) { return "article", "文章" }
+
+func (articleHandle) Detail(ctx *gin.Context) {
+	level, _ := ctx.Get(AccessLevelContextKey)
+	ctx.String(http.StatusOK, "%s %s %v %s", ctx.Request.Method, ctx.Param("id"), level, ctx.GetString(VersionContextKey))
+}
+
+func (articleHandle) Reset(ctx *gin.Context) {
+	ctx.String(http.StatusOK, "%s %s", ctx.GetString("group"), ctx.GetString("route"))
+}
+
+func serveRoute(handler http.Handler, method, target string, header map[string]string) string {
+	req := httptest.NewRequest(method, target, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		return http.StatusText(w.Code)
+	}
+	return w.Body.String()
+}
+
+func TestRouterInfoBuilder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	h := articleHandle{}
+	b := NewRouteInfoBuilder("app", h, engine.Group("/api"), MenuOption{})
+
+	// 未调用 Build 的路由不影响后续路由
+	b.Get("/articles/:id", AccessPublic, h.Detail)
+	b.Patch("/articles/:id", AccessAuthorized, h.Detail).Name("修改文章").Build()
+	b.Any("/any/:id", AccessPublic, h.Detail).Build()
+
+	admin := b.Group("/admin", func(c *gin.Context) { c.Set("group", "admin") }).WithAccess(AccessSuperAdmin)
+	admin.Post("/reset", "", h.Reset).Use(func(c *gin.Context) { c.Set("route", "reset") }).Build()
+
+	// 单独调用 Use 同样生效，不会因为忽略返回值而丢失中间件
+	audit := b.Group("/audit")
+	audit.Use(func(c *gin.Context) { c.Set("group", "audit") })
+	audit.Post("/reset", AccessPublic, h.Reset).Build()
+
+	cases := []struct{ method, target, want string }{
+		{http.MethodPatch, "/api/articles/1", "PATCH 1 需要授权 "},
+		{http.MethodGet, "/api/articles/1", "Not Found"},
+		{http.MethodDelete, "/api/any/2", "DELETE 2 开放接口 "},
+		{http.MethodPost, "/api/admin/reset", "admin reset"},
+		{http.MethodPost, "/api/audit/reset", "audit "},
+	}
+	for _, c := range cases {
+		if got := serveRoute(engine, c.method, c.target, nil); got != c.want {
+			t.Errorf("%s %s = %q, want %q", c.method, c.target, got, c.want)
+		}
+	}
+
+	var reset *RouterInfo
+	for _, r := range GetAllRoutes() {
+		if r.Path == "/api/admin/reset" {
+			reset = &r
+		}
+	}
+	if reset == nil || reset.AccessLevel != AccessSuperAdmin || !reset.operateLog {
+		t.Fatalf("reset route = %+v", reset)
+	}
+	if menu := b.GetMenu(); len(menu.Children) != 1 || menu.Children[0].Meta.Title != "修改文章" {
+		t.Fatalf("menu = %+v", menu.Children)
+	}
+}
+
+func TestVersionAndURL(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	h := articleHandle{}
+	b := NewRouteInfoBuilder("app", h, engine.Group("/open"), MenuOption{})
+	b.Version("v1").Get("/posts/:id", AccessPublic, h.Detail).As("post.v1.detail").Build()
+	b.Version("v2").Get("/posts/:id", AccessPublic, h.Detail).As("post.v2.detail").Build()
+	handler := VersionHandler(engine)
+
+	cases := []struct {
+		target string
+		header map[string]string
+		want   string
+	}{
+		{"/open/v1/posts/1", nil, "GET 1 开放接口 v1"},
+		{"/open/posts/1", map[string]string{HeaderAPIVersion: "2"}, "GET 1 开放接口 v2"},
+		{"/open/posts/1", map[string]string{HeaderAcceptVersion: "v1"}, "GET 1 开放接口 v1"},
+		{"/open/v1/posts/1", map[string]string{HeaderAPIVersion: "v1"}, "GET 1 开放接口 v1"},
+		{"/open/posts/1", map[string]string{HeaderAPIVersion: "v3"}, "Not Found"},
+	}
+	for _, c := range cases {
+		if got := serveRoute(handler, http.MethodGet, c.target, c.header); got != c.want {
+			t.Errorf("GET %s %v = %q, want %q", c.target, c.header, got, c.want)
+		}
+	}
+
+	if got, err := URL("post.v2.detail", 7); err != nil || got != "/open/v2/posts/7" {
+		t.Fatalf("URL = %q, %v", got, err)
+	}
+	if got := MustURL("post.v1.detail", map[string]any{"id": "a b"}); got != "/open/v1/posts/a%20b" {
+		t.Fatalf("URL = %q", got)
+	}
+	if _, err := URL("post.v1.detail"); err == nil {
+		t.Fatal("缺少参数时应当返回错误")
+	}
+	if _, err := URL("post.unknown", 1); err == nil {
+		t.Fatal("路由不存在时应当返回错误")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("重复的路由名称应当 panic")
+		}
+	}()
+	b.Get("/dup", AccessPublic, h.Detail).As("post.v1.detail").Build()
+}
@@ -0,0 +1,308 @@
+package router
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Export 流式导出大列表，数据边查询边输出，不会一次加载到内存
+// 格式由 format 查询参数或 Accept 决定，支持 csv、ndjson 和 json 数组，默认为 csv
+// filename 不为空时作为附件下载，扩展名按格式自动添加
+// CSV 的列为结构体的导出字段，列名依次取 csv、json 标签，嵌入的结构体展开
+//
+//	router.Export(ctx, "用户", func(write func(items ...User) error) error {
+//		var batch []User
+//		return db.Model(&User{}).FindInBatches(&batch, 500, func(tx *gorm.DB, _ int) error {
+//			return write(batch...)
+//		}).Error
+//	})
+//
+// 输出开始之前 produce 返回的错误按 Fail 返回错误响应，开始之后无法修改状态码，ndjson 在末尾追加一行 {"error": ...}
+func Export[T any](ctx *gin.Context, filename string, produce func(write func(items ...T) error) error) {
+	format := ctx.Query("format")
+	switch format {
+	case FormatCSV, FormatNDJSON, FormatJSON:
+	default:
+		format = NegotiateFormat(ctx.GetHeader("Accept"), FormatCSV, FormatNDJSON, FormatJSON)
+	}
+
+	e := &exporter[T]{ctx: ctx, format: format, filename: filename}
+	err := produce(e.write)
+	if err != nil && !e.started {
+		Fail(ctx, err)
+		return
+	}
+	if !e.started {
+		// 没有数据时同样输出 CSV 表头或空数组
+		_ = e.start(nil)
+	}
+	e.finish(err)
+}
+
+type exporter[T any] struct {
+	ctx      *gin.Context
+	format   string
+	filename string
+	started  bool
+	count    int
+	csv      *csv.Writer
+	columns  []csvColumn
+}
+
+// csvColumn CSV 的列，index 为字段在结构体中的路径，map 时为键
+type csvColumn struct {
+	name  string
+	index []int
+	key   string
+}
+
+func (e *exporter[T]) write(items ...T) error {
+	if err := e.ctx.Request.Context().Err(); err != nil {
+		// 客户端已断开，通知 produce 停止查询
+		return err
+	}
+	if !e.started {
+		if err := e.start(items); err != nil {
+			return err
+		}
+	}
+
+	w := e.ctx.Writer
+	for _, item := range items {
+		switch e.format {
+		case FormatCSV:
+			if err := e.csv.Write(e.csvRecord(reflect.ValueOf(item))); err != nil {
+				return err
+			}
+		case FormatNDJSON:
+			data, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			if _, err = w.Write(append(data, '\n')); err != nil {
+				return err
+			}
+		default:
+			data, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			if e.count > 0 {
+				data = append([]byte{','}, data...)
+			}
+			if _, err = w.Write(data); err != nil {
+				return err
+			}
+		}
+		e.count++
+	}
+
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return nil
+}
+
+func (e *exporter[T]) start(first []T) error {
+	e.started = true
+	w := e.ctx.Writer
+	w.Header().Set("Content-Type", formatMIMEs[e.format][0]+"; charset=utf-8")
+	w.Header().Set("X-Accel-Buffering", "no")
+	if e.filename != "" {
+		name := e.filename + "." + e.format
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", url.PathEscape(name)))
+	}
+	w.WriteHeader(http.StatusOK)
+
+	switch e.format {
+	case FormatCSV:
+		// 写入 BOM，Excel 打开时才能正确识别 UTF-8 中文
+		if _, err := w.Write([]byte("\xEF\xBB\xBF")); err != nil {
+			return err
+		}
+		e.csv = csv.NewWriter(w)
+		e.columns = csvColumns(reflect.TypeOf((*T)(nil)).Elem(), first)
+		header := make([]string, len(e.columns))
+		for i, col := range e.columns {
+			header[i] = csvSafe(col.name)
+		}
+		return e.csv.Write(header)
+	case FormatJSON:
+		_, err := w.Write([]byte{'['})
+		return err
+	}
+	return nil
+}
+
+func (e *exporter[T]) finish(err error) {
+	w := e.ctx.Writer
+	switch e.format {
+	case FormatCSV:
+		e.csv.Flush()
+	case FormatNDJSON:
+		if err != nil {
+			data, _ := json.Marshal(gin.H{"error": AsAppError(err).Message})
+			_, _ = w.Write(append(data, '\n'))
+		}
+	case FormatJSON:
+		// 出错时不闭合数组，客户端解析失败即可发现导出不完整
+		if err == nil {
+			_, _ = w.Write([]byte{']'})
+		}
+	}
+	if err != nil {
+		_ = e.ctx.Error(err)
+	}
+	w.Flush()
+}
+
+func (e *exporter[T]) csvRecord(v reflect.Value) []string {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return make([]string, len(e.columns))
+		}
+		v = v.Elem()
+	}
+
+	record := make([]string, len(e.columns))
+	for i, col := range e.columns {
+		var field reflect.Value
+		if v.Kind() == reflect.Map {
+			field = v.MapIndex(reflect.ValueOf(col.key))
+		} else {
+			field = fieldByIndex(v, col.index)
+		}
+		record[i] = csvValue(field)
+	}
+	return record
+}
+
+// csvColumns 结构体使用导出字段，map 使用第一条数据的键
+func csvColumns[T any](t reflect.Type, first []T) []csvColumn {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		return structColumns(t, nil)
+	case reflect.Map:
+		if len(first) == 0 || t.Key().Kind() != reflect.String {
+			return nil
+		}
+		var columns []csvColumn
+		for _, key := range reflect.ValueOf(first[0]).MapKeys() {
+			columns = append(columns, csvColumn{name: key.String(), key: key.String()})
+		}
+		sort.Slice(columns, func(a, b int) bool { return columns[a].name < columns[b].name })
+		return columns
+	}
+	return []csvColumn{{name: "value"}}
+}
+
+func structColumns(t reflect.Type, parent []int) []csvColumn {
+	var columns []csvColumn
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		// 与 encoding/json 相同，未导出类型的嵌入结构体的导出字段同样输出
+		if !f.IsExported() && !(f.Anonymous && ft.Kind() == reflect.Struct) {
+			continue
+		}
+		index := append(append([]int{}, parent...), i)
+
+		name := strings.Split(f.Tag.Get("csv"), ",")[0]
+		if name == "" {
+			name = strings.Split(f.Tag.Get("json"), ",")[0]
+		}
+		if name == "-" {
+			continue
+		}
+
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			columns = append(columns, structColumns(ft, index)...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		columns = append(columns, csvColumn{name: name, index: index})
+	}
+	return columns
+}
+
+// fieldByIndex 与 reflect.Value.FieldByIndex 相同，嵌入的指针为 nil 时返回无效值而不是 panic
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	if v.Kind() != reflect.Struct {
+		return v
+	}
+	for n, i := range index {
+		if n > 0 {
+			for v.Kind() == reflect.Pointer {
+				if v.IsNil() {
+					return reflect.Value{}
+				}
+				v = v.Elem()
+			}
+		}
+		v = v.Field(i)
+	}
+	return v
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func csvValue(v reflect.Value) string {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return ""
+	}
+
+	if v.Type() == timeType {
+		t := v.Interface().(time.Time)
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format(time.DateTime)
+	}
+	if s, ok := v.Interface().(fmt.Stringer); ok {
+		return csvSafe(s.String())
+	}
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		data, _ := json.Marshal(v.Interface())
+		return string(data)
+	case reflect.String:
+		return csvSafe(v.String())
+	}
+	// 数字和布尔值不会被当作公式，负数保持原样
+	return fmt.Sprint(v.Interface())
+}
+
+// csvSafe 以 = + - @ 制表符或回车开头的单元格会被 Excel 当作公式执行，前面加上单引号作为文本显示
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
@@ -0,0 +1,165 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// VersionContextKey 请求上下文中当前路由 API 版本的键
+	VersionContextKey = "api_version"
+
+	HeaderAPIVersion    = "X-API-Version"
+	HeaderAcceptVersion = "Accept-Version"
+)
+
+var (
+	versionLock  sync.RWMutex
+	versionBases = map[string]map[string]struct{}{} // 分组路径 -> 版本
+)
+
+func registerVersionBase(base, version string) {
+	base = strings.TrimSuffix(base, "/")
+	versionLock.Lock()
+	defer versionLock.Unlock()
+	if versionBases[base] == nil {
+		versionBases[base] = map[string]struct{}{}
+	}
+	versionBases[base][version] = struct{}{}
+}
+
+// VersionHandler 按请求头选择 API 版本，X-API-Version 或 Accept-Version 为 1、v1 时
+// /api/users 重写为 /api/v1/users，路径中已带版本时不处理
+func VersionHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version := r.Header.Get(HeaderAPIVersion)
+		if version == "" {
+			version = r.Header.Get(HeaderAcceptVersion)
+		}
+		if version != "" {
+			if !strings.HasPrefix(version, "v") {
+				version = "v" + version
+			}
+			if p, ok := versionedPath(r.URL.Path, version); ok {
+				r.URL.Path = p
+				r.URL.RawPath = ""
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// versionedPath 在最长匹配的分组路径后插入版本
+func versionedPath(p, version string) (string, bool) {
+	versionLock.RLock()
+	defer versionLock.RUnlock()
+
+	var bases []string
+	for base, versions := range versionBases {
+		if _, ok := versions[version]; !ok {
+			continue
+		}
+		if base == "" || p == base || strings.HasPrefix(p, base+"/") {
+			bases = append(bases, base)
+		}
+	}
+	if len(bases) == 0 {
+		return p, false
+	}
+	sort.Slice(bases, func(a, b int) bool { return len(bases[a]) > len(bases[b]) })
+	base := bases[0]
+
+	rest := strings.TrimPrefix(p, base)
+	if rest == "/"+version || strings.HasPrefix(rest, "/"+version+"/") {
+		return p, false
+	}
+	return base + "/" + version + rest, true
+}
+
+var (
+	namedLock   sync.RWMutex
+	namedRoutes = map[string]string{} // 路由名称 -> 完整路径
+)
+
+func registerNamedRoute(name, fullPath string) {
+	namedLock.Lock()
+	defer namedLock.Unlock()
+	if exist, ok := namedRoutes[name]; ok {
+		panic(fmt.Sprintf("路由名称 %s 重复：%s、%s", name, exist, fullPath))
+	}
+	namedRoutes[name] = fullPath
+}
+
+// URL 按路由名称生成路径，参数按顺序替换 :id、*path，也可以传入一个 map[string]any 按名称替换
+//
+//	router.URL("user.detail", 1) // /api/v1/users/1
+//	router.URL("user.detail", map[string]any{"id": 1})
+func URL(name string, params ...any) (string, error) {
+	namedLock.RLock()
+	fullPath, ok := namedRoutes[name]
+	namedLock.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("路由 %s 不存在", name)
+	}
+
+	var named map[string]any
+	if len(params) == 1 {
+		named, _ = params[0].(map[string]any)
+	}
+
+	segments := strings.Split(fullPath, "/")
+	n := 0
+	for i, seg := range segments {
+		if seg == "" || (seg[0] != ':' && seg[0] != '*') {
+			continue
+		}
+		key := seg[1:]
+		var value any
+		if named != nil {
+			v, ok := named[key]
+			if !ok {
+				return "", fmt.Errorf("路由 %s 缺少参数 %s", name, key)
+			}
+			value = v
+		} else {
+			if n >= len(params) {
+				return "", fmt.Errorf("路由 %s 缺少参数 %s", name, key)
+			}
+			value = params[n]
+			n++
+		}
+		s := fmt.Sprint(value)
+		if seg[0] == '*' {
+			segments[i] = strings.TrimPrefix(s, "/")
+		} else {
+			segments[i] = url.PathEscape(s)
+		}
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// MustURL 与 URL 相同，路由不存在或缺少参数时 panic，用于启动时生成固定的地址
+func MustURL(name string, params ...any) string {
+	u, err := URL(name, params...)
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+// joinPaths 与 gin 拼接分组路径的方式相同，保留结尾的 /
+func joinPaths(absolutePath, relativePath string) string {
+	if relativePath == "" {
+		return absolutePath
+	}
+	finalPath := path.Join(absolutePath, relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(finalPath, "/") {
+		return finalPath + "/"
+	}
+	return finalPath
+}
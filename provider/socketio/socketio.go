@@ -0,0 +1,123 @@
+package socketio
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	socketio "github.com/googollee/go-socket.io"
+)
+
+// UserIDHeader 认证通过后写入请求头的用户 ID，客户端传入的同名请求头会被删除
+const UserIDHeader = "X-Owl-User-Id"
+
+// Options Socket.IO 配置
+type Options struct {
+	Path           string        `json:"path"`
+	Auth           bool          `json:"auth"`
+	PingInterval   int           `json:"ping-interval" validate:"min=0"`
+	PingTimeout    int           `json:"ping-timeout" validate:"min=0"`
+	AllowedOrigins []string      `json:"allowed-origins"`
+	Redis          AdapterConfig `json:"redis"`
+}
+
+// AdapterConfig Redis 适配器配置，多节点部署时房间广播通过 Redis 同步到所有节点
+// 连接使用 redis.yaml 的配置，与容器中的 redis.UniversalClient 是同一个 Redis
+type AdapterConfig struct {
+	Enabled bool   `json:"enabled"`
+	Prefix  string `json:"prefix"`
+}
+
+// redisConfig redis.yaml 中适配器使用的配置，redis 包依赖 owl 包，不能直接使用 redis.Options
+type redisConfig struct {
+	Mode   string `json:"mode"`
+	Single struct {
+		Host     string `json:"host"`
+		Port     int    `json:"port"`
+		Password string `json:"password"`
+		Database int    `json:"database"`
+	} `json:"single"`
+	Cluster struct {
+		Addrs    []string `json:"addrs"`
+		Password string   `json:"password"`
+	} `json:"cluster"`
+}
+
+// adapterOptions 根据 redis.yaml 的配置生成适配器的连接参数
+// go-socket.io 的适配器只能连接单个节点，集群模式连接第一个节点，发布的消息由集群转发到所有节点
+func adapterOptions(opt *redisConfig, prefix string) *socketio.RedisAdapterOptions {
+	if opt.Mode == "cluster" {
+		if len(opt.Cluster.Addrs) == 0 {
+			panic("socketio.redis 已开启，但 redis.yaml 中没有配置集群节点")
+		}
+		return &socketio.RedisAdapterOptions{
+			Addr:     opt.Cluster.Addrs[0],
+			Password: opt.Cluster.Password,
+			Prefix:   prefix,
+		}
+	}
+	if opt.Single.Host == "" {
+		panic("socketio.redis 已开启，但 redis.yaml 中没有配置 single.host")
+	}
+	return &socketio.RedisAdapterOptions{
+		Addr:     fmt.Sprintf("%s:%d", opt.Single.Host, opt.Single.Port),
+		Password: opt.Single.Password,
+		DB:       opt.Single.Database,
+		Prefix:   prefix,
+	}
+}
+
+// Namespace 命名空间的事件处理，Path 为 / 时为根命名空间
+//
+//	socketio.Namespace{
+//		Path: "/chat",
+//		Events: map[string]any{
+//			"say": func(s socketio.Conn, msg string) string { return "ok" },
+//		},
+//	}
+type Namespace struct {
+	Path         string
+	OnConnect    func(s socketio.Conn) error
+	OnDisconnect func(s socketio.Conn, reason string)
+	OnError      func(s socketio.Conn, err error)
+	Events       map[string]any // 事件名称 -> 处理函数，参数和返回值的规则见 go-socket.io 的 OnEvent
+}
+
+var (
+	registryLock sync.RWMutex
+	namespaces   []Namespace
+	authHandlers []gin.HandlerFunc
+)
+
+// RegisterNamespaces 注册命名空间，需在 Socket.IO 服务创建之前调用，子应用可实现 owl.SocketIONamespaceProvider
+func RegisterNamespaces(list ...Namespace) {
+	registryLock.Lock()
+	namespaces = append(namespaces, list...)
+	registryLock.Unlock()
+}
+
+// UseAuth 设置连接使用的认证中间件，通常与需要登录的接口相同，认证中间件需在上下文中设置 user_id
+// 浏览器不能为 WebSocket 设置请求头，令牌通过查询参数 access_token 传递，见 middleware.StreamToken
+func UseAuth(handlers ...gin.HandlerFunc) {
+	registryLock.Lock()
+	authHandlers = append(authHandlers, handlers...)
+	registryLock.Unlock()
+}
+
+func registered() ([]Namespace, []gin.HandlerFunc) {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	return append([]Namespace{}, namespaces...), append([]gin.HandlerFunc{}, authHandlers...)
+}
+
+// UserID 连接的用户 ID，未登录时为空
+func UserID(s socketio.Conn) string {
+	return s.RemoteHeader().Get(UserIDHeader)
+}
+
+// UserRoom 用户的房间，已登录的连接会自动加入，用于推送消息给用户的所有连接
+//
+//	server.BroadcastToRoom("/", socketio.UserRoom(userID), "notice", data)
+func UserRoom(userID string) string {
+	return "user:" + userID
+}
@@ -1,45 +1,191 @@
 package socketio
 
 import (
+	"context"
+	_ "embed"
 	"net/http"
+	"strings"
+	"time"
 
 	"bit-labs.cn/owl/contract/foundation"
+	logContract "bit-labs.cn/owl/contract/log"
+	"bit-labs.cn/owl/provider/conf"
+	"bit-labs.cn/owl/provider/router"
+	"bit-labs.cn/owl/utils"
+	"github.com/gin-gonic/gin"
 	socketio "github.com/googollee/go-socket.io"
 	"github.com/googollee/go-socket.io/engineio"
 	"github.com/googollee/go-socket.io/engineio/transport"
+	"github.com/googollee/go-socket.io/engineio/transport/polling"
 	"github.com/googollee/go-socket.io/engineio/transport/websocket"
+	"github.com/spf13/cast"
 )
 
 type SocketIOServiceProvider struct {
-	app foundation.Application
+	app    foundation.Application
+	opt    Options
+	server *socketio.Server
 }
 
-func (s SocketIOServiceProvider) Description() string {
+func (s *SocketIOServiceProvider) Description() string {
 	return "Socket.IO 实时通信服务"
 }
 
 var _ foundation.ServiceProvider = (*SocketIOServiceProvider)(nil)
 
-func (s SocketIOServiceProvider) Register() {
-	s.app.Register(func() *socketio.Server {
-		server := socketio.NewServer(&engineio.Options{
+func (s *SocketIOServiceProvider) Register() {
+	s.app.Register(func(c *conf.Configure, l logContract.Logger) *socketio.Server {
+		err := c.GetConfig("socketio", &s.opt)
+		if err != nil {
+			panic(err)
+		}
+		if s.opt.Path == "" {
+			s.opt.Path = "/socket.io/"
+		}
+
+		origins := s.opt.AllowedOrigins
+		if len(origins) == 0 {
+			// 未单独配置时与 HTTP 接口的跨域配置相同
+			var cors router.CorsConfig
+			if err = c.GetConfig("router.cors", &cors); err == nil {
+				origins = cors.AllowedOrigins
+			}
+		}
+		checkOrigin := router.CheckOrigin(origins)
+
+		s.server = socketio.NewServer(&engineio.Options{
+			PingInterval: time.Duration(s.opt.PingInterval) * time.Second,
+			PingTimeout:  time.Duration(s.opt.PingTimeout) * time.Second,
 			Transports: []transport.Transport{
-				&websocket.Transport{
-					CheckOrigin: func(r *http.Request) bool {
-						return true
-					},
-				},
+				&polling.Transport{Client: &http.Client{Timeout: time.Minute}, CheckOrigin: checkOrigin},
+				&websocket.Transport{CheckOrigin: checkOrigin},
 			},
 		})
-		return server
+
+		// 适配器需在创建命名空间之前设置
+		if s.opt.Redis.Enabled {
+			var redisOpt redisConfig
+			if err = c.GetConfig("redis", &redisOpt); err != nil {
+				panic("socketio.redis 已开启，需要注册 Redis 服务并配置 redis.yaml: " + err.Error())
+			}
+			if _, err = s.server.Adapter(adapterOptions(&redisOpt, s.opt.Redis.Prefix)); err != nil {
+				panic(err)
+			}
+		}
+
+		s.registerNamespaces(l)
+		return s.server
 	})
+}
+
+func (s *SocketIOServiceProvider) registerNamespaces(l logContract.Logger) {
+	list, _ := registered()
+	hasRoot := false
+	for _, ns := range list {
+		if ns.Path == "" || ns.Path == "/" {
+			hasRoot = true
+		}
+	}
+	if !hasRoot {
+		// 客户端总是先连接根命名空间，没有根命名空间的处理时所有连接都会被拒绝
+		list = append([]Namespace{{Path: "/"}}, list...)
+	}
+
+	for _, ns := range list {
+		ns := ns
+		path := ns.Path
+		if path == "" {
+			path = "/"
+		}
+
+		s.server.OnConnect(path, func(conn socketio.Conn) error {
+			userID := UserID(conn)
+			if s.opt.Auth && userID == "" {
+				return router.ErrUnauthorized
+			}
+			if userID != "" {
+				conn.Join(UserRoom(userID))
+			}
+			if ns.OnConnect != nil {
+				return ns.OnConnect(conn)
+			}
+			return nil
+		})
+		if ns.OnDisconnect != nil {
+			s.server.OnDisconnect(path, ns.OnDisconnect)
+		}
+		s.server.OnError(path, func(conn socketio.Conn, err error) {
+			if ns.OnError != nil {
+				ns.OnError(conn, err)
+				return
+			}
+			if l != nil {
+				l.Warning("Socket.IO 错误", " namespace:", path, " error:", err.Error())
+			}
+		})
+		for event, handler := range ns.Events {
+			s.server.OnEvent(path, event, handler)
+		}
+	}
+}
+
+func (s *SocketIOServiceProvider) Boot() {
+	err := s.app.Invoke(func(engine *gin.Engine, server *socketio.Server) {
+		if _, auth := registered(); s.opt.Auth && len(auth) == 0 {
+			utils.PrintLnYellow("socketio.auth 已开启但没有设置认证中间件，所有连接都会被拒绝，请调用 socketio.UseAuth")
+		}
+		s.mount(engine)
+
+		go func() {
+			if err := server.Serve(); err != nil {
+				utils.PrintLnRed("Socket.IO 服务退出: " + err.Error())
+			}
+		}()
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+// mount 挂载到路由引擎，请求依次经过认证中间件，认证通过后用户 ID 写入请求头供连接读取
+func (s *SocketIOServiceProvider) mount(engine *gin.Engine) {
+	_, auth := registered()
+	handlers := []gin.HandlerFunc{func(c *gin.Context) {
+		// 用户 ID 只能由认证中间件设置
+		c.Request.Header.Del(UserIDHeader)
+	}}
+	handlers = append(handlers, auth...)
+	handlers = append(handlers, func(c *gin.Context) {
+		if userID := cast.ToString(c.Value("user_id")); userID != "" {
+			c.Request.Header.Set(UserIDHeader, userID)
+		}
+		s.server.ServeHTTP(c.Writer, c.Request)
+	})
+
+	path := strings.TrimSuffix(s.opt.Path, "/")
+	engine.GET(path+"/*any", handlers...)
+	engine.POST(path+"/*any", handlers...)
+}
 
+// Shutdown 关闭 Socket.IO 服务，断开所有连接
+func (s *SocketIOServiceProvider) Shutdown(ctx context.Context) error {
+	if s.server == nil {
+		return nil
+	}
+	return s.server.Close()
 }
 
-func (s SocketIOServiceProvider) Boot() {
+//go:embed socketio.yaml
+var socketioYaml string
 
+func (s *SocketIOServiceProvider) Conf() map[string]string {
+	return map[string]string{
+		"socketio.yaml": socketioYaml,
+	}
 }
 
-func (s SocketIOServiceProvider) Conf() map[string]string {
-	return nil
+func (s *SocketIOServiceProvider) ConfSchema() map[string]any {
+	return map[string]any{
+		"socketio.yaml": &Options{},
+	}
 }
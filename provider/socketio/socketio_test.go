@@ -0,0 +1,109 @@
+package socketio
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	socketio "github.com/googollee/go-socket.io"
+)
+
+func TestConnectAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	connected := make(chan string, 2)
+	RegisterNamespaces(Namespace{
+		Path: "/",
+		OnConnect: func(conn socketio.Conn) error {
+			connected <- UserID(conn)
+			return nil
+		},
+	})
+	UseAuth(func(c *gin.Context) {
+		if token := c.GetHeader("Authorization"); token != "" {
+			c.Set("user_id", token)
+		}
+	})
+
+	s := &SocketIOServiceProvider{opt: Options{Path: "/socket.io/", Auth: true}}
+	s.server = socketio.NewServer(nil)
+	s.registerNamespaces(nil)
+	engine := gin.New()
+	s.mount(engine)
+	go func() { _ = s.server.Serve() }()
+	defer s.server.Close()
+
+	srv := httptest.NewServer(engine)
+	defer srv.Close()
+
+	get := func(url string, header http.Header) string {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		req.Header = header
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status = %d, body = %s", resp.StatusCode, body)
+		}
+		return string(body)
+	}
+	// 长轮询握手后需再轮询一次，服务端写出连接包后才会调用 OnConnect
+	handshake := func(header http.Header) {
+		url := srv.URL + "/socket.io/?EIO=3&transport=polling"
+		sid := regexp.MustCompile(`"sid":"([^"]+)"`).FindStringSubmatch(get(url, header))
+		if sid == nil {
+			t.Fatal("握手响应中没有 sid")
+		}
+		go func() {
+			req, _ := http.NewRequest(http.MethodGet, url+"&sid="+sid[1], nil)
+			req.Header = header
+			if resp, err := http.DefaultClient.Do(req); err == nil {
+				_ = resp.Body.Close()
+			}
+		}()
+	}
+
+	// 客户端伪造的用户 ID 会被删除，以认证中间件设置的为准
+	handshake(http.Header{"Authorization": {"u1"}, UserIDHeader: {"admin"}})
+	select {
+	case userID := <-connected:
+		if userID != "u1" {
+			t.Fatalf("user id = %s", userID)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("没有建立连接")
+	}
+
+	// 未登录的连接在根命名空间被拒绝
+	handshake(http.Header{UserIDHeader: {"admin"}})
+	select {
+	case userID := <-connected:
+		t.Fatalf("未登录的连接被接受: %q", userID)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+func TestAdapterOptions(t *testing.T) {
+	var opt redisConfig
+	if err := json.Unmarshal([]byte(`{"mode":"single","single":{"host":"10.0.0.1","port":6380,"password":"p","database":2}}`), &opt); err != nil {
+		t.Fatal(err)
+	}
+	got := adapterOptions(&opt, "socket.io")
+	if got.Addr != "10.0.0.1:6380" || got.Password != "p" || got.DB != 2 || got.Prefix != "socket.io" {
+		t.Fatalf("single = %+v", got)
+	}
+
+	opt.Mode = "cluster"
+	opt.Cluster.Addrs = []string{"10.0.0.2:7000", "10.0.0.3:7000"}
+	opt.Cluster.Password = "c"
+	if got = adapterOptions(&opt, "io"); got.Addr != "10.0.0.2:7000" || got.Password != "c" || got.DB != 0 {
+		t.Fatalf("cluster = %+v", got)
+	}
+}
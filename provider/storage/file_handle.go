@@ -1,10 +1,12 @@
 package storage
 
 import (
+	"net/http"
 	"path/filepath"
 	"strings"
 
 	"bit-labs.cn/owl/provider/router"
+	"bit-labs.cn/owl/utils/file"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 )
@@ -23,12 +25,36 @@ func (i *FileHandle) ModuleName() (en string, zh string) {
 	return "file", "文件"
 }
 
+// multipartOverhead 请求体中除文件内容外的表单字段、分隔符等的预留大小
+const multipartOverhead = 1 << 20
+
 func (i *FileHandle) Upload(ctx *gin.Context) {
+	var maxFileSize int64
+	if i.storage.options != nil {
+		maxFileSize = i.storage.options.Upload.MaxFileSize
+	}
+	if maxFileSize > 0 {
+		// 以上传配置为准，读取时超过限制立即停止，不会将超大的文件写入临时目录
+		router.LimitBody(ctx, maxFileSize+multipartOverhead)
+	}
+
 	fileHeader, err := ctx.FormFile("file")
 	if err != nil {
+		switch appErr := router.AsAppError(err); appErr.Status {
+		case http.StatusRequestEntityTooLarge:
+			router.Fail(ctx, appErr.WithMessage("文件大小不能超过 %s", humanSize(maxFileSize)))
+			return
+		case http.StatusRequestTimeout:
+			router.Fail(ctx, appErr)
+			return
+		}
 		router.BadRequest(ctx, "缺少文件")
 		return
 	}
+	if maxFileSize > 0 && fileHeader.Size > maxFileSize {
+		router.Fail(ctx, router.ErrPayloadTooLarge.WithMessage("文件大小不能超过 %s", humanSize(maxFileSize)))
+		return
+	}
 
 	ext := strings.ToLower(filepath.Ext(fileHeader.Filename))
 	objectPath := uuid.NewString() + ext
@@ -56,3 +82,7 @@ func (i *FileHandle) Upload(ctx *gin.Context) {
 		"originalName": fileHeader.Filename,
 	})
 }
+
+func humanSize(size int64) string {
+	return (&file.Node{Size: size}).HumanSize()
+}
@@ -12,7 +12,7 @@ import (
 
 // Options 存储配置选项
 type Options struct {
-	Driver     string           `json:"driver"`
+	Driver     string           `json:"driver" validate:"omitempty,oneof=local s3 minio oss cos qiniu"`
 	Local      LocalConfig      `json:"local"`
 	S3         S3Config         `json:"s3"`
 	MinIO      MinIOConfig      `json:"minio"`
@@ -98,19 +98,19 @@ type QiniuConfig struct {
 
 // UploadConfig 上传配置
 type UploadConfig struct {
-	MaxFileSize       int64    `json:"max-file-size"`
+	MaxFileSize       int64    `json:"max-file-size" validate:"min=0"`
 	AllowedTypes      []string `json:"allowed-types"`
 	AllowedExtensions []string `json:"allowed-extensions"`
 	CheckFileType     bool     `json:"check-file-type"`
 	UniqueFilename    bool     `json:"unique-filename"`
-	FilenameStrategy  string   `json:"filename-strategy"`
+	FilenameStrategy  string   `json:"filename-strategy" validate:"omitempty,oneof=uuid timestamp hash original"`
 	KeepOriginalName  bool     `json:"keep-original-name"`
 }
 
 // ImageConfig 图片处理配置
 type ImageConfig struct {
 	Enabled    bool                       `json:"enabled"`
-	Quality    int                        `json:"quality"`
+	Quality    int                        `json:"quality" validate:"omitempty,min=1,max=100"`
 	Formats    []string                   `json:"formats"`
 	Thumbnails map[string]ThumbnailConfig `json:"thumbnails"`
 	Watermark  WatermarkConfig            `json:"watermark"`
@@ -144,7 +144,7 @@ type SecurityConfig struct {
 // CacheConfig 缓存配置
 type CacheConfig struct {
 	Enabled bool   `json:"enabled"`
-	Driver  string `json:"driver"`
+	Driver  string `json:"driver" validate:"omitempty,oneof=memory redis file"`
 	TTL     int    `json:"ttl"`
 	Prefix  string `json:"prefix"`
 }
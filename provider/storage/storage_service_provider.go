@@ -31,6 +31,7 @@ func (s *StorageServiceProvider) Register() {
 
 		// 初始化存储管理器
 		manager := NewStorageManager()
+		manager.options = &opt
 
 		// 初始化本地存储
 		if opt.Local.Root != "" {
@@ -88,3 +89,9 @@ func (s *StorageServiceProvider) Conf() map[string]string {
 		"storage.yaml": storageYaml,
 	}
 }
+
+func (s *StorageServiceProvider) ConfSchema() map[string]any {
+	return map[string]any{
+		"storage.yaml": &Options{},
+	}
+}
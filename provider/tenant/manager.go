@@ -0,0 +1,129 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"bit-labs.cn/owl/contract/log"
+	"bit-labs.cn/owl/provider/db"
+	"gorm.io/gorm"
+)
+
+// Manager schema 和 database 策略的租户连接管理器，连接在租户第一次访问时创建
+// 每个租户使用独立的连接池，连接数受 max-conns 限制，租户连接不包含从库配置
+type Manager struct {
+	opt     *Options
+	base    *db.Options
+	log     log.Logger
+	prepare func(tenant string, opt *db.Options)
+	lock    sync.Mutex
+	conns   map[string]*gorm.DB
+}
+
+// NewManager base 为默认连接的配置，prepare 在创建连接之前调整配置，如 sqlite 文件路径，可以为空
+func NewManager(opt *Options, base *db.Options, l log.Logger, prepare func(tenant string, opt *db.Options)) *Manager {
+	return &Manager{
+		opt:     opt,
+		base:    base,
+		log:     l,
+		prepare: prepare,
+		conns:   make(map[string]*gorm.DB),
+	}
+}
+
+// Strategy 当前的隔离策略
+func (i *Manager) Strategy() Strategy {
+	return i.opt.Strategy
+}
+
+// Has 租户是否存在，只有 database 策略需要在 databases 中配置租户
+func (i *Manager) Has(tenant string) bool {
+	if i.opt.Strategy != StrategyDatabase {
+		return true
+	}
+	_, ok := i.opt.Databases[tenant]
+	return ok
+}
+
+// Options 返回租户连接的配置，column 策略或租户不存在时 ok 为 false
+func (i *Manager) Options(tenant string) (*db.Options, bool) {
+	switch i.opt.Strategy {
+	case StrategySchema:
+		if !Valid(tenant) {
+			return nil, false
+		}
+		return i.base.Inherit(db.Options{
+			Schema:       i.opt.SchemaName(tenant),
+			MaxConns:     i.opt.MaxConns,
+			MaxIdleConns: i.opt.MaxIdleConns,
+		}), true
+	case StrategyDatabase:
+		override, ok := i.opt.Databases[tenant]
+		if !ok {
+			return nil, false
+		}
+		// 租户单独配置了连接数时使用租户的配置
+		if override.MaxConns == 0 {
+			override.MaxConns = i.opt.MaxConns
+		}
+		if override.MaxIdleConns == 0 {
+			override.MaxIdleConns = i.opt.MaxIdleConns
+		}
+		return i.base.Inherit(override), true
+	}
+	return nil, false
+}
+
+// Connection 获取租户连接，租户不存在或连接失败时 panic，column 策略返回 nil
+func (i *Manager) Connection(tenant string) *gorm.DB {
+	if i.opt.Strategy == StrategyColumn {
+		return nil
+	}
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	if conn, ok := i.conns[tenant]; ok {
+		return conn
+	}
+
+	opt, ok := i.Options(tenant)
+	if !ok {
+		panic(fmt.Sprintf("租户 %s 不存在，请检查 tenant.yaml", tenant))
+	}
+	if i.prepare != nil {
+		i.prepare(tenant, opt)
+	}
+
+	conn := db.InitDB(opt, i.log)
+	i.conns[tenant] = conn
+	return conn
+}
+
+// Tenants 返回 database 策略配置的所有租户
+func (i *Manager) Tenants() []string {
+	tenants := make([]string, 0, len(i.opt.Databases))
+	for tenant := range i.opt.Databases {
+		tenants = append(tenants, tenant)
+	}
+	sort.Strings(tenants)
+	return tenants
+}
+
+// Switch 返回上下文中租户的连接，用于 db.SetConnectionSwitcher，没有租户或跳过租户隔离时返回 nil
+// 成员检查失败时返回带有错误的 *gorm.DB，语句返回该错误
+func (i *Manager) Switch(ctx context.Context) *gorm.DB {
+	if skipped(ctx) {
+		return nil
+	}
+	tenant, err := Current(ctx)
+	if err != nil {
+		return &gorm.DB{Error: err}
+	}
+	if tenant == "" {
+		return nil
+	}
+	return i.Connection(tenant)
+}
@@ -0,0 +1,91 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MemberChecker 检查当前请求的用户是否属于租户，不属于时返回 ErrTenantForbidden
+// 检查在第一次使用租户时执行，此时认证中间件已经运行，可以从 c 中读取用户
+// 检查中访问数据库需要使用 SkipTenant(c)，否则返回 errMemberChecking
+type MemberChecker func(c *gin.Context, tenant string) error
+
+var (
+	memberChecker     MemberChecker
+	memberCheckerLock sync.RWMutex
+
+	errMemberChecking = errors.New("租户成员检查尚未完成")
+)
+
+// SetMemberChecker 设置租户成员检查，请求头、子域名等解析出的租户由客户端指定，需要确认用户属于该租户
+//
+//	tenant.SetMemberChecker(func(c *gin.Context, id string) error {
+//		if !memberRepo.WithContext(tenant.SkipTenant(c)).Has(c.GetUint("user_id"), id) {
+//			return tenant.ErrTenantForbidden
+//		}
+//		return nil
+//	})
+func SetMemberChecker(checker MemberChecker) {
+	memberCheckerLock.Lock()
+	memberChecker = checker
+	memberCheckerLock.Unlock()
+}
+
+func getMemberChecker() MemberChecker {
+	memberCheckerLock.RLock()
+	defer memberCheckerLock.RUnlock()
+	return memberChecker
+}
+
+// member 待检查成员关系的租户，保存在请求上下文中，检查结果在请求内复用
+type member struct {
+	tenant   string
+	c        *gin.Context
+	check    MemberChecker
+	lock     sync.Mutex
+	checking bool
+	checked  bool
+	err      error
+}
+
+func (i *member) result() (string, error) {
+	i.lock.Lock()
+	if i.checked {
+		i.lock.Unlock()
+		return i.tenant, i.err
+	}
+	if i.checking {
+		i.lock.Unlock()
+		return "", errMemberChecking
+	}
+	i.checking = true
+	i.lock.Unlock()
+
+	err := i.check(i.c, i.tenant)
+
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.checking, i.checked, i.err = false, true, err
+	return i.tenant, err
+}
+
+// Current 获取上下文中的租户，设置了成员检查时在第一次调用时执行检查，没有租户时返回空字符串
+func Current(ctx context.Context) (string, error) {
+	if ctx == nil {
+		return "", nil
+	}
+	switch v := ctx.Value(ContextKey).(type) {
+	case string:
+		return v, nil
+	case *member:
+		tenant, err := v.result()
+		if err != nil {
+			return "", err
+		}
+		return tenant, nil
+	}
+	return "", nil
+}
@@ -0,0 +1,49 @@
+package tenant
+
+import (
+	"context"
+
+	"bit-labs.cn/owl/provider/router"
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware 解析请求的租户并保存到上下文，exists 为空时不检查租户是否存在
+// 中间件在认证之前运行，设置了 SetMemberChecker 时成员关系在第一次使用租户时检查
+func Middleware(opt *Options, resolvers []Resolver, exists func(tenant string) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var tenant string
+		for _, resolve := range resolvers {
+			if tenant = resolve(c); tenant != "" {
+				break
+			}
+		}
+
+		if tenant == "" {
+			if opt.Required {
+				router.BadRequest(c, ErrTenantRequired.Error())
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+		if !Valid(tenant) {
+			router.BadRequest(c, ErrInvalidTenant.Error())
+			c.Abort()
+			return
+		}
+		if exists != nil && !exists(tenant) {
+			router.BadRequest(c, ErrUnknownTenant.Error())
+			c.Abort()
+			return
+		}
+
+		var value any = tenant
+		if check := getMemberChecker(); check != nil {
+			value = &member{tenant: tenant, c: c, check: check}
+		}
+		c.Set(ContextKey, value)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), ContextKey, value))
+		c.Next()
+	}
+}
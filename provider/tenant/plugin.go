@@ -0,0 +1,99 @@
+package tenant
+
+import (
+	"reflect"
+
+	"bit-labs.cn/owl/provider/db"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ColumnPlugin column 策略的 gorm 插件，模型包含租户列时查询、更新、删除追加租户条件，创建时填充租户列
+// 原生 SQL(Raw/Exec) 不受影响
+type ColumnPlugin struct {
+	Column   string // 租户列名
+	Required bool   // 未指定租户时返回 ErrTenantRequired，否则不过滤
+}
+
+func (i *ColumnPlugin) Name() string {
+	return "owl:tenant"
+}
+
+func (i *ColumnPlugin) Initialize(conn *gorm.DB) error {
+	cb := conn.Callback()
+	if err := cb.Create().Before("gorm:create").Register("owl:tenant", i.fill); err != nil {
+		return err
+	}
+	if err := cb.Query().Before("gorm:query").Register("owl:tenant", i.apply); err != nil {
+		return err
+	}
+	if err := cb.Row().Before("gorm:row").Register("owl:tenant", i.apply); err != nil {
+		return err
+	}
+	if err := cb.Update().Before("gorm:update").Register("owl:tenant", i.applyWrite); err != nil {
+		return err
+	}
+	return cb.Delete().Before("gorm:delete").Register("owl:tenant", i.applyWrite)
+}
+
+// tenant 返回当前语句的租户，模型没有租户列或跳过租户隔离时 ok 为 false
+func (i *ColumnPlugin) tenant(conn *gorm.DB) (tenant string, ok bool) {
+	stmt := conn.Statement
+	if conn.Error != nil || stmt.Schema == nil || stmt.Schema.LookUpField(i.Column) == nil || skipped(stmt.Context) {
+		return "", false
+	}
+	tenant, err := Current(stmt.Context)
+	if err != nil {
+		_ = conn.AddError(err)
+		return "", false
+	}
+	if tenant == "" {
+		if i.Required {
+			_ = conn.AddError(ErrTenantRequired)
+		}
+		return "", false
+	}
+	return tenant, true
+}
+
+func (i *ColumnPlugin) apply(conn *gorm.DB) {
+	tenant, ok := i.tenant(conn)
+	if !ok {
+		return
+	}
+	db.AndWhere(conn.Statement, clause.Eq{
+		Column: clause.Column{Table: clause.CurrentTable, Name: i.Column},
+		Value:  tenant,
+	})
+}
+
+// applyWrite 更新、删除没有条件时不追加租户条件，保留 gorm 的 ErrMissingWhereClause 检查
+func (i *ColumnPlugin) applyWrite(conn *gorm.DB) {
+	if db.HasWriteCondition(conn) {
+		i.apply(conn)
+	}
+}
+
+// fill 创建数据时租户列为空则填充当前租户
+func (i *ColumnPlugin) fill(conn *gorm.DB) {
+	tenant, ok := i.tenant(conn)
+	if !ok {
+		return
+	}
+	field := conn.Statement.Schema.LookUpField(i.Column)
+
+	ctx := conn.Statement.Context
+	setIfZero := func(v reflect.Value) {
+		if _, zero := field.ValueOf(ctx, v); zero {
+			_ = field.Set(ctx, v, tenant)
+		}
+	}
+	switch rv := reflect.Indirect(conn.Statement.ReflectValue); rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for n := 0; n < rv.Len(); n++ {
+			setIfZero(reflect.Indirect(rv.Index(n)))
+		}
+	case reflect.Struct:
+		setIfZero(rv)
+	}
+}
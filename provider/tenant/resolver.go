@@ -0,0 +1,132 @@
+package tenant
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Resolver 从请求中解析租户，解析不到时返回空字符串
+type Resolver func(c *gin.Context) string
+
+// HeaderResolver 从请求头中解析租户
+func HeaderResolver(header string) Resolver {
+	return func(c *gin.Context) string {
+		return strings.TrimSpace(c.GetHeader(header))
+	}
+}
+
+// SubdomainResolver 从子域名中解析租户，如 domain 为 saas.com 时 acme.saas.com 的租户为 acme
+func SubdomainResolver(domain string) Resolver {
+	suffix := "." + strings.TrimPrefix(strings.ToLower(domain), ".")
+	return func(c *gin.Context) string {
+		host := strings.ToLower(c.Request.Host)
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if !strings.HasSuffix(host, suffix) {
+			return ""
+		}
+		sub := strings.TrimSuffix(host, suffix)
+		// 只取最后一级，如 api.acme.saas.com 的租户为 acme
+		if idx := strings.LastIndex(sub, "."); idx >= 0 {
+			sub = sub[idx+1:]
+		}
+		return sub
+	}
+}
+
+// jwtHashes 支持的 HMAC 签名算法
+var jwtHashes = map[string]func() hash.Hash{
+	"HS256": sha256.New,
+	"HS384": sha512.New384,
+	"HS512": sha512.New,
+}
+
+// JWTResolver 从 Authorization 请求头的 JWT 中读取租户，只接受 secret 签名且未过期的令牌
+// 租户来自签发令牌的认证服务，客户端无法伪造
+func JWTResolver(claim, secret string) Resolver {
+	return func(c *gin.Context) string {
+		token := strings.TrimSpace(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+		parts := strings.Split(token, ".")
+		if len(parts) != 3 {
+			return ""
+		}
+
+		var header struct {
+			Alg string `json:"alg"`
+		}
+		if !decodeJWTPart(parts[0], &header) {
+			return ""
+		}
+		newHash, ok := jwtHashes[header.Alg]
+		if !ok {
+			return ""
+		}
+		sign, err := base64.RawURLEncoding.DecodeString(parts[2])
+		if err != nil {
+			return ""
+		}
+		mac := hmac.New(newHash, []byte(secret))
+		mac.Write([]byte(parts[0] + "." + parts[1]))
+		if !hmac.Equal(sign, mac.Sum(nil)) {
+			return ""
+		}
+
+		var claims map[string]any
+		if !decodeJWTPart(parts[1], &claims) {
+			return ""
+		}
+		if exp, ok := claims["exp"].(float64); ok && time.Now().Unix() >= int64(exp) {
+			return ""
+		}
+		switch v := claims[claim].(type) {
+		case string:
+			return v
+		case float64:
+			return fmt.Sprintf("%.0f", v)
+		}
+		return ""
+	}
+}
+
+func decodeJWTPart(part string, v any) bool {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+// NewResolvers 根据配置创建解析器，未知的解析方式 panic
+func NewResolvers(opt *Options) []Resolver {
+	resolvers := make([]Resolver, 0, len(opt.Resolvers))
+	for _, name := range opt.Resolvers {
+		switch name {
+		case "header":
+			resolvers = append(resolvers, HeaderResolver(opt.Header))
+		case "subdomain":
+			if opt.Domain == "" {
+				panic("租户 subdomain 解析需要配置 domain")
+			}
+			resolvers = append(resolvers, SubdomainResolver(opt.Domain))
+		case "jwt":
+			if opt.JWTSecret == "" {
+				panic("租户 jwt 解析需要配置 jwt-secret")
+			}
+			resolvers = append(resolvers, JWTResolver(opt.JWTClaim, opt.JWTSecret))
+		default:
+			panic(fmt.Sprintf("未知的租户解析方式 %s", name))
+		}
+	}
+	return resolvers
+}
@@ -0,0 +1,116 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"bit-labs.cn/owl/provider/db"
+	"bit-labs.cn/owl/provider/router"
+)
+
+// Strategy 租户隔离策略
+type Strategy string
+
+const (
+	StrategyColumn   Strategy = "column"   // 共享表，按租户列过滤
+	StrategySchema   Strategy = "schema"   // pgsql 每个租户一个模式，切换 search_path
+	StrategyDatabase Strategy = "database" // 每个租户一个数据库连接
+)
+
+const (
+	// ContextKey 上下文中保存当前租户的键
+	ContextKey = "tenant_id"
+	// skipKey 上下文中跳过租户隔离的键
+	skipKey = "tenant_skip"
+)
+
+var (
+	ErrTenantRequired = errors.New("缺少租户信息")
+	ErrInvalidTenant  = errors.New("租户标识不合法")
+	ErrUnknownTenant  = errors.New("租户不存在")
+	// ErrTenantForbidden 用户不属于请求的租户，返回 403
+	ErrTenantForbidden = router.ErrForbidden.WithMessage("没有该租户的访问权限")
+)
+
+// tenantRegexp 租户标识只允许字母、数字、下划线和中划线，用于拼接模式名称
+var tenantRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+type Options struct {
+	Strategy  Strategy              `json:"strategy" validate:"omitempty,oneof=column schema database"`
+	Column    string                `json:"column"`                                               // column 策略的租户列名
+	Resolvers []string              `json:"resolvers" validate:"dive,oneof=header subdomain jwt"` // 租户解析方式，按顺序取第一个非空的结果
+	Header    string                `json:"header"`
+	Domain    string                `json:"domain"`
+	JWTClaim  string                `json:"jwt-claim"`
+	JWTSecret string                `json:"jwt-secret"`                // jwt 解析校验签名的 HMAC 密钥
+	Required  bool                  `json:"required"`                  // 请求必须携带租户
+	Schema    string                `json:"schema"`                    // schema 策略的模式名称模板，如 tenant_{tenant}
+	Databases map[string]db.Options `json:"databases" validate:"dive"` // database 策略的租户数据库，未填写的字段继承默认连接
+	// 每个租户连接池的最大连接数和空闲连接数，租户较多时避免连接数超出数据库限制
+	MaxConns     int `json:"max-conns"`
+	MaxIdleConns int `json:"max-idle-conns"`
+}
+
+func (i *Options) setDefaults() {
+	if i.Strategy == "" {
+		i.Strategy = StrategyColumn
+	}
+	if i.Column == "" {
+		i.Column = "tenant_id"
+	}
+	if len(i.Resolvers) == 0 {
+		i.Resolvers = []string{"header"}
+	}
+	if i.Header == "" {
+		i.Header = "X-Tenant-ID"
+	}
+	if i.JWTClaim == "" {
+		i.JWTClaim = "tenant_id"
+	}
+	if i.Schema == "" {
+		i.Schema = "tenant_{tenant}"
+	}
+	if i.MaxConns == 0 {
+		i.MaxConns = 10
+	}
+	if i.MaxIdleConns == 0 {
+		i.MaxIdleConns = 2
+	}
+}
+
+// SchemaName 返回租户的 pgsql 模式名称
+func (i *Options) SchemaName(tenant string) string {
+	return strings.ReplaceAll(i.Schema, "{tenant}", tenant)
+}
+
+// Valid 租户标识是否合法
+func Valid(tenant string) bool {
+	return tenantRegexp.MatchString(tenant)
+}
+
+// WithTenant 返回指定租户的上下文，用于请求之外的场景，如队列任务
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, ContextKey, tenant)
+}
+
+// FromContext 获取上下文中的租户，没有租户或成员检查失败时返回空字符串，需要区分时使用 Current
+func FromContext(ctx context.Context) string {
+	tenant, _ := Current(ctx)
+	return tenant
+}
+
+// SkipTenant 返回不受租户隔离限制的上下文，用于平台管理后台跨租户统计
+// 只对 column 策略生效，schema 和 database 策略使用默认连接
+func SkipTenant(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipKey, true)
+}
+
+func skipped(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	skip, _ := ctx.Value(skipKey).(bool)
+	return skip
+}
@@ -0,0 +1,131 @@
+package tenant
+
+import (
+	"context"
+	_ "embed"
+	"path/filepath"
+	"sync"
+
+	"bit-labs.cn/owl"
+	"bit-labs.cn/owl/contract/foundation"
+	"bit-labs.cn/owl/contract/log"
+	"bit-labs.cn/owl/provider/conf"
+	"bit-labs.cn/owl/provider/db"
+	"bit-labs.cn/owl/provider/router"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var _ foundation.ServiceProvider = (*TenantServiceProvider)(nil)
+
+// TenantServiceProvider 多租户，需要在 DBServiceProvider 之后注册
+type TenantServiceProvider struct {
+	app  foundation.Application
+	conf *conf.Configure
+	log  log.Logger
+
+	once       sync.Once
+	manager    *Manager
+	middleware gin.HandlerFunc
+}
+
+func (i *TenantServiceProvider) Description() string {
+	return "多租户隔离"
+}
+
+// Register 首次运行时 tenant.yaml 在 Register 之后才会生成，配置在第一次使用时读取
+func (i *TenantServiceProvider) Register() {
+	err := i.app.Invoke(func(c *conf.Configure, l log.Logger) {
+		i.conf, i.log = c, l
+	})
+	owl.PanicIf(err)
+
+	i.app.Register(func() *Manager {
+		return i.setup()
+	})
+
+	// column 策略在连接初始化时安装插件，其他策略将默认连接上的查询、仓库和事务切换到租户连接
+	db.RegisterPlugin(&lazyColumnPlugin{provider: i})
+	db.SetConnectionSwitcher(func(ctx context.Context) *gorm.DB {
+		if m := i.setup(); m.opt.Strategy != StrategyColumn {
+			return m.Switch(ctx)
+		}
+		return nil
+	})
+
+	router.RegisterMiddleware(func(c *gin.Context) {
+		i.setup()
+		i.middleware(c)
+	})
+}
+
+// setup 读取配置并创建租户管理器，只执行一次
+func (i *TenantServiceProvider) setup() *Manager {
+	i.once.Do(func() {
+		var opt Options
+		var dbOpt db.Options
+		err := i.conf.GetConfig("tenant", &opt)
+		owl.PanicIf(err)
+		err = i.conf.GetConfig("database", &dbOpt)
+		owl.PanicIf(err)
+		opt.setDefaults()
+		dbOpt.Replicas, dbOpt.Connections = nil, nil
+
+		if opt.Strategy == StrategySchema && dbOpt.Driver != db.Pgsql {
+			panic("租户 schema 策略仅支持 pgsql")
+		}
+
+		l := i.log
+		i.manager = NewManager(&opt, &dbOpt, l, func(tenant string, o *db.Options) {
+			l.Debug("连接租户数据库", "租户", tenant, "配置信息", o.Host, o.Port, o.Database, o.Schema)
+
+			if o.Driver == db.Sqlite {
+				o.Host = filepath.Join(i.app.GetConfigPath(), o.Host)
+			}
+		})
+
+		var exists func(string) bool
+		if opt.Strategy == StrategyDatabase {
+			exists = i.manager.Has
+		}
+		i.middleware = Middleware(&opt, NewResolvers(&opt), exists)
+	})
+	return i.manager
+}
+
+// Boot 配置错误在启动时暴露，而不是第一个请求时
+func (i *TenantServiceProvider) Boot() {
+	i.setup()
+}
+
+// lazyColumnPlugin 连接初始化时根据配置安装 ColumnPlugin
+type lazyColumnPlugin struct {
+	provider *TenantServiceProvider
+}
+
+func (i *lazyColumnPlugin) Name() string {
+	return "owl:tenant"
+}
+
+func (i *lazyColumnPlugin) Initialize(conn *gorm.DB) error {
+	opt := i.provider.setup().opt
+	if opt.Strategy != StrategyColumn {
+		return nil
+	}
+	return (&ColumnPlugin{Column: opt.Column, Required: opt.Required}).Initialize(conn)
+}
+
+//go:embed tenant.yaml
+var tenantYaml string
+
+func (i *TenantServiceProvider) Conf() map[string]string {
+	return map[string]string{
+		"tenant.yaml": tenantYaml,
+	}
+}
+
+func (i *TenantServiceProvider) ConfSchema() map[string]any {
+	return map[string]any{
+		"tenant.yaml": &Options{},
+	}
+}
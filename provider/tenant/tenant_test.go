@@ -0,0 +1,270 @@
+package tenant
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	logContract "bit-labs.cn/owl/contract/log"
+	"bit-labs.cn/owl/provider/db"
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+type nopLogger struct{}
+
+func (i nopLogger) WithContext(ctx context.Context) logContract.Logger { return i }
+func (i nopLogger) Emergency(content ...interface{})                   {}
+func (i nopLogger) Alert(content ...interface{})                       {}
+func (i nopLogger) Critical(content ...interface{})                    {}
+func (i nopLogger) Error(content ...interface{})                       {}
+func (i nopLogger) Warning(content ...interface{})                     {}
+func (i nopLogger) Notice(content ...interface{})                      {}
+func (i nopLogger) Info(content ...interface{})                        {}
+func (i nopLogger) Debug(content ...interface{})                       {}
+
+type project struct {
+	ID       uint
+	TenantID string
+	Name     string
+}
+
+func TestColumnPlugin(t *testing.T) {
+	conn, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = conn.Use(&ColumnPlugin{Column: "tenant_id", Required: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err = conn.AutoMigrate(&project{}); err != nil {
+		t.Fatal(err)
+	}
+
+	acme := WithTenant(context.Background(), "acme")
+	globex := WithTenant(context.Background(), "globex")
+	conn.WithContext(acme).Create(&[]project{{Name: "a1"}, {Name: "a2"}})
+	conn.WithContext(globex).Create(&project{Name: "g1"})
+
+	var list []project
+	conn.WithContext(acme).Where("name = ? OR name = ?", "a1", "g1").Find(&list)
+	if len(list) != 1 || list[0].Name != "a1" || list[0].TenantID != "acme" {
+		t.Fatalf("acme projects = %+v", list)
+	}
+
+	// 跨租户更新不生效
+	res := conn.WithContext(globex).Model(&project{}).Where("name = ?", "a2").Update("name", "hacked")
+	if res.Error != nil || res.RowsAffected != 0 {
+		t.Fatalf("update = %v/%d", res.Error, res.RowsAffected)
+	}
+
+	// 没有条件的更新、删除仍然返回 ErrMissingWhereClause，不会修改整个租户的数据
+	if err = conn.WithContext(acme).Model(&project{}).Update("name", "all").Error; !errors.Is(err, gorm.ErrMissingWhereClause) {
+		t.Fatalf("update without where err = %v", err)
+	}
+	if err = conn.WithContext(acme).Model(&project{}).Updates(&project{Name: "all"}).Error; !errors.Is(err, gorm.ErrMissingWhereClause) {
+		t.Fatalf("updates with zero id err = %v", err)
+	}
+	if err = conn.WithContext(acme).Delete(&project{}).Error; !errors.Is(err, gorm.ErrMissingWhereClause) {
+		t.Fatalf("delete without where err = %v", err)
+	}
+
+	var count int64
+	conn.WithContext(SkipTenant(context.Background())).Model(&project{}).Count(&count)
+	if count != 3 {
+		t.Fatalf("count = %d", count)
+	}
+	if err = conn.WithContext(context.Background()).Find(&list).Error; err != ErrTenantRequired {
+		t.Fatalf("err = %v", err)
+	}
+}
+
+func TestDatabaseStrategy(t *testing.T) {
+	dir := t.TempDir()
+	base := &db.Options{Driver: db.Sqlite, Host: filepath.Join(dir, "default.db")}
+	opt := &Options{Strategy: StrategyDatabase, Databases: map[string]db.Options{
+		"acme": {Host: filepath.Join(dir, "acme.db")},
+	}}
+	opt.setDefaults()
+
+	defaultDB := db.NewManager(base, nopLogger{}, nil).Default()
+	manager := NewManager(opt, base, nopLogger{}, nil)
+	db.SetConnectionSwitcher(manager.Switch)
+	defer db.SetConnectionSwitcher(nil)
+
+	acme := WithTenant(context.Background(), "acme")
+	for _, conn := range []*gorm.DB{defaultDB, manager.Connection("acme")} {
+		if err := conn.AutoMigrate(&project{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repo := db.NewBaseRepository[project](defaultDB)
+	if err := repo.WithContext(acme).Create(&project{Name: "a1"}); err != nil {
+		t.Fatal(err)
+	}
+	err := db.Tx(acme, func(ctx context.Context) error {
+		return defaultDB.WithContext(ctx).Create(&project{Name: "a2"}).Error
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count int64
+	defaultDB.Model(&project{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("default count = %d", count)
+	}
+	manager.Connection("acme").Model(&project{}).Count(&count)
+	if count != 2 {
+		t.Fatalf("acme count = %d", count)
+	}
+	if manager.Has("globex") {
+		t.Fatal("globex should not exist")
+	}
+
+	// 成员检查失败时不回落到默认连接
+	denied := context.WithValue(context.Background(), ContextKey, &member{
+		tenant: "acme",
+		check:  func(c *gin.Context, tenant string) error { return ErrTenantForbidden },
+	})
+	if err = repo.WithContext(denied).Create(&project{Name: "d1"}); !errors.Is(err, ErrTenantForbidden) {
+		t.Fatalf("create err = %v", err)
+	}
+	if err = defaultDB.WithContext(denied).Create(&project{Name: "d2"}).Error; !errors.Is(err, ErrTenantForbidden) {
+		t.Fatalf("create err = %v", err)
+	}
+	err = db.Tx(denied, func(ctx context.Context) error { return nil })
+	if !errors.Is(err, ErrTenantForbidden) {
+		t.Fatalf("tx err = %v", err)
+	}
+	defaultDB.Model(&project{}).Count(&count)
+	if count != 0 {
+		t.Fatalf("default count = %d", count)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	opt := &Options{Resolvers: []string{"header", "subdomain", "jwt"}, Domain: "saas.com", JWTSecret: "secret", Required: true}
+	opt.setDefaults()
+
+	engine := gin.New()
+	engine.Use(Middleware(opt, NewResolvers(opt), func(tenant string) bool { return tenant != "unknown" }))
+	engine.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, FromContext(c.Request.Context()))
+	})
+
+	valid := signJWT(`{"tenant_id":"jwt1"}`, "secret")
+	forged := signJWT(`{"tenant_id":"jwt1"}`, "other")
+	expired := signJWT(`{"tenant_id":"jwt1","exp":1}`, "secret")
+	cases := []struct {
+		host, header, auth string
+		status             int
+		tenant             string
+	}{
+		{host: "api.saas.com", header: "acme", status: 200, tenant: "acme"},
+		{host: "globex.saas.com", status: 200, tenant: "globex"},
+		{host: "localhost", auth: "Bearer " + valid, status: 200, tenant: "jwt1"},
+		{host: "localhost", auth: "Bearer " + forged, status: 400},
+		{host: "localhost", auth: "Bearer " + expired, status: 400},
+		{host: "localhost", status: 400},
+		{host: "localhost", header: "a'b", status: 400},
+		{host: "localhost", header: "unknown", status: 400},
+	}
+	for _, item := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Host = item.host
+		if item.header != "" {
+			req.Header.Set("X-Tenant-ID", item.header)
+		}
+		if item.auth != "" {
+			req.Header.Set("Authorization", item.auth)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != item.status || (item.status == 200 && w.Body.String() != item.tenant) {
+			t.Errorf("%+v: %d %s", item, w.Code, w.Body.String())
+		}
+	}
+}
+
+func signJWT(claims, secret string) string {
+	unsigned := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+		base64.RawURLEncoding.EncodeToString([]byte(claims))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestMemberChecker(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	conn, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = conn.Use(&ColumnPlugin{Column: "tenant_id"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = conn.AutoMigrate(&project{}); err != nil {
+		t.Fatal(err)
+	}
+	conn.WithContext(WithTenant(context.Background(), "acme")).Create(&project{Name: "a1"})
+
+	SetMemberChecker(func(c *gin.Context, tenant string) error {
+		// 检查中访问数据库需要跳过租户隔离
+		var count int64
+		conn.WithContext(SkipTenant(c)).Model(&project{}).Count(&count)
+		if c.GetString("user") != tenant+"-admin" {
+			return ErrTenantForbidden
+		}
+		return nil
+	})
+	defer SetMemberChecker(nil)
+
+	opt := &Options{}
+	opt.setDefaults()
+	engine := gin.New()
+	engine.Use(Middleware(opt, NewResolvers(opt), nil))
+	// 认证中间件在租户中间件之后运行
+	engine.Use(func(c *gin.Context) {
+		c.Set("user", c.GetHeader("X-User"))
+	})
+	engine.GET("/", func(c *gin.Context) {
+		var list []project
+		if err := conn.WithContext(c).Find(&list).Error; err != nil {
+			if !errors.Is(err, ErrTenantForbidden) {
+				t.Errorf("err = %v", err)
+			}
+			c.Status(http.StatusForbidden)
+			return
+		}
+		c.String(http.StatusOK, "%d", len(list))
+	})
+
+	cases := []struct {
+		user   string
+		status int
+		body   string
+	}{
+		{user: "acme-admin", status: 200, body: "1"},
+		{user: "globex-admin", status: 403},
+	}
+	for _, item := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Tenant-ID", "acme")
+		req.Header.Set("X-User", item.user)
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+		if w.Code != item.status || (item.status == 200 && w.Body.String() != item.body) {
+			t.Errorf("%+v: %d %s", item, w.Code, w.Body.String())
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"bit-labs.cn/owl/provider/router"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cast"
+	"gopkg.in/yaml.v3"
+)
+
+// pluralForms 复数形式，值为包含这些键且包含 other 的对象时按数量选择
+var pluralForms = map[string]bool{"zero": true, "one": true, "two": true, "few": true, "many": true, "other": true}
+
+var placeholderRegexp = regexp.MustCompile(`\{\{\s*([\w.]+)\s*\}\}`)
+
+// Translator 多语言翻译，消息文件按语言组织，键使用 . 连接嵌套的层级
+//
+//	lang/zh.yaml            顶层文件，文件名为语言
+//	lang/en/order.yaml      模块文件，目录名为语言，键以文件名为前缀，如 order.paid
+//
+// 消息中使用 {{name}} 或 {{1}} 作为占位符，复数使用包含 one、other 等键的对象，按 count 参数选择
+type Translator struct {
+	lock          sync.RWMutex
+	defaultLocale string
+	messages      map[string]map[string]any // 语言 -> 键 -> string 或 map[string]string(复数)
+	dirs          []string                  // LoadDir 加载过的目录，模块消息后注册时重新加载以保持覆盖顺序
+}
+
+// New defaultLocale 为请求的语言没有对应翻译时使用的语言
+func New(defaultLocale string) *Translator {
+	return &Translator{
+		defaultLocale: normalizeLocale(defaultLocale),
+		messages:      make(map[string]map[string]any),
+	}
+}
+
+// DefaultLocale 默认语言
+func (i *Translator) DefaultLocale() string {
+	return i.defaultLocale
+}
+
+// Add 添加消息，嵌套的对象展开为以 . 连接的键，已存在的键被覆盖
+func (i *Translator) Add(locale string, messages map[string]any) {
+	locale = normalizeLocale(locale)
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	if i.messages[locale] == nil {
+		i.messages[locale] = make(map[string]any)
+	}
+	flatten(i.messages[locale], "", messages)
+}
+
+// Load 加载 fsys 中 dir 目录下的 json、yaml 消息文件
+func (i *Translator) Load(fsys fs.FS, dir string) error {
+	return fs.WalkDir(fsys, dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		ext := path.Ext(p)
+		if ext != ".json" && ext != ".yaml" && ext != ".yml" {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		var messages map[string]any
+		if ext == ".json" {
+			err = json.Unmarshal(data, &messages)
+		} else {
+			err = yaml.Unmarshal(data, &messages)
+		}
+		if err != nil {
+			return fmt.Errorf("解析消息文件 %s 失败: %w", p, err)
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(p, dir), "/")
+		name := strings.TrimSuffix(path.Base(rel), ext)
+		if locale := path.Dir(rel); locale != "." {
+			// 模块文件，如 en/order.yaml
+			i.Add(path.Base(locale), map[string]any{name: messages})
+		} else {
+			i.Add(name, messages)
+		}
+		return nil
+	})
+}
+
+// LoadDir 加载磁盘目录中的消息文件，目录不存在时忽略
+// 目录中的消息优先于 Register 注册的模块消息，即使模块在之后注册
+func (i *Translator) LoadDir(dir string) error {
+	i.lock.Lock()
+	i.dirs = append(i.dirs, dir)
+	i.lock.Unlock()
+	return i.loadDir(dir)
+}
+
+func (i *Translator) loadDir(dir string) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	return i.Load(os.DirFS(dir), ".")
+}
+
+// Get 翻译消息，请求的语言没有对应翻译时使用默认语言，都没有时 ok 为 false
+// args 为一个 map[string]any 时替换命名占位符，否则按顺序替换 {{1}}、{{2}}
+func (i *Translator) Get(locale, key string, args ...any) (msg string, ok bool) {
+	i.lock.RLock()
+	value, ok := i.lookup(normalizeLocale(locale), key)
+	i.lock.RUnlock()
+	if !ok {
+		return "", false
+	}
+
+	named, _ := singleMap(args)
+	switch v := value.(type) {
+	case string:
+		msg = v
+	case map[string]string:
+		msg = plural(v, count(named, args))
+	}
+	return replacePlaceholders(msg, named, args), true
+}
+
+// T 翻译消息，没有对应翻译时返回 key
+func (i *Translator) T(locale, key string, args ...any) string {
+	if msg, ok := i.Get(locale, key, args...); ok {
+		return msg
+	}
+	return key
+}
+
+// Trans 按请求的语言翻译消息，语言的识别规则见 router.Locale
+func (i *Translator) Trans(ctx *gin.Context, key string, args ...any) string {
+	return i.T(router.Locale(ctx), key, args...)
+}
+
+// Locales 返回所有语言
+func (i *Translator) Locales() []string {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	locales := make([]string, 0, len(i.messages))
+	for locale := range i.messages {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Missing 返回每种语言缺少的键，以所有语言的键的并集为准
+func (i *Translator) Missing() map[string][]string {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+
+	all := map[string]bool{}
+	for _, messages := range i.messages {
+		for key := range messages {
+			all[key] = true
+		}
+	}
+	result := map[string][]string{}
+	for locale, messages := range i.messages {
+		for key := range all {
+			if _, ok := messages[key]; !ok {
+				result[locale] = append(result[locale], key)
+			}
+		}
+		sort.Strings(result[locale])
+	}
+	return result
+}
+
+func (i *Translator) lookup(locale, key string) (any, bool) {
+	candidates := []string{locale}
+	if idx := strings.IndexAny(locale, "-_"); idx > 0 {
+		candidates = append(candidates, locale[:idx])
+	}
+	candidates = append(candidates, i.defaultLocale)
+	for _, l := range candidates {
+		if value, ok := i.messages[l][key]; ok {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func flatten(dst map[string]any, prefix string, src map[string]any) {
+	for key, value := range src {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]any:
+			if forms, ok := pluralMessage(v); ok {
+				dst[key] = forms
+			} else {
+				flatten(dst, key, v)
+			}
+		default:
+			dst[key] = cast.ToString(v)
+		}
+	}
+}
+
+// pluralMessage 对象的键都是复数形式且包含 other 时为复数消息
+func pluralMessage(v map[string]any) (map[string]string, bool) {
+	if _, ok := v["other"]; !ok {
+		return nil, false
+	}
+	forms := make(map[string]string, len(v))
+	for form, msg := range v {
+		if !pluralForms[form] {
+			return nil, false
+		}
+		forms[form] = cast.ToString(msg)
+	}
+	return forms, true
+}
+
+// plural 按数量选择复数形式，只区分 zero、one 和 other
+func plural(forms map[string]string, n int) string {
+	if msg, ok := forms["zero"]; ok && n == 0 {
+		return msg
+	}
+	if msg, ok := forms["one"]; ok && n == 1 {
+		return msg
+	}
+	return forms["other"]
+}
+
+func singleMap(args []any) (map[string]any, bool) {
+	if len(args) != 1 {
+		return nil, false
+	}
+	switch m := args[0].(type) {
+	case map[string]any:
+		return m, true
+	case gin.H:
+		return m, true
+	}
+	return nil, false
+}
+
+// count 复数使用的数量，命名参数为 count，顺序参数为第一个参数
+func count(named map[string]any, args []any) int {
+	if named != nil {
+		return cast.ToInt(named["count"])
+	}
+	if len(args) > 0 {
+		return cast.ToInt(args[0])
+	}
+	return 0
+}
+
+func replacePlaceholders(msg string, named map[string]any, args []any) string {
+	if len(args) == 0 {
+		return msg
+	}
+	return placeholderRegexp.ReplaceAllStringFunc(msg, func(m string) string {
+		name := placeholderRegexp.FindStringSubmatch(m)[1]
+		if named != nil {
+			if v, ok := named[name]; ok {
+				return cast.ToString(v)
+			}
+			return m
+		}
+		if n, err := cast.ToIntE(name); err == nil && n >= 1 && n <= len(args) {
+			return cast.ToString(args[n-1])
+		}
+		if name == "count" {
+			// 顺序参数时 {{count}} 与 {{1}} 相同
+			return cast.ToString(args[0])
+		}
+		return m
+	})
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(locale)), "_", "-")
+}
+
+var (
+	sourcesLock sync.Mutex
+	sources     []source
+	instances   []*Translator
+)
+
+type source struct {
+	fsys fs.FS
+	dir  string
+}
+
+// Register 注册模块内嵌的消息文件，已创建的翻译服务同样生效
+//
+//	//go:embed lang
+//	var langFS embed.FS
+//
+//	translator.Register(langFS, "lang")
+func Register(fsys fs.FS, dir string) {
+	sourcesLock.Lock()
+	defer sourcesLock.Unlock()
+	sources = append(sources, source{fsys: fsys, dir: dir})
+	for _, t := range instances {
+		if err := t.Load(fsys, dir); err != nil {
+			panic(err)
+		}
+		t.lock.RLock()
+		dirs := append([]string(nil), t.dirs...)
+		t.lock.RUnlock()
+		for _, d := range dirs {
+			if err := t.loadDir(d); err != nil {
+				panic(err)
+			}
+		}
+	}
+}
+
+// loadSources 加载已注册的模块消息，之后注册的模块消息也会加载到 t 中
+func loadSources(t *Translator) error {
+	sourcesLock.Lock()
+	defer sourcesLock.Unlock()
+	for _, s := range sources {
+		if err := t.Load(s.fsys, s.dir); err != nil {
+			return err
+		}
+	}
+	instances = append(instances, t)
+	return nil
+}
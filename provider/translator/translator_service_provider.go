@@ -0,0 +1,114 @@
+package translator
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"bit-labs.cn/owl/contract/foundation"
+	"bit-labs.cn/owl/provider/appconf"
+	"bit-labs.cn/owl/provider/router"
+	"bit-labs.cn/owl/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/spf13/cobra"
+)
+
+var _ foundation.ServiceProvider = (*TranslatorServiceProvider)(nil)
+var _ foundation.CommandProvider = (*TranslatorServiceProvider)(nil)
+
+//go:embed lang
+var langFS embed.FS
+
+type TranslatorServiceProvider struct {
+	app foundation.Application
+}
+
+func (i *TranslatorServiceProvider) Description() string {
+	return "多语言翻译"
+}
+
+func (i *TranslatorServiceProvider) Register() {
+	i.app.Register(func(opt *appconf.Options) (*Translator, error) {
+		if opt.Locale != "" {
+			i.app.SetLocale(opt.Locale)
+		}
+
+		// 加载顺序：框架消息、模块消息、应用 lang 目录，后加载的覆盖先加载的
+		t := New(i.app.GetLocale())
+		if err := t.Load(langFS, "lang"); err != nil {
+			return nil, err
+		}
+		if err := loadSources(t); err != nil {
+			return nil, err
+		}
+		if err := t.LoadDir(i.app.GetLangPath()); err != nil {
+			return nil, err
+		}
+		return t, nil
+	})
+}
+
+func (i *TranslatorServiceProvider) Boot() {
+	err := i.app.Invoke(func(t *Translator) {
+		router.SetDefaultLocale(t.DefaultLocale())
+		router.SetMessageTranslator(func(ctx *gin.Context, key string, fallback string) string {
+			if msg, ok := t.Get(router.Locale(ctx), key); ok {
+				return msg
+			}
+			return fallback
+		})
+	})
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (i *TranslatorServiceProvider) Conf() map[string]string {
+	return nil
+}
+
+// Commands 多语言相关命令
+func (i *TranslatorServiceProvider) Commands() []*cobra.Command {
+	return []*cobra.Command{i.newLangMissingCommand()}
+}
+
+func (i *TranslatorServiceProvider) newLangMissingCommand() *cobra.Command {
+	var locales []string
+	cmd := &cobra.Command{
+		Use:   "lang:missing",
+		Short: "查看未翻译的消息",
+		Long:  "以所有语言消息键的并集为准，列出每种语言缺少翻译的键，存在未翻译的消息时返回错误，可用于持续集成检查",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var t *Translator
+			if err := i.app.Invoke(func(translator *Translator) { t = translator }); err != nil {
+				return err
+			}
+
+			missing := t.Missing()
+			if len(locales) == 0 {
+				locales = t.Locales()
+			}
+
+			total := 0
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			_, _ = fmt.Fprintln(w, "语言\t键")
+			for _, locale := range locales {
+				for _, key := range missing[normalizeLocale(locale)] {
+					_, _ = fmt.Fprintf(w, "%s\t%s\n", locale, key)
+					total++
+				}
+			}
+			if total == 0 {
+				utils.PrintLnGreen("所有消息均已翻译")
+				return nil
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			return fmt.Errorf("存在 %d 条未翻译的消息", total)
+		},
+	}
+	cmd.Flags().StringSliceVar(&locales, "locale", nil, "只检查指定的语言，多个语言使用逗号分隔")
+	return cmd
+}
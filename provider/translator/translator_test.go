@@ -0,0 +1,136 @@
+package translator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"bit-labs.cn/owl/provider/router"
+	"github.com/gin-gonic/gin"
+)
+
+func TestTranslate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"lang/zh.json": {Data: []byte(`{"greeting": "你好，{{name}}"}`)},
+		"lang/en.yaml": {Data: []byte("greeting: Hello, {{name}}\n")},
+		"lang/zh/order.yaml": {Data: []byte(`
+paid: 订单 {{1}} 已支付
+items:
+  one: "{{count}} 件商品"
+  other: "{{count}} 件商品"
+`)},
+		"lang/en/order.yaml": {Data: []byte(`
+items:
+  zero: No items
+  one: "{{count}} item"
+  other: "{{count}} items"
+`)},
+	}
+	tr := New("zh")
+	if err := tr.Load(fsys, "lang"); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		locale, key string
+		args        []any
+		want        string
+	}{
+		{"zh", "greeting", []any{map[string]any{"name": "张三"}}, "你好，张三"},
+		{"en-US", "greeting", []any{map[string]any{"name": "Tom"}}, "Hello, Tom"},
+		{"zh", "order.paid", []any{"A001"}, "订单 A001 已支付"},
+		{"en", "order.paid", []any{"A001"}, "订单 A001 已支付"}, // 没有英文翻译时使用默认语言
+		{"en", "order.items", []any{map[string]any{"count": 0}}, "No items"},
+		{"en", "order.items", []any{map[string]any{"count": 1}}, "1 item"},
+		{"en", "order.items", []any{3}, "3 items"},
+		{"fr", "order.items", []any{2}, "2 件商品"},
+		{"zh", "unknown", nil, "unknown"},
+	}
+	for _, c := range cases {
+		if got := tr.T(c.locale, c.key, c.args...); got != c.want {
+			t.Errorf("T(%s, %s) = %q, want %q", c.locale, c.key, got, c.want)
+		}
+	}
+
+	want := map[string][]string{"en": {"order.paid"}}
+	if got := tr.Missing(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Missing() = %v, want %v", got, want)
+	}
+}
+
+func TestRegisterKeepsAppOverrides(t *testing.T) {
+	dir := t.TempDir()
+	tr := New("zh")
+	if err := loadSources(tr); err != nil {
+		t.Fatal(err)
+	}
+	defer func() { instances = instances[:len(instances)-1] }()
+	if err := tr.LoadDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	tr.Add("zh", map[string]any{"shop": map[string]any{"title": "商城"}})
+
+	// 模块在翻译服务创建之后注册，消息同样生效
+	Register(fstest.MapFS{"lang/zh/shop.yaml": {Data: []byte("name: 小店\n")}}, "lang")
+	defer func() { sources = sources[:len(sources)-1] }()
+
+	if got := tr.T("zh", "shop.name"); got != "小店" {
+		t.Errorf("shop.name = %q", got)
+	}
+}
+
+func TestLocaleAwareResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tr := New("zh")
+	if err := tr.Load(langFS, "lang"); err != nil {
+		t.Fatal(err)
+	}
+	router.SetMessageTranslator(func(ctx *gin.Context, key string, fallback string) string {
+		if msg, ok := tr.Get(router.Locale(ctx), key); ok {
+			return msg
+		}
+		return fallback
+	})
+	defer router.SetMessageTranslator(nil)
+
+	engine := gin.New()
+	engine.GET("/ok", func(c *gin.Context) { router.Success(c, nil) })
+	engine.GET("/missing", func(c *gin.Context) { router.Fail(c, router.ErrNotFound) })
+
+	cases := []struct {
+		path, lang, want string
+	}{
+		{"/ok", "en-US,en;q=0.9", "Success"},
+		{"/ok", "", "操作成功"},
+		{"/missing", "en", "Record not found"},
+	}
+	for _, c := range cases {
+		req := httptest.NewRequest(http.MethodGet, c.path, nil)
+		if c.lang != "" {
+			req.Header.Set("Accept-Language", c.lang)
+		}
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, req)
+
+		var resp router.Resp
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Msg != c.want {
+			t.Errorf("%s [%s] msg = %q, want %q", c.path, c.lang, resp.Msg, c.want)
+		}
+	}
+
+	// 请求没有指定语言时使用 app.yaml 中的 locale
+	router.SetDefaultLocale("en")
+	defer router.SetDefaultLocale("zh")
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/ok", nil))
+	var resp router.Resp
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Msg != "Success" {
+		t.Errorf("default locale msg = %q, err = %v", resp.Msg, err)
+	}
+}
@@ -0,0 +1,103 @@
+package validator
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// commonRules 内置的常用规则
+var commonRules = []Rule{
+	{
+		Tag:      "mobile",
+		Func:     stringRule(IsMobile),
+		Messages: map[string]string{"zh": "{0}必须是有效的手机号码", "en": "{0} must be a valid mobile phone number"},
+	},
+	{
+		Tag:      "idcard",
+		Func:     stringRule(IsIDCard),
+		Messages: map[string]string{"zh": "{0}必须是有效的身份证号码", "en": "{0} must be a valid ID card number"},
+	},
+	{
+		Tag:      "uscc",
+		Func:     stringRule(IsUSCC),
+		Messages: map[string]string{"zh": "{0}必须是有效的统一社会信用代码", "en": "{0} must be a valid unified social credit code"},
+	},
+	{
+		Tag:      "snowflake",
+		Func:     stringRule(IsSnowflakeID),
+		Messages: map[string]string{"zh": "{0}必须是有效的 ID", "en": "{0} must be a valid ID"},
+	},
+}
+
+// stringRule 只校验字符串字段，其他类型的字段校验失败
+func stringRule(fn func(string) bool) validator.Func {
+	return func(fl validator.FieldLevel) bool {
+		s, ok := fl.Field().Interface().(string)
+		return ok && fn(s)
+	}
+}
+
+var mobileRegexp = regexp.MustCompile(`^1[3-9]\d{9}$`)
+
+// IsMobile 中国大陆手机号码
+func IsMobile(s string) bool {
+	return mobileRegexp.MatchString(s)
+}
+
+var (
+	idCardWeights = []int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	idCardChecks  = "10X98765432"
+	idCardRegexp  = regexp.MustCompile(`^\d{17}[\dXx]$`)
+)
+
+// IsIDCard 18 位居民身份证号码，校验出生日期和校验码
+func IsIDCard(s string) bool {
+	if !idCardRegexp.MatchString(s) {
+		return false
+	}
+	birthday, err := time.Parse("20060102", s[6:14])
+	if err != nil || birthday.After(time.Now()) || birthday.Year() < 1900 {
+		return false
+	}
+	sum := 0
+	for n, w := range idCardWeights {
+		sum += int(s[n]-'0') * w
+	}
+	return idCardChecks[sum%11] == strings.ToUpper(s[17:])[0]
+}
+
+var (
+	usccChars   = "0123456789ABCDEFGHJKLMNPQRTUWXY"
+	usccWeights = []int{1, 3, 9, 27, 19, 26, 16, 17, 20, 29, 25, 13, 8, 24, 10, 30, 28}
+)
+
+// IsUSCC 18 位统一社会信用代码，校验字符集和校验码
+func IsUSCC(s string) bool {
+	if len(s) != 18 {
+		return false
+	}
+	s = strings.ToUpper(s)
+	sum := 0
+	for n, w := range usccWeights {
+		idx := strings.IndexByte(usccChars, s[n])
+		if idx < 0 {
+			return false
+		}
+		sum += idx * w
+	}
+	check := (31 - sum%31) % 31
+	return s[17] == usccChars[check]
+}
+
+// IsSnowflakeID 字符串形式的雪花 ID，前端传递 ID 时使用字符串避免精度丢失
+func IsSnowflakeID(s string) bool {
+	if s == "" || len(s) > 20 || s[0] == '0' {
+		return false
+	}
+	id, err := strconv.ParseUint(s, 10, 64)
+	return err == nil && id > 0
+}
@@ -0,0 +1,56 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+func TestCommonRules(t *testing.T) {
+	cases := []struct {
+		fn    func(string) bool
+		value string
+		want  bool
+	}{
+		{IsMobile, "13800138000", true},
+		{IsMobile, "12800138000", false},
+		{IsMobile, "1380013800", false},
+		{IsIDCard, "11010519491231002X", true},
+		{IsIDCard, "11010519491231002x", true},
+		{IsIDCard, "110105194912310021", false},
+		{IsIDCard, "11010519491331002X", false},
+		{IsUSCC, "91350100M000100Y43", true},
+		{IsUSCC, "91350100M000100Y44", false},
+		{IsUSCC, "91350100M000100I43", false},
+		{IsSnowflakeID, "797351428456058880", true},
+		{IsSnowflakeID, "0797351428456058880", false},
+		{IsSnowflakeID, "99999999999999999999", false},
+		{IsSnowflakeID, "12a", false},
+	}
+	for _, c := range cases {
+		if got := c.fn(c.value); got != c.want {
+			t.Errorf("%s = %v, want %v", c.value, got, c.want)
+		}
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	type req struct {
+		Mobile string `json:"mobile" binding:"required,mobile"`
+		Name   string `form:"name" binding:"required"`
+	}
+	err := New("binding").Struct(&req{Mobile: "123"})
+	errs, ok := err.(validator.ValidationErrors)
+	if !ok || len(errs) != 2 {
+		t.Fatalf("err = %v", err)
+	}
+	if got := Translate("zh", errs[0]); got != "mobile必须是有效的手机号码" {
+		t.Errorf("zh = %s", got)
+	}
+	if got := Translate("en", errs[1]); got != "name is a required field" {
+		t.Errorf("en = %s", got)
+	}
+	if got := Translate("fr", errs[1]); got != "name为必填字段" {
+		t.Errorf("fallback = %s", got)
+	}
+}
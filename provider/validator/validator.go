@@ -0,0 +1,168 @@
+package validator
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"bit-labs.cn/owl/provider/router"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/zh"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	zhTranslations "github.com/go-playground/validator/v10/translations/zh"
+)
+
+// Rule 自定义校验规则，Messages 为各语言的错误信息，{0} 为字段名称，{1} 为规则参数
+//
+//	validator.Rule{
+//		Tag:      "username",
+//		Func:     func(fl validator.FieldLevel) bool { ... },
+//		Messages: map[string]string{"zh": "{0}只能包含字母和数字", "en": "{0} must be alphanumeric"},
+//	}
+type Rule struct {
+	Tag        string
+	Func       validator.Func
+	CallIfNull bool
+	Messages   map[string]string
+}
+
+// DefaultLocale 请求没有指定语言或语言不支持时使用的语言
+const DefaultLocale = "zh"
+
+var (
+	uni = ut.New(zh.New(), zh.New(), en.New())
+
+	rulesLock sync.Mutex
+	rules     []Rule
+	instances []*validator.Validate
+)
+
+// RegisterRules 注册自定义校验规则，容器中的验证器、gin 请求绑定的验证器以及之后创建的验证器同时生效，同名规则覆盖之前的注册
+// 自定义规则都应通过该方法注册，直接调用某个验证器的 RegisterValidation 不会同步到其他验证器
+func RegisterRules(list ...Rule) {
+	rulesLock.Lock()
+	defer rulesLock.Unlock()
+	rules = append(rules, list...)
+	for _, v := range instances {
+		for _, rule := range list {
+			applyRule(v, rule)
+		}
+	}
+}
+
+// New 创建使用 json 名称作为字段名称的验证器，注册常用规则、自定义规则和中英文翻译
+// tagName 为校验规则使用的标签，容器中的验证器使用 validate，gin 请求绑定使用 binding，两者是不同的实例
+func New(tagName string) *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.SetTagName(tagName)
+	v.RegisterTagNameFunc(func(fld reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name := strings.Split(fld.Tag.Get(tag), ",")[0]
+			if name == "-" {
+				return fld.Name
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return fld.Name
+	})
+
+	zhTrans, _ := uni.GetTranslator("zh")
+	enTrans, _ := uni.GetTranslator("en")
+	_ = zhTranslations.RegisterDefaultTranslations(v, zhTrans)
+	_ = enTranslations.RegisterDefaultTranslations(v, enTrans)
+
+	rulesLock.Lock()
+	defer rulesLock.Unlock()
+	for _, rule := range commonRules {
+		applyRule(v, rule)
+	}
+	for _, rule := range rules {
+		applyRule(v, rule)
+	}
+	instances = append(instances, v)
+	return v
+}
+
+func applyRule(v *validator.Validate, rule Rule) {
+	if err := v.RegisterValidation(rule.Tag, rule.Func, rule.CallIfNull); err != nil {
+		panic("注册校验规则 " + rule.Tag + " 失败: " + err.Error())
+	}
+	for locale, message := range rule.Messages {
+		trans, found := uni.GetTranslator(locale)
+		if !found {
+			continue
+		}
+		message := message
+		_ = v.RegisterTranslation(rule.Tag, trans, func(ut ut.Translator) error {
+			return ut.Add(rule.Tag, message, true)
+		}, func(ut ut.Translator, fe validator.FieldError) string {
+			t, err := ut.T(fe.Tag(), fe.Field(), fe.Param())
+			if err != nil {
+				return fe.Error()
+			}
+			return t
+		})
+	}
+}
+
+// Translate 按语言翻译字段错误，语言不支持时依次使用应用的默认语言（app.yaml 的 locale）和 DefaultLocale
+func Translate(locale string, fe validator.FieldError) string {
+	trans, found := uni.GetTranslator(locale)
+	if !found {
+		trans, found = uni.GetTranslator(router.DefaultLocale())
+	}
+	if !found {
+		trans, _ = uni.GetTranslator(DefaultLocale)
+	}
+	return fe.Translate(trans)
+}
+
+// ginValidator gin 请求绑定使用的验证器，第一次绑定时创建
+type ginValidator struct {
+	once     sync.Once
+	validate *validator.Validate
+}
+
+var _ binding.StructValidator = (*ginValidator)(nil)
+
+func (i *ginValidator) ValidateStruct(obj any) error {
+	if obj == nil {
+		return nil
+	}
+	value := reflect.ValueOf(obj)
+	switch value.Kind() {
+	case reflect.Ptr:
+		if value.Elem().Kind() != reflect.Struct {
+			return i.ValidateStruct(value.Elem().Interface())
+		}
+		return i.Engine().(*validator.Validate).Struct(obj)
+	case reflect.Struct:
+		return i.Engine().(*validator.Validate).Struct(obj)
+	case reflect.Slice, reflect.Array:
+		// 校验通过的元素保留 nil，下标与请求体中的元素对应，router.FieldErrors 据此生成 [1].name 形式的键
+		errs := make(binding.SliceValidationError, value.Len())
+		failed := false
+		for n := 0; n < value.Len(); n++ {
+			if errs[n] = i.ValidateStruct(value.Index(n).Interface()); errs[n] != nil {
+				failed = true
+			}
+		}
+		if !failed {
+			return nil
+		}
+		return errs
+	}
+	return nil
+}
+
+func (i *ginValidator) Engine() any {
+	i.once.Do(func() {
+		i.validate = New("binding")
+	})
+	return i.validate
+}
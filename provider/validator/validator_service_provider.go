@@ -1,10 +1,10 @@
 package validator
 
 import (
-	"reflect"
-	"strings"
-
 	"bit-labs.cn/owl/contract/foundation"
+	"bit-labs.cn/owl/provider/router"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 )
 
@@ -18,21 +18,17 @@ func (i *ValidatorServiceProvider) Description() string {
 	return "结构体验证器"
 }
 
+// Register 注册两个验证器：容器中使用 validate 标签的验证器和 gin 请求绑定使用 binding 标签的验证器
+// 一个验证器只能使用一种标签，两者共享字段名称、规则和翻译，自定义规则需要通过 RegisterRules 注册
+// 直接调用容器中验证器的 RegisterValidation 只对该实例生效，请求绑定不会使用
 func (i *ValidatorServiceProvider) Register() {
 	i.app.Register(func() *validator.Validate {
-		v := validator.New(validator.WithRequiredStructEnabled())
-		v.RegisterTagNameFunc(func(fld reflect.StructField) string {
-			name := fld.Tag.Get("json")
-			if name == "" {
-				return fld.Name
-			}
-			name = strings.Split(name, ",")[0]
-			if name == "" || name == "-" {
-				return fld.Name
-			}
-			return name
-		})
-		return v
+		return New("validate")
+	})
+
+	binding.Validator = &ginValidator{}
+	router.SetFieldErrorTranslator(func(ctx *gin.Context, fe validator.FieldError) string {
+		return Translate(router.Locale(ctx), fe)
 	})
 }
 
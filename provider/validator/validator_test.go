@@ -0,0 +1,120 @@
+package validator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"bit-labs.cn/owl/provider/router"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+func TestGinBinding(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	binding.Validator = &ginValidator{}
+	router.SetFieldErrorTranslator(func(ctx *gin.Context, fe validator.FieldError) string {
+		return Translate(router.Locale(ctx), fe)
+	})
+	RegisterRules(Rule{
+		Tag:      "even",
+		Func:     func(fl validator.FieldLevel) bool { return fl.Field().Int()%2 == 0 },
+		Messages: map[string]string{"zh": "{0}必须是偶数", "en": "{0} must be even"},
+	})
+
+	type req struct {
+		Mobile string `json:"mobile" binding:"required,mobile"`
+		Count  int    `json:"count" binding:"even"`
+	}
+	engine := gin.New()
+	engine.POST("/", func(c *gin.Context) {
+		var r req
+		if err := c.ShouldBindJSON(&r); err != nil {
+			router.Fail(c, router.BindError(err))
+			return
+		}
+		router.Success(c, r)
+	})
+
+	send := func(lang, body string) router.Resp {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		r.Header.Set("Accept-Language", lang)
+		engine.ServeHTTP(w, r)
+		var resp router.Resp
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp
+	}
+
+	resp := send("zh-CN,zh;q=0.9", `{"mobile":"123","count":3}`)
+	details, _ := resp.Details.(map[string]any)
+	if resp.Code != router.CodeValidation || resp.Msg != "mobile必须是有效的手机号码" || details["count"] != "count必须是偶数" {
+		t.Fatalf("zh resp = %+v", resp)
+	}
+	resp = send("en-US", `{"mobile":"13800138000","count":3}`)
+	if resp.Msg != "count must be even" {
+		t.Fatalf("en resp = %+v", resp)
+	}
+	if resp = send("en", `{"mobile":"13800138000","count":4}`); !resp.Success {
+		t.Fatalf("valid resp = %+v", resp)
+	}
+}
+
+func TestFieldErrorsPath(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	binding.Validator = &ginValidator{}
+
+	type item struct {
+		Name string `json:"name" binding:"required"`
+	}
+	type order struct {
+		Name    string `json:"name" binding:"required"`
+		Address struct {
+			Name string `json:"name" binding:"required"`
+		} `json:"address"`
+		Items []item `json:"items" binding:"dive"`
+	}
+	bind := func(body string, obj any) router.FieldErrorMap {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		return router.FieldErrors(c, c.ShouldBindJSON(obj))
+	}
+
+	// 嵌套和数组中的同名字段不会互相覆盖
+	fields := bind(`{"items":[{"name":"a"},{}]}`, &order{})
+	if len(fields) != 3 || fields["name"] == "" || fields["address.name"] == "" || fields["items[1].name"] == "" {
+		t.Fatalf("fields = %v", fields)
+	}
+	// 数组请求体的键以元素下标开头
+	fields = bind(`[{"name":"a"},{}]`, &[]item{})
+	if len(fields) != 1 || fields["[1].name"] == "" {
+		t.Fatalf("slice fields = %v", fields)
+	}
+}
+
+func TestRegisterRulesSharedByInstances(t *testing.T) {
+	type req struct {
+		Code string `json:"code" validate:"upper_code" binding:"upper_code"`
+	}
+	container := New("validate")
+	gv := &ginValidator{}
+	_ = gv.Engine()
+
+	// 两个验证器创建之后注册的规则同时生效
+	RegisterRules(Rule{
+		Tag:  "upper_code",
+		Func: func(fl validator.FieldLevel) bool { return strings.ToUpper(fl.Field().String()) == fl.Field().String() },
+	})
+	if err := container.Struct(&req{Code: "abc"}); err == nil {
+		t.Fatal("container validator should use the rule")
+	}
+	if err := gv.ValidateStruct(&req{Code: "abc"}); err == nil {
+		t.Fatal("binding validator should use the rule")
+	}
+	if err := gv.ValidateStruct(&req{Code: "ABC"}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,69 @@
+package owl
+
+import (
+	"fmt"
+	"slices"
+
+	"bit-labs.cn/owl/contract"
+	"bit-labs.cn/owl/utils"
+	"github.com/spf13/cobra"
+	"gorm.io/gorm"
+)
+
+// SeederProvider 子应用可选实现此接口，提供 db:seed 命令执行的数据填充，按返回顺序执行
+type SeederProvider interface {
+	Seeders() []contract.Seeder
+}
+
+// seeders 按子应用注册顺序收集所有数据填充
+func (i *Application) seeders() []contract.Seeder {
+	var result []contract.Seeder
+	for _, app := range i.subApps {
+		if p, ok := app.(SeederProvider); ok {
+			result = append(result, p.Seeders()...)
+		}
+	}
+	return result
+}
+
+// newSeedCommand 创建 db:seed 命令
+func (i *Application) newSeedCommand() *cobra.Command {
+	var classes []string
+	var list bool
+	cmd := &cobra.Command{
+		Use:   "db:seed",
+		Short: "填充数据",
+		Long:  "按子应用注册顺序执行数据填充，每个数据填充在独立的事务中执行，使用 --class 只执行指定的数据填充",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			seeders := i.seeders()
+			if list {
+				for _, s := range seeders {
+					fmt.Println(s.Name())
+				}
+				return nil
+			}
+
+			for _, class := range classes {
+				if !slices.ContainsFunc(seeders, func(s contract.Seeder) bool { return s.Name() == class }) {
+					return fmt.Errorf("数据填充 %s 不存在", class)
+				}
+			}
+
+			return i.Invoke(func(db *gorm.DB) error {
+				for _, s := range seeders {
+					if len(classes) > 0 && !slices.Contains(classes, s.Name()) {
+						continue
+					}
+					if err := db.Transaction(s.Run); err != nil {
+						return fmt.Errorf("数据填充 %s 执行失败: %w", s.Name(), err)
+					}
+					utils.PrintLnGreen("已执行数据填充: ", s.Name())
+				}
+				return nil
+			})
+		},
+	}
+	cmd.Flags().StringSliceVar(&classes, "class", nil, "只执行指定名称的数据填充，多个名称使用逗号分隔")
+	cmd.Flags().BoolVar(&list, "list", false, "列出所有数据填充")
+	return cmd
+}
@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext 差异前后保留的上下文行数
+const diffContext = 3
+
+type diffLine struct {
+	op   byte // ' ' 相同，'-' 删除，'+' 新增
+	text string
+}
+
+// UnifiedDiff 按行比较两段文本，返回 unified 格式的差异，内容相同时返回空字符串
+func UnifiedDiff(fromName, toName, from, to string) string {
+	if from == to {
+		return ""
+	}
+	lines := diffLines(splitLines(from), splitLines(to))
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", fromName, toName))
+
+	for start := 0; start < len(lines); {
+		// 找到下一处差异
+		for start < len(lines) && lines[start].op == ' ' {
+			start++
+		}
+		if start == len(lines) {
+			break
+		}
+
+		// 向后合并间隔不超过两倍上下文的差异
+		end := start
+		for idx := start; idx < len(lines); idx++ {
+			if lines[idx].op != ' ' {
+				end = idx + 1
+			} else if idx-end >= diffContext*2 {
+				break
+			}
+		}
+
+		from, to := max(start-diffContext, 0), min(end+diffContext, len(lines))
+		oldStart, newStart := lineNumbers(lines[:from])
+		oldCount, newCount := lineNumbers(lines[from:to])
+		b.WriteString(fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", oldStart+1, oldCount, newStart+1, newCount))
+		for _, l := range lines[from:to] {
+			b.WriteByte(l.op)
+			b.WriteString(l.text)
+			b.WriteByte('\n')
+		}
+		start = to
+	}
+	return b.String()
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// lineNumbers 统计旧文本和新文本的行数
+func lineNumbers(lines []diffLine) (int, int) {
+	var oldCount, newCount int
+	for _, l := range lines {
+		if l.op != '+' {
+			oldCount++
+		}
+		if l.op != '-' {
+			newCount++
+		}
+	}
+	return oldCount, newCount
+}
+
+// diffLines 基于最长公共子序列计算逐行差异
+func diffLines(a, b []string) []diffLine {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var result []diffLine
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, diffLine{op: ' ', text: a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, diffLine{op: '-', text: a[i]})
+			i++
+		default:
+			result = append(result, diffLine{op: '+', text: b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		result = append(result, diffLine{op: '-', text: a[i]})
+	}
+	for ; j < len(b); j++ {
+		result = append(result, diffLine{op: '+', text: b[j]})
+	}
+	return result
+}